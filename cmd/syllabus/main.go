@@ -8,13 +8,17 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/michaeldvinci/syllabus/internal/auth"
 	"github.com/michaeldvinci/syllabus/internal/cache"
+	"github.com/michaeldvinci/syllabus/internal/configsync"
 	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/events"
 	"github.com/michaeldvinci/syllabus/internal/handlers"
 	"github.com/michaeldvinci/syllabus/internal/models"
 	"github.com/michaeldvinci/syllabus/internal/scraper"
@@ -24,7 +28,12 @@ import (
 
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatalf("Usage: %s <path-to-yaml>", os.Args[0])
+		log.Fatalf("Usage: %s <path-to-yaml> | migrate status|up", os.Args[0])
+	}
+	
+	if os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
 	}
 	
 	path := os.Args[1]
@@ -44,12 +53,14 @@ func main() {
 		settings.CacheTimeout, settings.LogLevel)
 
 	// Initialize individual providers with fresh HTTP clients to prevent shared state
+	// Each provider gets one rate limiter shared by every worker
 	audibleProvider := &scrapers.AudibleScraperProvider{
 		Enabled: true, 
 		Client: &http.Client{
 			Timeout: 12 * time.Second,
 			Jar:     nil, // Disable cookies to prevent session state bleeding
 		},
+		Limiter: scrapers.NewRateLimiter(time.Duration(settings.AudibleRateLimit) * time.Millisecond),
 	}
 	
 	amazonProvider := &scrapers.AmazonScraperProvider{
@@ -58,6 +69,7 @@ func main() {
 			Timeout: 12 * time.Second,
 			Jar:     nil, // Disable cookies to prevent session state bleeding
 		},
+		Limiter: scrapers.NewRateLimiter(time.Duration(settings.AmazonRateLimit) * time.Millisecond),
 	}
 	
 	// Create provider map for background scraper
@@ -90,8 +102,17 @@ func main() {
 	
 	dbService := database.NewService(db)
 	
-	// Initialize authentication store with file persistence
-	authStore := auth.NewStoreWithFile(filepath.Join(dataDir, "users.json"))
+	// Initialize authentication store on the database
+	authStore := auth.NewStore(auth.NewSQLStorage(db.DB))
+	
+	// Import users from users.json, written by versions before users moved
+	// into the database
+	usersFile := filepath.Join(dataDir, "users.json")
+	if n, err := authStore.ImportUsersFile(usersFile); err != nil {
+		log.Fatalf("failed to import users from %s: %v", usersFile, err)
+	} else if n > 0 {
+		log.Printf("imported %d users from %s", n, usersFile)
+	}
 	
 	// Create default admin user if it doesn't exist
 	_, err = authStore.CreateUserWithRole("admin", "admin", auth.RoleAdmin)
@@ -100,16 +121,72 @@ func main() {
 	}
 	if err == nil {
 		log.Printf("created default admin user (username: admin, password: admin)")
-	} else {
-		log.Printf("loaded existing users from %s", filepath.Join(dataDir, "users.json"))
+	}
+	
+	// Make the default admin choose a new password at login, including on
+	// existing installs that never changed it
+	if _, err := authStore.AuthenticateUser("admin", "admin"); err == nil {
+		if err := authStore.RequirePasswordChange("admin"); err != nil {
+			log.Printf("warning: failed to require a password change for admin: %v", err)
+		}
 	}
 
 	// Initialize authentication middleware and handlers
 	authMiddleware := auth.NewMiddleware(authStore)
 	authHandlers := auth.NewAuthHandlers(authStore)
+	authHandlers.DisablePasswordLogin = settings.DisablePasswordLogin
+	if o := settings.OIDC; o != nil && o.Issuer != "" {
+		provider, err := auth.NewOIDCProvider(context.Background(), auth.OIDCConfig{
+			Issuer:            o.Issuer,
+			ClientID:          o.ClientID,
+			ClientSecret:      o.ClientSecret,
+			RedirectURL:       o.RedirectURL,
+			DisplayName:       o.DisplayName,
+			Scopes:            o.Scopes,
+			UsernameClaim:     o.UsernameClaim,
+			RoleClaim:         o.RoleClaim,
+			AdminValues:       o.AdminValues,
+			EditorValues:      o.EditorValues,
+			DefaultRole:       auth.UserRole(o.DefaultRole),
+			LinkExistingUsers: o.LinkExistingUsers,
+		}, authStore)
+		if err != nil {
+			log.Fatalf("failed to set up single sign-on: %v", err)
+		}
+		authHandlers.OIDC = provider
+		log.Printf("single sign-on enabled with %s", o.Issuer)
+	}
+	if pa := settings.ProxyAuth; pa != nil && len(pa.TrustedProxies) > 0 {
+		proxyAuth, err := auth.NewProxyAuth(auth.ProxyAuthConfig{
+			UserHeader:     pa.UserHeader,
+			GroupsHeader:   pa.GroupsHeader,
+			TrustedProxies: pa.TrustedProxies,
+			AdminGroups:    pa.AdminGroups,
+			EditorGroups:   pa.EditorGroups,
+			DefaultRole:    auth.UserRole(pa.DefaultRole),
+		})
+		if err != nil {
+			log.Fatalf("failed to set up proxy authentication: %v", err)
+		}
+		authMiddleware.Proxy = proxyAuth
+		log.Printf("proxy authentication enabled for %s", strings.Join(pa.TrustedProxies, ", "))
+	}
+	// Client addresses for throttling come from X-Forwarded-For only behind
+	// proxies we trust; those trusted to authenticate users are trusted here too
+	trustedProxies := settings.TrustedProxies
+	if pa := settings.ProxyAuth; pa != nil {
+		trustedProxies = append(slices.Clone(trustedProxies), pa.TrustedProxies...)
+	}
+	if authHandlers.TrustedProxies, err = auth.ParseTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("invalid trusted_proxies: %v", err)
+	}
+	if settings.DisablePasswordLogin && authHandlers.OIDC == nil && authMiddleware.Proxy == nil {
+		log.Fatalf("disable_password_login requires oidc or proxy_auth to be configured")
+	}
 
 	// Initialize background scraper with provider map
-	backgroundScraper := scraper.NewBackgroundScraper(providers, dbService)
+	backgroundScraper := scraper.NewBackgroundScraper(providers, dbService, settings.MaxScrapeAttempts,
+		time.Duration(settings.CaptchaCooldown)*time.Minute)
 	
 	// Initialize application
 	app := &handlers.App{
@@ -117,15 +194,41 @@ func main() {
 		DB:                dbService,
 		Cache:             cache.NewCache(time.Duration(settings.CacheTimeout) * time.Hour),
 		Data:              series,
-		RefreshChan:       make(chan bool, 1),
-		ScraperUpdateCh:   backgroundScraper.GetUpdateChannel(),
+		Events:            events.NewHub(events.DefaultHistory, events.DefaultKeepAlive),
 		BackgroundScraper: backgroundScraper,
 		Settings:          settings,
 	}
+	if settings.WriteBack {
+		app.ConfigWriter = configsync.NewWriter(path)
+		log.Printf("writing series changes back to %s", path)
+	}
+	
+	// Bring the database in line with the config's audiobooks list
+	reconciler := &configsync.Reconciler{
+		DB:      dbService,
+		Scraper: backgroundScraper,
+		Writer:  app.ConfigWriter,
+		DryRun:  settings.SyncDryRun,
+		OnAdded: func(series *database.Series) {
+			watchForAllUsers(dbService, authStore, series)
+		},
+	}
 
 	// Setup authentication routes (no middleware needed)
 	http.HandleFunc("/login", authHandlers.HandleLogin)
+	http.HandleFunc("/login/2fa", authHandlers.HandleTwoFactorLogin)
 	http.HandleFunc("/logout", authHandlers.HandleLogout)
+	http.HandleFunc("/change-password", authMiddleware.RequireAuthForPasswordChange(authHandlers.HandleChangePasswordPage))
+	http.HandleFunc("/api/me/password", authMiddleware.RequireAuthForPasswordChange(authHandlers.HandleChangePassword))
+	http.HandleFunc("/api/me/sessions", authMiddleware.RequireAuth(authHandlers.HandleListSessions))
+	http.HandleFunc("/api/me/sessions/revoke", authMiddleware.RequireAuth(authHandlers.HandleRevokeSession))
+	http.HandleFunc("/api/me/sessions/revoke-all", authMiddleware.RequireAuth(authHandlers.HandleRevokeAllSessions))
+	http.HandleFunc("/api/me/2fa", authMiddleware.RequireAuth(authHandlers.HandleTOTPStatus))
+	http.HandleFunc("/api/me/2fa/setup", authMiddleware.RequireAuth(authHandlers.HandleTOTPSetup))
+	http.HandleFunc("/api/me/2fa/enable", authMiddleware.RequireAuth(authHandlers.HandleTOTPEnable))
+	http.HandleFunc("/api/me/2fa/disable", authMiddleware.RequireAuth(authHandlers.HandleTOTPDisable))
+	http.HandleFunc("GET /auth/oidc/login", authHandlers.HandleOIDCLogin)
+	http.HandleFunc("GET /auth/oidc/callback", authHandlers.HandleOIDCCallback)
 	http.HandleFunc("/api/auth", authMiddleware.OptionalAuth(authHandlers.HandleAPI))
 	
 	// Setup admin-only routes
@@ -133,18 +236,37 @@ func main() {
 	http.HandleFunc("/api/users/create", authMiddleware.RequireAdmin(authHandlers.HandleCreateUser))
 	http.HandleFunc("/api/users/delete", authMiddleware.RequireAdmin(authHandlers.HandleDeleteUser))
 	http.HandleFunc("/api/users/reset-password", authMiddleware.RequireAdmin(authHandlers.HandleResetPassword))
+	http.HandleFunc("/api/users/reset-2fa", authMiddleware.RequireAdmin(authHandlers.HandleResetTOTP))
+	http.HandleFunc("/api/users/require-2fa", authMiddleware.RequireAdmin(authHandlers.HandleRequireAdminTOTP))
+	http.HandleFunc("/api/users/role", authMiddleware.RequireAdmin(authHandlers.HandleSetUserRole))
 
 	// Setup protected HTTP routes with authentication middleware
-	http.HandleFunc("/", authMiddleware.RequireAuth(app.HandleIndex))
-	http.HandleFunc("/api/series", authMiddleware.RequireAuth(app.HandleAPI))
-	http.HandleFunc("/api/scrape-status", authMiddleware.RequireAuth(app.HandleScrapeStatus))
-	http.HandleFunc("/events", authMiddleware.RequireAuth(app.HandleEvents))
+	view := func(next http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware.RequirePermission(auth.PermView, next)
+	}
+	manageSeries := func(next http.HandlerFunc) http.HandlerFunc {
+		return authMiddleware.RequirePermission(auth.PermManageSeries, next)
+	}
+	http.HandleFunc("/", view(app.HandleIndex))
+	http.HandleFunc("/api/series", view(app.HandleAPI))
+	http.HandleFunc("GET /api/series/{id}/history", view(app.HandleSeriesHistory))
+	http.HandleFunc("GET /series/{id}", view(app.HandleSeriesPage))
+	http.HandleFunc("GET /api/progress", view(app.HandleGetProgress))
+	http.HandleFunc("POST /api/progress", view(app.HandleSetProgress))
+	http.HandleFunc("/api/scrape-status", view(app.HandleScrapeStatus))
+	http.HandleFunc("/api/scrape-jobs/cancel", manageSeries(app.HandleCancelScrapeJob))
+	http.HandleFunc("/events", view(app.HandleEvents))
 	http.HandleFunc("/calendar.ics", authMiddleware.RequireICalTokenOrAuth(app.HandleICal))
 	http.HandleFunc("/api/ical-token/regenerate", authMiddleware.RequireAuth(authHandlers.HandleRegenerateICalToken))
-	http.HandleFunc("/refresh", authMiddleware.RequireAuth(app.HandleRefresh))
-	http.HandleFunc("/api/auto-refresh", authMiddleware.RequireAuth(app.HandleAutoRefresh))
-	http.HandleFunc("/api/add-series", authMiddleware.RequireAuth(app.HandleAddSeries))
-	http.HandleFunc("/api/delete-series", authMiddleware.RequireAuth(app.HandleDeleteSeries))
+	http.HandleFunc("/api/tokens", authMiddleware.RequireAuth(authHandlers.HandleListTokens))
+	http.HandleFunc("/api/tokens/create", authMiddleware.RequireAuth(authHandlers.HandleCreateToken))
+	http.HandleFunc("/api/tokens/revoke", authMiddleware.RequireAuth(authHandlers.HandleRevokeToken))
+	http.HandleFunc("/refresh", manageSeries(app.HandleRefresh))
+	http.HandleFunc("GET /api/auto-refresh", view(app.HandleAutoRefresh))
+	http.HandleFunc("POST /api/auto-refresh", authMiddleware.RequirePermission(auth.PermManageSettings, app.HandleAutoRefresh))
+	http.HandleFunc("/api/add-series", manageSeries(app.HandleAddSeries))
+	http.HandleFunc("/api/delete-series", manageSeries(app.HandleDeleteSeries))
+	app.RegisterAPIv1(http.DefaultServeMux, authMiddleware.RequirePermission)
 	
 	// Serve static files (favicon, logo) - check for local vs docker paths
 	staticDir := "./app/res/"
@@ -178,7 +300,9 @@ func main() {
 		log.Fatalf("failed to get absolute path: %v", err)
 	}
 	
-	err = watcher.Add(configPath)
+	// Watch the directory rather than the file, since editors and write-back
+	// save by replacing the file
+	err = watcher.Add(filepath.Dir(configPath))
 	if err != nil {
 		log.Fatalf("failed to watch config file: %v", err)
 	}
@@ -191,7 +315,10 @@ func main() {
 				if !ok {
 					return
 				}
-				if event.Op&fsnotify.Write == fsnotify.Write {
+				if filepath.Clean(event.Name) != configPath {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
 					log.Printf("config file modified: %s", event.Name)
 					
 					// Reload config
@@ -204,16 +331,14 @@ func main() {
 					newSeries := utils.ToSeriesIDs(newCfg.Audiobooks)
 					log.Printf("processing config update with %d total series", len(newSeries))
 					
-					// Populate database with new series (upsert operation)
-					if err := populateDatabase(dbService, newSeries); err != nil {
-						log.Printf("error updating database with new series: %v", err)
-					} else {
-						log.Printf("updated database with new series")
+					// Add, update and remove series to match the file; the
+					// reconciler queues scrapes for whatever it changed
+					changes, err := reconciler.Reconcile(newSeries)
+					if err != nil {
+						log.Printf("error syncing database with config: %v", err)
+					} else if len(changes) > 0 && !settings.SyncDryRun {
+						app.Events.Publish(events.TypeRefresh, nil)
 					}
-					
-					// Use incremental update to add only new entries
-					app.UpdateDataIncremental(newSeries)
-					log.Printf("incremental config update initiated")
 				}
 			case err, ok := <-watcher.Errors:
 				if !ok {
@@ -224,20 +349,33 @@ func main() {
 		}
 	}()
 	
+	// Give existing users the series they saw before watchlists existed
+	users, err := authStore.ListUsers()
+	if err != nil {
+		log.Fatalf("failed to list users: %v", err)
+	}
+	var userIDs []string
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	if err := dbService.SeedWatchlists(userIDs); err != nil {
+		log.Printf("warning: failed to seed watchlists: %v", err)
+	}
+	
 	// Populate database with series from config
-	log.Printf("populating database with %d series from config...", len(series))
-	if err := populateDatabase(dbService, series); err != nil {
-		log.Printf("warning: failed to populate database: %v", err)
+	log.Printf("syncing database with %d series from config...", len(series))
+	if _, err := reconciler.Reconcile(series); err != nil {
+		log.Printf("warning: failed to sync database with config: %v", err)
 	}
 	
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	
-	// Clean up any stale running jobs from previous session
-	log.Printf("cleaning up stale scrape jobs...")
-	if err := backgroundScraper.CleanupStaleJobs(); err != nil {
-		log.Printf("warning: failed to cleanup stale jobs: %v", err)
+	// Resume any jobs left over from the previous session
+	log.Printf("recovering interrupted scrape jobs...")
+	if err := backgroundScraper.RecoverJobs(); err != nil {
+		log.Printf("warning: failed to recover scrape jobs: %v", err)
 	}
 	
 	// Start background scraper
@@ -245,6 +383,13 @@ func main() {
 	backgroundScraper.Start(ctx, settings.DefaultWorkers) // Use config setting for worker threads
 	defer backgroundScraper.Stop()
 	
+	// Send scrape progress to the clients watching each series
+	go func() {
+		for update := range backgroundScraper.GetUpdateChannel() {
+			app.Events.PublishSeries(events.TypeScrape, update.SeriesID, update)
+		}
+	}()
+	
 	// Queue initial scraping jobs for all series
 	if err := backgroundScraper.QueueAllSeriesUpdate(); err != nil {
 		log.Printf("warning: failed to queue initial scraping jobs: %v", err)
@@ -253,11 +398,17 @@ func main() {
 	// Start auto-refresh loop
 	app.StartAutoRefresh()
 	
+	// Reject cross-site form posts and fetches made with a visitor's cookies
+	handler, err := auth.CSRFProtect(http.DefaultServeMux, settings.TrustedOrigins)
+	if err != nil {
+		log.Fatalf("failed to set up cross-origin protection: %v", err)
+	}
+
 	// Start server in background
 	addr := fmt.Sprintf(":%d", settings.ServerPort)
 	log.Printf("starting server on %s ...", addr)
 	go func() {
-		log.Fatal(http.ListenAndServe(addr, nil))
+		log.Fatal(http.ListenAndServe(addr, handler))
 	}()
 	
 	// No longer need cache warmup - data comes from database instantly!
@@ -273,15 +424,17 @@ func main() {
 	cancel() // This will stop the background scraper
 }
 
-// populateDatabase ensures all series from config are in the database
-func populateDatabase(dbService *database.Service, series []models.SeriesIDs) error {
-	for _, s := range series {
-		_, err := dbService.UpsertSeries(s.Title, s.AudibleID, s.AudibleURL, s.AmazonASIN)
-		if err != nil {
-			log.Printf("error upserting series %s: %v", s.Title, err)
-			continue
+// watchForAllUsers adds a series the config introduces to every user's
+// watchlist, since books.yaml describes the library shared by everyone
+func watchForAllUsers(dbService *database.Service, authStore *auth.Store, series *database.Series) {
+	users, err := authStore.ListUsers()
+	if err != nil {
+		log.Printf("error listing users for series %s: %v", series.Title, err)
+		return
+	}
+	for _, user := range users {
+		if err := dbService.WatchSeries(user.ID, series.ID); err != nil {
+			log.Printf("error adding series %s to %s's watchlist: %v", series.Title, user.Username, err)
 		}
-		log.Printf("ensured series %s exists in database", s.Title)
 	}
-	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/michaeldvinci/syllabus/internal/database"
+)
+
+// runMigrate implements `syllabus migrate status|up`
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dataDir := fs.String("data", "./data", "directory containing syllabus.db")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s migrate [-data dir] status|up\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	switch fs.Arg(0) {
+	case "status":
+		migrateStatus(*dataDir)
+	case "up":
+		db, err := database.Open(*dataDir)
+		if err != nil {
+			log.Fatalf("failed to open database: %v", err)
+		}
+		defer db.Close()
+
+		n, err := db.MigrateUp()
+		if err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+		fmt.Printf("applied %d migration(s)\n", n)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// migrateStatus prints each migration and when it was applied. It opens the
+// database read-only, so it never creates or adopts a database itself.
+func migrateStatus(dataDir string) {
+	db, err := database.OpenReadOnly(dataDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Printf("database not initialized: no syllabus.db in %s\n", dataDir)
+		return
+	}
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	status, err := db.MigrationStatus()
+	if err != nil {
+		log.Fatalf("failed to read migration status: %v", err)
+	}
+
+	applied, legacy := 0, 0
+	for _, s := range status {
+		if s.AppliedAt != nil {
+			applied++
+		} else if s.Legacy {
+			legacy++
+		}
+	}
+	switch {
+	case legacy > 0:
+		fmt.Println("legacy database: `migrate up` will record the migrations it already has")
+	case applied == 0:
+		fmt.Println("database not initialized")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, s := range status {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		} else if s.Legacy {
+			applied = "legacy"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	w.Flush()
+}
@@ -47,10 +47,10 @@ docker compose up -d`}</code></pre>
 cd syllabus
 
 # Run directly
-go run cmd/syllabus/main.go config/books.yaml
+go run ./cmd/syllabus config/books.yaml
 
 # Or build first
-go build -o syllabus cmd/syllabus/main.go
+go build -o syllabus ./cmd/syllabus
 ./syllabus config/books.yaml`}</code></pre>
 
       <h2>Data Persistence</h2>
@@ -97,7 +97,7 @@ services:
       <h3>Local Development</h3>
       <pre><code>{`# Set environment variable
 export SYLLABUS_SERVER_PORT=9000
-go run cmd/syllabus/main.go config/books.yaml
+go run ./cmd/syllabus config/books.yaml
 
 # Or in your YAML config
 settings:
@@ -3,11 +3,18 @@ module github.com/michaeldvinci/syllabus
 go 1.26
 
 require (
+	github.com/coreos/go-oidc/v3 v3.18.0
 	github.com/fsnotify/fsnotify v1.7.0
+	github.com/go-jose/go-jose/v4 v4.1.4
 	github.com/google/uuid v1.3.0
 	github.com/mattn/go-sqlite3 v1.14.18
+	github.com/pquerna/otp v1.5.0
 	golang.org/x/crypto v0.14.0
+	golang.org/x/oauth2 v0.36.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require golang.org/x/sys v0.13.0 // indirect
+require (
+	github.com/boombuler/barcode v1.0.1-0.20190219062509-6c824513bacc // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
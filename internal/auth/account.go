@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+)
+
+// HandleChangePasswordPage serves the change password page, which users who
+// must change their password are sent to, and handles its form
+func (h *AuthHandlers) HandleChangePasswordPage(w http.ResponseWriter, r *http.Request) {
+	user, ok := GetUserFromContext(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		tmpl := template.Must(template.New("login").Parse(LoginHTML))
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := tmpl.Execute(w, loginPage{ChangePassword: true, Username: user.Username}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case "POST":
+		if _, ok := sessionUser(w, r, "Passwords can only be changed from a logged-in session"); !ok {
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("new_password") != r.FormValue("confirm_password") {
+			http.Redirect(w, r, "/change-password?error=mismatch", http.StatusSeeOther)
+			return
+		}
+		req := ChangePasswordRequest{
+			CurrentPassword: r.FormValue("current_password"),
+			NewPassword:     r.FormValue("new_password"),
+		}
+		if _, message := h.changePassword(r, user, req); message != "" {
+			http.Redirect(w, r, "/change-password?error="+message, http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleChangePassword changes the current user's password
+func (h *AuthHandlers) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := sessionUser(w, r, "Passwords can only be changed from a logged-in session")
+	if !ok {
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	status, message := h.changePassword(r, user, req)
+	w.Header().Set("Content-Type", "application/json")
+	if message != "" {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": changePasswordMessages[message],
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Password changed",
+	})
+}
+
+// changePasswordMessages describes the error codes returned by changePassword
+var changePasswordMessages = map[string]string{
+	"required":  "Current and new password are required",
+	"current":   "Current password is incorrect",
+	"unchanged": "New password must differ from the current one",
+	"failed":    "Failed to change password",
+}
+
+// changePassword applies a password change for the request's session. On
+// failure it returns a status and an error code from changePasswordMessages.
+func (h *AuthHandlers) changePassword(r *http.Request, user *User, req ChangePasswordRequest) (int, string) {
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return http.StatusBadRequest, "required"
+	}
+
+	currentToken := ""
+	if cookie, err := r.Cookie("session_token"); err == nil {
+		currentToken = cookie.Value
+	}
+
+	switch err := h.store.ChangePassword(user.ID, req.CurrentPassword, req.NewPassword, currentToken); err {
+	case nil:
+		return http.StatusOK, ""
+	case ErrInvalidPassword:
+		return http.StatusForbidden, "current"
+	case ErrPasswordUnchanged:
+		return http.StatusBadRequest, "unchanged"
+	default:
+		return http.StatusInternalServerError, "failed"
+	}
+}
+
+// HandleListSessions lists the current user's sessions
+func (h *AuthHandlers) HandleListSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := sessionUser(w, r, "Sessions can only be managed from a logged-in session")
+	if !ok {
+		return
+	}
+
+	sessions, err := h.store.ListSessions(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	currentToken := ""
+	if cookie, err := r.Cookie("session_token"); err == nil {
+		currentToken = cookie.Value
+	}
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, SessionInfo{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.Token == currentToken,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ListSessionsResponse{Sessions: infos})
+}
+
+// HandleRevokeSession ends one of the current user's sessions
+func (h *AuthHandlers) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := sessionUser(w, r, "Sessions can only be managed from a logged-in session")
+	if !ok {
+		return
+	}
+
+	var req RevokeSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Session ID is required",
+		})
+		return
+	}
+
+	if err := h.store.RevokeSession(user.ID, req.ID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Session not found",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Session revoked",
+	})
+}
+
+// HandleRevokeAllSessions logs the current user out everywhere, including
+// the session making the request
+func (h *AuthHandlers) HandleRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := sessionUser(w, r, "Sessions can only be managed from a logged-in session")
+	if !ok {
+		return
+	}
+
+	n, err := h.store.RevokeAllSessions(user.ID, "")
+	if err != nil {
+		http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+	clearSessionCookie(w, r)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Logged out everywhere",
+		"revoked": n,
+	})
+}
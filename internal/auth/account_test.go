@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sessionRequest returns a request carrying a session cookie, with the user
+// resolved by the middleware the way the server would
+func sessionRequest(method, path, body, token string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session_token", Value: token})
+	return req
+}
+
+func TestForcedPasswordChange(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	if _, err := store.CreateUserWithRole("admin", "admin", RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RequirePasswordChange("admin"); err != nil {
+		t.Fatal(err)
+	}
+	admin, _ := store.GetUser("admin")
+	laptop, _ := store.CreateSession(admin.ID, "Firefox", "10.0.0.2")
+	phone, _ := store.CreateSession(admin.ID, "Safari", "10.0.0.3")
+
+	m := NewMiddleware(store)
+	h := NewAuthHandlers(store)
+	serve := func(handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	// Everything but the password change is blocked until it's done
+	if rec := serve(m.RequireAuth(noop), sessionRequest("GET", "/api/series", "", laptop.Token)); rec.Code != http.StatusForbidden {
+		t.Errorf("API before password change = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	page := sessionRequest("GET", "/", "", laptop.Token)
+	page.Header.Del("Accept")
+	if rec := serve(m.RequireAuth(noop), page); rec.Header().Get("Location") != "/change-password" {
+		t.Errorf("page before password change redirected to %q, want /change-password", rec.Header().Get("Location"))
+	}
+
+	changePassword := m.RequireAuthForPasswordChange(h.HandleChangePassword)
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"wrong current password", `{"current_password":"nope","new_password":"s3cret"}`, http.StatusForbidden},
+		{"same password", `{"current_password":"admin","new_password":"admin"}`, http.StatusBadRequest},
+		{"missing new password", `{"current_password":"admin"}`, http.StatusBadRequest},
+		{"valid change", `{"current_password":"admin","new_password":"s3cret"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(changePassword, sessionRequest("POST", "/api/me/password", tt.body, laptop.Token))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	// The flag is cleared, the new password works and other sessions ended
+	if rec := serve(m.RequireAuth(noop), sessionRequest("GET", "/api/series", "", laptop.Token)); rec.Code != http.StatusOK {
+		t.Errorf("API after password change = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if _, err := store.AuthenticateUser("admin", "s3cret"); err != nil {
+		t.Errorf("new password rejected: %v", err)
+	}
+	if _, err := store.GetSession(phone.Token); err != ErrSessionNotFound {
+		t.Errorf("other session after password change = %v, want ErrSessionNotFound", err)
+	}
+
+	// An admin reset forces another change and ends every session
+	if err := store.ResetUserPassword("admin", "temp"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetSession(laptop.Token); err != ErrSessionNotFound {
+		t.Errorf("session after reset = %v, want ErrSessionNotFound", err)
+	}
+	if admin, _ := store.GetUser("admin"); !admin.MustChangePassword {
+		t.Error("reset password doesn't require a change")
+	}
+}
+
+func TestChangePasswordPageRefusesTokens(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	user, err := store.CreateUserWithRole("erin", "old-pass", RoleEditor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, secret, err := store.CreateAPIToken(user, "cron", ScopeManage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _ := store.CreateSession(user.ID, "Firefox", "10.0.0.2")
+
+	page := NewMiddleware(store).RequireAuthForPasswordChange(NewAuthHandlers(store).HandleChangePasswordPage)
+	form := func() *http.Request {
+		req := httptest.NewRequest("POST", "/change-password",
+			strings.NewReader("current_password=old-pass&new_password=new-pass&confirm_password=new-pass"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	req := form()
+	req.Header.Set("Authorization", "Bearer "+secret)
+	rec := httptest.NewRecorder()
+	page(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("form post with an API token = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if _, err := store.AuthenticateUser("erin", "old-pass"); err != nil {
+		t.Errorf("API token changed the password: %v", err)
+	}
+
+	req = form()
+	req.AddCookie(&http.Cookie{Name: "session_token", Value: session.Token})
+	rec = httptest.NewRecorder()
+	page(rec, req)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/" {
+		t.Errorf("form post from a session = %d to %q, want %d to /", rec.Code, rec.Header().Get("Location"), http.StatusSeeOther)
+	}
+	if _, err := store.AuthenticateUser("erin", "new-pass"); err != nil {
+		t.Errorf("new password rejected: %v", err)
+	}
+}
+
+func TestSessionManagement(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	user, _ := store.CreateUser("alice", "secret")
+	laptop, _ := store.CreateSession(user.ID, "Firefox", "10.0.0.2")
+	phone, _ := store.CreateSession(user.ID, "Safari", "10.0.0.3")
+	tablet, _ := store.CreateSession(user.ID, "Chrome", "10.0.0.4")
+
+	m := NewMiddleware(store)
+	h := NewAuthHandlers(store)
+
+	rec := httptest.NewRecorder()
+	m.RequireAuth(h.HandleListSessions)(rec, sessionRequest("GET", "/api/me/sessions", "", laptop.Token))
+	var list ListSessionsResponse
+	json.NewDecoder(rec.Body).Decode(&list)
+	if len(list.Sessions) != 3 {
+		t.Fatalf("sessions = %d, want 3", len(list.Sessions))
+	}
+	for _, s := range list.Sessions {
+		if s.Current != (s.UserAgent == "Firefox") || s.IP == "" {
+			t.Errorf("session %+v: want only Firefox marked current, with an IP", s)
+		}
+	}
+	if strings.Contains(rec.Body.String(), laptop.Token) {
+		t.Error("session list exposes session tokens")
+	}
+
+	// Revoke one session by ID
+	rec = httptest.NewRecorder()
+	m.RequireAuth(h.HandleRevokeSession)(rec, sessionRequest("POST", "/api/me/sessions/revoke", `{"id":"`+phone.ID+`"}`, laptop.Token))
+	if rec.Code != http.StatusOK {
+		t.Errorf("revoke = %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := store.GetSession(phone.Token); err != ErrSessionNotFound {
+		t.Errorf("revoked session = %v, want ErrSessionNotFound", err)
+	}
+
+	// Log out everywhere, including here
+	rec = httptest.NewRecorder()
+	m.RequireAuth(h.HandleRevokeAllSessions)(rec, sessionRequest("POST", "/api/me/sessions/revoke-all", "", laptop.Token))
+	if rec.Code != http.StatusOK {
+		t.Errorf("revoke all = %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, s := range []*Session{laptop, tablet} {
+		if _, err := store.GetSession(s.Token); err != ErrSessionNotFound {
+			t.Errorf("session %s after logging out everywhere = %v", s.UserAgent, err)
+		}
+	}
+}
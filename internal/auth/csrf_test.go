@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSRFProtect(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler, err := CSRFProtect(ok, []string{"https://books.example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CSRFProtect(ok, []string{"books.example.com"}); err == nil {
+		t.Errorf("origin without a scheme accepted")
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{"same-origin fetch", "POST", "/api/add-series",
+			map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://syllabus.test"}, http.StatusOK},
+		{"cross-site form post", "POST", "/api/delete-series",
+			map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, http.StatusForbidden},
+		{"cross-site login", "POST", "/login",
+			map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, http.StatusForbidden},
+		{"same-site subdomain", "POST", "/api/users/create",
+			map[string]string{"Sec-Fetch-Site": "same-site", "Origin": "https://other.syllabus.test"}, http.StatusForbidden},
+		{"older browser, foreign origin", "POST", "/refresh",
+			map[string]string{"Origin": "https://evil.example"}, http.StatusForbidden},
+		{"older browser, matching origin", "POST", "/refresh",
+			map[string]string{"Origin": "http://syllabus.test"}, http.StatusOK},
+		{"trusted origin behind a proxy", "POST", "/refresh",
+			map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://books.example.com"}, http.StatusOK},
+		{"cross-site navigation", "GET", "/",
+			map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, http.StatusOK},
+		{"script with an API token", "POST", "/refresh",
+			map[string]string{"Authorization": "Bearer syl_test"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://syllabus.test"+tt.path, nil)
+			req.AddCookie(&http.Cookie{Name: "session_token", Value: "victim"})
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -3,17 +3,47 @@ package auth
 import (
 	"encoding/json"
 	"html/template"
+	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // AuthHandlers provides authentication-related HTTP handlers
 type AuthHandlers struct {
-	store *Store
+	store    *Store
+	throttle *LoginThrottle
+
+	// OIDC, if set, enables single sign-on at /auth/oidc/login
+	OIDC *OIDCProvider
+
+	// DisablePasswordLogin rejects username/password logins
+	DisablePasswordLogin bool
+
+	// TrustedProxies are the proxies whose X-Forwarded-For header is believed
+	// when working out a client's address for throttling and sessions
+	TrustedProxies []netip.Prefix
+}
+
+// loginPage is the data for LoginHTML
+type loginPage struct {
+	PasswordLogin  bool
+	SSOName        string // Empty when single sign-on is off
+	ChangePassword bool   // Show the change password form instead
+	Username       string // The user changing their password
+
+	TwoFactor     bool         // Ask for the second factor of a login
+	TOTPSecret    string       // Set when the user must enroll first
+	TOTPQRCode    template.URL // PNG data URL of the secret's URI
+	RecoveryCodes []string     // Codes to show after enrolling
+	Next          string       // Where to continue after the recovery codes
 }
 
 // NewAuthHandlers creates new authentication handlers
 func NewAuthHandlers(store *Store) *AuthHandlers {
-	return &AuthHandlers{store: store}
+	return &AuthHandlers{store: store, throttle: NewLoginThrottle()}
 }
 
 // HandleLogin serves the login page and handles login POST requests
@@ -38,9 +68,22 @@ func (h *AuthHandlers) serveLoginPage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	page := loginPage{PasswordLogin: !h.DisablePasswordLogin}
+	if h.OIDC != nil {
+		page.SSOName = h.OIDC.DisplayName()
+	}
+	if cookie, err := r.Cookie(mfaChallengeCookie); err == nil && r.URL.Query().Get("step") == "2fa" {
+		page = loginPage{TwoFactor: true}
+		if key := h.store.PendingEnrollment(cookie.Value); key != nil {
+			setup := newTOTPSetupResponse(key)
+			page.TOTPSecret = setup.Secret
+			page.TOTPQRCode = template.URL(setup.QRCode)
+		}
+	}
+
 	tmpl := template.Must(template.New("login").Parse(LoginHTML))
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := tmpl.Execute(w, nil); err != nil {
+	if err := tmpl.Execute(w, page); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
@@ -51,7 +94,22 @@ func (h *AuthHandlers) handleLoginPost(w http.ResponseWriter, r *http.Request) {
 
 	// Handle both JSON and form data
 	contentType := r.Header.Get("Content-Type")
-	if contentType == "application/json" {
+	asJSON := contentType == "application/json"
+
+	if h.DisablePasswordLogin {
+		if asJSON {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(LoginResponse{
+				Success: false,
+				Message: "Password login is disabled",
+			})
+			return
+		}
+		http.Redirect(w, r, "/login?error=disabled", http.StatusSeeOther)
+		return
+	}
+	if asJSON {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
 			return
@@ -65,15 +123,22 @@ func (h *AuthHandlers) handleLoginPost(w http.ResponseWriter, r *http.Request) {
 		req.Password = r.FormValue("password")
 	}
 
+	ip := h.clientIP(r)
+	if wait, ok := h.throttle.Allow(ip, req.Username); !ok {
+		loginLocked(w, r, wait, asJSON)
+		return
+	}
+
 	// Authenticate user
 	user, err := h.store.AuthenticateUser(req.Username, req.Password)
 	if err != nil {
+		h.throttle.Failure(ip, req.Username)
 		response := LoginResponse{
 			Success: false,
 			Message: "Invalid username or password",
 		}
 
-		if contentType == "application/json" {
+		if asJSON {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(response)
@@ -85,14 +150,75 @@ func (h *AuthHandlers) handleLoginPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.store.NeedsSecondFactor(user) {
+		h.beginTwoFactorLogin(w, r, user, asJSON)
+		return
+	}
+	h.startSession(w, r, user, asJSON, nil)
+}
+
+// startSession logs a user in once their credentials are checked. Recovery
+// codes from enrolling during login are shown before continuing.
+func (h *AuthHandlers) startSession(w http.ResponseWriter, r *http.Request, user *User, asJSON bool, recoveryCodes []string) {
 	// Create session
-	session, err := h.store.CreateSession(user.ID)
+	ip := h.clientIP(r)
+	session, err := h.store.CreateSession(user.ID, r.UserAgent(), ip)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Set session cookie
+	setSessionCookie(w, r, session)
+	h.throttle.Success(ip, user.Username)
+
+	response := LoginResponse{
+		Success:       true,
+		Message:       "Login successful",
+		Token:         session.Token,
+		User:          user,
+		RecoveryCodes: recoveryCodes,
+	}
+
+	if asJSON {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	// Redirect to home page, or to choose a new password first
+	next := "/"
+	if user.MustChangePassword {
+		next = "/change-password"
+	}
+	if len(recoveryCodes) > 0 {
+		tmpl := template.Must(template.New("login").Parse(LoginHTML))
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := tmpl.Execute(w, loginPage{RecoveryCodes: recoveryCodes, Next: next}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// loginLocked refuses a login attempt while its address or username is
+// locked out after too many failures
+func loginLocked(w http.ResponseWriter, r *http.Request, wait time.Duration, asJSON bool) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+	if asJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(LoginResponse{
+			Success: false,
+			Message: "Too many failed login attempts; try again later",
+		})
+		return
+	}
+	http.Redirect(w, r, "/login?error=locked", http.StatusSeeOther)
+}
+
+// setSessionCookie sets the session cookie for a newly created session
+func setSessionCookie(w http.ResponseWriter, r *http.Request, session *Session) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session_token",
 		Value:    session.Token,
@@ -102,22 +228,49 @@ func (h *AuthHandlers) handleLoginPost(w http.ResponseWriter, r *http.Request) {
 		Secure:   r.TLS != nil,
 		SameSite: http.SameSiteStrictMode,
 	})
+}
 
-	response := LoginResponse{
-		Success: true,
-		Message: "Login successful",
-		Token:   session.Token,
-		User:    user,
-	}
+// clearSessionCookie removes the session cookie
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_token",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
 
-	if contentType == "application/json" {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-		return
+// clientIP returns the address of the client that sent a request. When it
+// came through trusted proxies, that's the last address in X-Forwarded-For
+// that isn't one of them; the header is ignored from anyone else, since
+// clients can set it to anything.
+func (h *AuthHandlers) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrustedAddr(h.TrustedProxies, host) {
+		return host
 	}
 
-	// Redirect to home page
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break // Garbage from a client; trust nothing further left
+		}
+		host = hop
+		if !isTrustedAddr(h.TrustedProxies, hop) {
+			break
+		}
+	}
+	return host
 }
 
 // HandleLogout handles logout requests
@@ -129,16 +282,7 @@ func (h *AuthHandlers) HandleLogout(w http.ResponseWriter, r *http.Request) {
 		h.store.DeleteSession(cookie.Value)
 	}
 
-	// Clear session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_token",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   r.TLS != nil,
-		SameSite: http.SameSiteStrictMode,
-	})
+	clearSessionCookie(w, r)
 
 	// Check if this is an API request
 	if r.Header.Get("Accept") == "application/json" {
@@ -202,9 +346,9 @@ func (h *AuthHandlers) HandleCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Default role is user if not specified
+	// Default role is viewer if not specified
 	if req.Role == "" {
-		req.Role = RoleUser
+		req.Role = RoleViewer
 	}
 
 	// Create user
@@ -217,6 +361,9 @@ func (h *AuthHandlers) HandleCreateUser(w http.ResponseWriter, r *http.Request)
 		case ErrUserExists:
 			statusCode = http.StatusConflict
 			message = "Username already exists"
+		case ErrInvalidRole:
+			statusCode = http.StatusBadRequest
+			message = "Role must be viewer, editor or admin"
 		default:
 			statusCode = http.StatusInternalServerError
 			message = "Failed to create user"
@@ -246,11 +393,16 @@ func (h *AuthHandlers) HandleListUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	users := h.store.ListUsers()
+	users, err := h.store.ListUsers()
+	if err != nil {
+		http.Error(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ListUsersResponse{
-		Users: users,
+		Users:           users,
+		RequireAdmin2FA: h.store.AdminTOTPRequired(),
 	})
 }
 
@@ -386,6 +538,71 @@ func (h *AuthHandlers) HandleDeleteUser(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// HandleSetUserRole changes a user's role (admin only). Admins can't change
+// their own role, so there is always one left to undo a mistake.
+func (h *AuthHandlers) HandleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Username string   `json:"username"`
+		Role     UserRole `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Username and role are required",
+		})
+		return
+	}
+
+	if current, ok := GetUserFromContext(r); ok && current.Username == req.Username {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Cannot change your own role",
+		})
+		return
+	}
+
+	user, err := h.store.SetUserRole(req.Username, req.Role)
+	if err != nil {
+		var statusCode int
+		var message string
+
+		switch err {
+		case ErrUserNotFound:
+			statusCode = http.StatusNotFound
+			message = "User not found"
+		case ErrInvalidRole:
+			statusCode = http.StatusBadRequest
+			message = "Role must be viewer, editor or admin"
+		default:
+			statusCode = http.StatusInternalServerError
+			message = "Failed to change role"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Role changed to " + string(user.Role),
+	})
+}
+
 // HandleRegenerateICalToken regenerates the iCal token for the current user
 func (h *AuthHandlers) HandleRegenerateICalToken(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -422,12 +639,168 @@ func (h *AuthHandlers) HandleRegenerateICalToken(w http.ResponseWriter, r *http.
 	})
 }
 
+// tokenUser returns the session user for API token management. Tokens can't
+// be used to manage tokens, so a leaked read-only token can't mint a broader one.
+func tokenUser(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	return sessionUser(w, r, "API tokens can only be managed from a logged-in session")
+}
+
+// sessionUser returns the request's user, refusing requests authenticated by
+// an API token with message
+func sessionUser(w http.ResponseWriter, r *http.Request, message string) (*User, bool) {
+	user, ok := GetUserFromContext(r)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Authentication required",
+		})
+		return nil, false
+	}
+	if _, viaToken := GetTokenFromContext(r); viaToken {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": message,
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// HandleListTokens lists the current user's API tokens
+func (h *AuthHandlers) HandleListTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := tokenUser(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.store.ListAPITokens(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to list tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ListTokensResponse{
+		Tokens: tokens,
+	})
+}
+
+// HandleCreateToken mints an API token for the current user
+func (h *AuthHandlers) HandleCreateToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := tokenUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(CreateTokenResponse{
+			Success: false,
+			Message: "Invalid request format",
+		})
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(CreateTokenResponse{
+			Success: false,
+			Message: "Token name is required",
+		})
+		return
+	}
+
+	token, secret, err := h.store.CreateAPIToken(user, req.Name, req.Scope)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		message := "Failed to create token"
+		if err == ErrInvalidScope {
+			statusCode = http.StatusBadRequest
+			message = "Scope must be read, manage or admin, and no broader than your role allows"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(CreateTokenResponse{
+			Success: false,
+			Message: message,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateTokenResponse{
+		Success: true,
+		Message: "Token created; copy it now, it won't be shown again",
+		Secret:  secret,
+		Token:   token,
+	})
+}
+
+// HandleRevokeToken revokes one of the current user's API tokens
+func (h *AuthHandlers) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := tokenUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req RevokeTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Token ID is required",
+		})
+		return
+	}
+
+	if err := h.store.RevokeAPIToken(user.ID, req.ID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Token not found",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Token revoked",
+	})
+}
+
 const LoginHTML = `
 <!doctype html>
 <html>
 <head>
 <meta charset="utf-8">
-<title>syllabus - Login</title>
+<title>syllabus - {{if .ChangePassword}}Change Password{{else if .RecoveryCodes}}Recovery Codes{{else}}Login{{end}}</title>
 <meta name="viewport" content="width=device-width, initial-scale=1">
 <style>
 :root {
@@ -539,6 +912,52 @@ input[type="password"]:focus {
   background: var(--primary-hover);
 }
 
+.sso-btn {
+  display: block;
+  text-align: center;
+  text-decoration: none;
+}
+
+.divider {
+  margin: 1rem 0;
+  text-align: center;
+  color: var(--muted);
+  font-size: 0.875rem;
+}
+
+.divider a {
+  color: var(--muted);
+}
+
+.notice {
+  margin: 0 0 1rem;
+  color: var(--muted);
+  font-size: 0.875rem;
+  text-align: center;
+}
+
+.qr-code {
+  display: block;
+  margin: 0 auto 1rem;
+  background: #ffffff;
+  padding: 0.5rem;
+  border-radius: 0.375rem;
+}
+
+.secret,
+.recovery-codes {
+  font-family: ui-monospace, monospace;
+  text-align: center;
+  word-break: break-all;
+}
+
+.recovery-codes {
+  list-style: none;
+  padding: 0;
+  margin: 0 0 1rem;
+  columns: 2;
+}
+
 .error {
   color: #dc2626;
   font-size: 0.875rem;
@@ -609,7 +1028,58 @@ input[type="password"]:focus {
       <h1>syllabus</h1>
     </div>
     
+    {{if .SSOName}}
+    <a href="/auth/oidc/login" class="btn sso-btn">Sign in with {{.SSOName}}</a>
+    {{if .PasswordLogin}}<div class="divider">or</div>{{end}}
+    {{end}}
+
+    {{if .ChangePassword}}
+    <form method="POST" action="/change-password">
+      <p class="notice">Choose a new password for <strong>{{.Username}}</strong> to continue.</p>
+      <div class="form-group">
+        <label for="current_password">Current password</label>
+        <input type="password" id="current_password" name="current_password" autocomplete="current-password" required>
+      </div>
+
+      <div class="form-group">
+        <label for="new_password">New password</label>
+        <input type="password" id="new_password" name="new_password" autocomplete="new-password" required>
+      </div>
+
+      <div class="form-group">
+        <label for="confirm_password">Confirm new password</label>
+        <input type="password" id="confirm_password" name="confirm_password" autocomplete="new-password" required>
+      </div>
+
+      <button type="submit" class="btn">Change Password</button>
+      <div class="divider"><a href="/logout">Sign out</a></div>
+    {{else if .TwoFactor}}
+    <form method="POST" action="/login/2fa">
+      {{if .TOTPSecret}}
+      <p class="notice">Two-factor authentication is required for your account. Scan this code with an authenticator app, or enter the secret manually.</p>
+      {{if .TOTPQRCode}}<img class="qr-code" src="{{.TOTPQRCode}}" alt="QR code" width="200" height="200">{{end}}
+      <p class="notice secret">{{.TOTPSecret}}</p>
+      {{else}}
+      <p class="notice">Enter the code from your authenticator app, or a recovery code.</p>
+      {{end}}
+      <div class="form-group">
+        <label for="code">Code</label>
+        <input type="text" id="code" name="code" inputmode="numeric" autocomplete="one-time-code" autofocus required>
+      </div>
+
+      <button type="submit" class="btn">Verify</button>
+      <div class="divider"><a href="/login">Start over</a></div>
+    {{else if .RecoveryCodes}}
+    <form method="GET" action="{{.Next}}">
+      <p class="notice">Two-factor authentication is on. Store these recovery codes somewhere safe; each signs you in once if you lose your device. They won't be shown again.</p>
+      <ul class="recovery-codes">
+        {{range .RecoveryCodes}}<li>{{.}}</li>{{end}}
+      </ul>
+      <button type="submit" class="btn">Continue</button>
+    {{else}}
     <form method="POST" action="/login">
+    {{end}}
+      {{if .PasswordLogin}}
       <div class="form-group">
         <label for="username">Username</label>
         <input type="text" id="username" name="username" required>
@@ -621,6 +1091,7 @@ input[type="password"]:focus {
       </div>
       
       <button type="submit" class="btn">Sign In</button>
+      {{end}}
       
       <script>
         // Theme management
@@ -670,10 +1141,25 @@ input[type="password"]:focus {
 
         // Check for error in URL params
         const urlParams = new URLSearchParams(window.location.search);
-        if (urlParams.get('error') === 'invalid') {
+        const loginErrors = {
+          invalid: 'Invalid username or password',
+          disabled: 'Password login is disabled',
+          sso: 'Single sign-on failed',
+          sso_conflict: 'A local account with your username already exists; ask an admin to link it',
+          mismatch: 'New passwords do not match',
+          required: 'Current and new password are required',
+          current: 'Current password is incorrect',
+          unchanged: 'New password must differ from the current one',
+          failed: 'Failed to change password',
+          locked: 'Too many failed login attempts; try again later',
+          code: 'Invalid code',
+          expired: 'Login expired; sign in again'
+        };
+        const loginError = loginErrors[urlParams.get('error')];
+        if (loginError) {
           const errorDiv = document.createElement('div');
           errorDiv.className = 'error';
-          errorDiv.textContent = 'Invalid username or password';
+          errorDiv.textContent = loginError;
           document.querySelector('form').appendChild(errorDiv);
         }
       </script>
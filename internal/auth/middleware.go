@@ -2,16 +2,25 @@ package auth
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"strings"
 )
 
 type contextKey string
 
 const UserContextKey contextKey = "user"
 
+// TokenContextKey holds the *APIToken for requests authenticated by an API token
+const TokenContextKey contextKey = "api_token"
+
 // Middleware provides authentication middleware functionality
 type Middleware struct {
 	store *Store
+
+	// Proxy, if set, accepts users asserted in headers by a trusted reverse
+	// proxy. Such requests need no session.
+	Proxy *ProxyAuth
 }
 
 // NewMiddleware creates a new authentication middleware
@@ -19,14 +28,41 @@ func NewMiddleware(store *Store) *Middleware {
 	return &Middleware{store: store}
 }
 
-// RequireAuth is middleware that requires authentication
+// RequireAuth is middleware that requires authentication: a personal API
+// token sent as "Authorization: Bearer <token>", a user header from a trusted
+// proxy, or a session cookie. Session users who must change their password
+// are sent to /change-password.
 func (m *Middleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return m.requireAuth(next, false)
+}
+
+// RequireAuthForPasswordChange is RequireAuth for the routes a user who must
+// change their password may still reach
+func (m *Middleware) RequireAuthForPasswordChange(next http.HandlerFunc) http.HandlerFunc {
+	return m.requireAuth(next, true)
+}
+
+func (m *Middleware) requireAuth(next http.HandlerFunc, allowPasswordChange bool) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if secret, ok := bearerToken(r); ok {
+			m.serveWithToken(w, r, secret, next)
+			return
+		}
+
+		if user, err := m.proxyUser(r); err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		} else if user != nil {
+			ctx := context.WithValue(r.Context(), UserContextKey, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// Check for session cookie
 		cookie, err := r.Cookie("session_token")
 		if err != nil {
 			// No session cookie, redirect to login
-			if r.Header.Get("Accept") == "application/json" {
+			if wantsJSON(r) {
 				http.Error(w, `{"error":"authentication required"}`, http.StatusUnauthorized)
 				return
 			}
@@ -48,7 +84,7 @@ func (m *Middleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 				SameSite: http.SameSiteStrictMode,
 			})
 			
-			if r.Header.Get("Accept") == "application/json" {
+			if wantsJSON(r) {
 				http.Error(w, `{"error":"authentication required"}`, http.StatusUnauthorized)
 				return
 			}
@@ -56,6 +92,15 @@ func (m *Middleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		if user.MustChangePassword && !allowPasswordChange {
+			if wantsJSON(r) {
+				http.Error(w, `{"error":"password change required"}`, http.StatusForbidden)
+				return
+			}
+			http.Redirect(w, r, "/change-password", http.StatusSeeOther)
+			return
+		}
+
 		// Add user to request context
 		ctx := context.WithValue(r.Context(), UserContextKey, user)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -65,6 +110,17 @@ func (m *Middleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 // OptionalAuth is middleware that adds user context if authenticated, but doesn't require it
 func (m *Middleware) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if secret, ok := bearerToken(r); ok {
+			m.serveWithToken(w, r, secret, next)
+			return
+		}
+
+		if user, err := m.proxyUser(r); err == nil && user != nil {
+			ctx := context.WithValue(r.Context(), UserContextKey, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// Check for session cookie
 		cookie, err := r.Cookie("session_token")
 		if err == nil {
@@ -83,22 +139,33 @@ func (m *Middleware) OptionalAuth(next http.HandlerFunc) http.HandlerFunc {
 
 // RequireAdmin is middleware that requires admin role
 func (m *Middleware) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return m.RequirePermission(PermManageUsers, next)
+}
+
+// RequirePermission is middleware that requires a user whose role grants
+// perm. Requests made with an API token also need a scope that covers it.
+func (m *Middleware) RequirePermission(perm Permission, next http.HandlerFunc) http.HandlerFunc {
 	return m.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
 		user, ok := GetUserFromContext(r)
 		if !ok {
 			http.Error(w, `{"error":"authentication required"}`, http.StatusUnauthorized)
 			return
 		}
-		
-		if !user.IsAdmin() {
-			if r.Header.Get("Accept") == "application/json" {
-				http.Error(w, `{"error":"admin access required"}`, http.StatusForbidden)
+
+		if !user.Can(perm) {
+			if wantsJSON(r) {
+				http.Error(w, `{"error":"permission denied"}`, http.StatusForbidden)
 				return
 			}
-			http.Error(w, "Admin access required", http.StatusForbidden)
+			http.Error(w, "Permission denied", http.StatusForbidden)
 			return
 		}
-		
+
+		if token, ok := GetTokenFromContext(r); ok && !token.Allows(permissionScope(perm)) {
+			http.Error(w, `{"error":"token scope does not allow this request"}`, http.StatusForbidden)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -121,6 +188,71 @@ func (m *Middleware) RequireICalTokenOrAuth(next http.HandlerFunc) http.HandlerF
 	})
 }
 
+// serveWithToken authenticates a request by API token. GET and HEAD requests
+// need the read scope; anything else changes data and needs manage.
+func (m *Middleware) serveWithToken(w http.ResponseWriter, r *http.Request, secret string, next http.HandlerFunc) {
+	user, token, err := m.store.GetUserByAPIToken(secret)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, `{"error":"invalid api token"}`, http.StatusUnauthorized)
+		return
+	}
+
+	required := ScopeManage
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		required = ScopeRead
+	}
+	if !token.Allows(required) {
+		http.Error(w, `{"error":"token scope does not allow this request"}`, http.StatusForbidden)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), UserContextKey, user)
+	ctx = context.WithValue(ctx, TokenContextKey, token)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// proxyUser returns the user a trusted proxy vouches for, creating them on
+// first sight, or nil if the request carries no trusted user header
+func (m *Middleware) proxyUser(r *http.Request) (*User, error) {
+	if m.Proxy == nil {
+		return nil, nil
+	}
+	username, role, ok := m.Proxy.identity(r)
+	if !ok {
+		return nil, nil
+	}
+	user, err := m.store.ensureUser(username, role, m.Proxy.config.DefaultRole)
+	if err != nil {
+		log.Printf("error resolving proxy user %s: %v", username, err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// wantsJSON reports whether errors should be sent as JSON rather than as a
+// redirect or plain text: the client asked for JSON, or it's using the
+// versioned API, whose clients are never browsers following redirects
+func wantsJSON(r *http.Request) bool {
+	return r.Header.Get("Accept") == "application/json" || strings.HasPrefix(r.URL.Path, "/api/v1/")
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// GetTokenFromContext returns the API token a request was authenticated with,
+// if it wasn't authenticated by session
+func GetTokenFromContext(r *http.Request) (*APIToken, bool) {
+	token, ok := r.Context().Value(TokenContextKey).(*APIToken)
+	return token, ok
+}
+
 // GetUserFromContext extracts the user from request context
 func GetUserFromContext(r *http.Request) (*User, bool) {
 	user, ok := r.Context().Value(UserContextKey).(*User)
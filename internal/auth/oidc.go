@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// oidcStateCookie carries the state, nonce and PKCE verifier of a login in
+// progress from /auth/oidc/login to the callback
+const oidcStateCookie = "oidc_state"
+
+var ErrIdentityConflict = errors.New("a local user with this username already exists")
+
+// OIDCConfig configures single sign-on against an OpenID Connect provider
+type OIDCConfig struct {
+	Issuer            string
+	ClientID          string
+	ClientSecret      string
+	RedirectURL       string   // Public URL of /auth/oidc/callback
+	DisplayName       string   // Login button label (default: SSO)
+	Scopes            []string // Default: openid, profile, email
+	UsernameClaim     string   // Default: preferred_username, falling back to email
+	RoleClaim         string   // Default: groups
+	AdminValues       []string // Role claim values that grant admin; if set, roles follow the claim
+	EditorValues      []string // Role claim values that grant editor
+	DefaultRole       UserRole // Role for everyone else, and for new users when roles are managed locally (default: viewer)
+	LinkExistingUsers bool     // Sign in to an existing local user with the same username
+}
+
+// OIDCProvider runs the authorization code flow with PKCE and provisions
+// users from the ID token
+type OIDCProvider struct {
+	config   OIDCConfig
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	store    *Store
+}
+
+// NewOIDCProvider discovers the issuer's endpoints and keys. Use
+// oidc.ClientContext on ctx to supply a custom HTTP client.
+func NewOIDCProvider(ctx context.Context, config OIDCConfig, store *Store) (*OIDCProvider, error) {
+	if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc issuer, client_id and redirect_url are required")
+	}
+	if config.DisplayName == "" {
+		config.DisplayName = "SSO"
+	}
+	defaultRole, err := defaultRoleOr(config.DefaultRole)
+	if err != nil {
+		return nil, err
+	}
+	config.DefaultRole = defaultRole
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "preferred_username"
+	}
+	if config.RoleClaim == "" {
+		config.RoleClaim = "groups"
+	}
+
+	provider, err := oidc.NewProvider(ctx, config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc issuer: %w", err)
+	}
+
+	return &OIDCProvider{
+		config: config,
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       config.Scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: config.ClientID}),
+		store:    store,
+	}, nil
+}
+
+// DisplayName returns the label for the login button
+func (p *OIDCProvider) DisplayName() string {
+	return p.config.DisplayName
+}
+
+// HandleOIDCLogin redirects to the provider to start a login
+func (h *AuthHandlers) HandleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.OIDC == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	state, nonce := randomHex(16), randomHex(16)
+	verifier := oauth2.GenerateVerifier()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state + "." + nonce + "." + verifier,
+		Path:     "/auth/oidc",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode, // Sent on the provider's redirect back to us
+	})
+
+	authURL := h.OIDC.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// HandleOIDCCallback completes a login: it exchanges the code, verifies the
+// ID token, provisions the user and starts a session
+func (h *AuthHandlers) HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.OIDC == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user, err := h.completeOIDCLogin(w, r)
+	if err != nil {
+		log.Printf("oidc login failed: %v", err)
+		reason := "sso"
+		if errors.Is(err, ErrIdentityConflict) {
+			reason = "sso_conflict"
+		}
+		http.Redirect(w, r, "/login?error="+reason, http.StatusSeeOther)
+		return
+	}
+
+	session, err := h.store.CreateSession(user.ID, r.UserAgent(), h.clientIP(r))
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	setSessionCookie(w, r, session)
+
+	// The session cookie is SameSite=Strict, so a redirect straight from
+	// the provider's cross-site navigation would arrive without it. Continue
+	// from a page on our own site instead.
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, `<!doctype html><meta http-equiv="refresh" content="0;url=/"><a href="/">Continue</a>`)
+}
+
+// completeOIDCLogin validates the callback request and returns the signed in user
+func (h *AuthHandlers) completeOIDCLogin(w http.ResponseWriter, r *http.Request) (*User, error) {
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		return nil, fmt.Errorf("missing state cookie")
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Value: "", Path: "/auth/oidc", MaxAge: -1})
+
+	parts := strings.SplitN(cookie.Value, ".", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed state cookie")
+	}
+	state, nonce, verifier := parts[0], parts[1], parts[2]
+
+	query := r.URL.Query()
+	if e := query.Get("error"); e != "" {
+		return nil, fmt.Errorf("provider returned %s: %s", e, query.Get("error_description"))
+	}
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+		return nil, fmt.Errorf("state mismatch")
+	}
+
+	p := h.OIDC
+	token, err := p.oauth2.Exchange(r.Context(), query.Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+	idToken, err := p.verifier.Verify(r.Context(), rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("id token: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("nonce mismatch")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("id token claims: %w", err)
+	}
+	return p.provisionUser(idToken.Issuer, idToken.Subject, claims)
+}
+
+// provisionUser returns the user linked to an issuer and subject, creating
+// one on first login. If admin values are configured the user's role is
+// updated from the role claim on every login.
+func (p *OIDCProvider) provisionUser(issuer, subject string, claims map[string]interface{}) (*User, error) {
+	role := p.roleFromClaims(claims)
+	storage := p.store.storage
+
+	user, err := storage.GetUserByIdentity(issuer, subject)
+	if err == nil {
+		if role != "" && user.Role != role {
+			user.Role = role
+			if err := storage.UpdateUser(user); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+	} else if err != ErrUserNotFound {
+		return nil, err
+	}
+
+	username, _ := claims[p.config.UsernameClaim].(string)
+	if username == "" {
+		username, _ = claims["email"].(string)
+	}
+	if username == "" {
+		return nil, fmt.Errorf("id token has no %s or email claim", p.config.UsernameClaim)
+	}
+
+	if _, err := storage.GetUserByUsername(username); err == nil && !p.config.LinkExistingUsers {
+		return nil, fmt.Errorf("%w: %s", ErrIdentityConflict, username)
+	} else if err != nil && err != ErrUserNotFound {
+		return nil, err
+	}
+	user, err = p.store.ensureUser(username, role, p.config.DefaultRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.LinkIdentity(user.ID, issuer, subject); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// roleFromClaims maps the role claim to a role. It returns "" when no admin
+// values are configured, leaving roles to be managed locally.
+func (p *OIDCProvider) roleFromClaims(claims map[string]interface{}) UserRole {
+	if len(p.config.AdminValues) == 0 {
+		return ""
+	}
+
+	var values []string
+	switch v := claims[p.config.RoleClaim].(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	return roleFromGroups(values, p.config.AdminValues, p.config.EditorValues, p.config.DefaultRole)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// stubIssuer is a minimal OpenID provider: discovery, keys and a token
+// endpoint that checks the PKCE verifier and returns a signed ID token
+type stubIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	// Set by the test to play the user's consent at the authorization endpoint
+	challenge string
+	nonce     string
+	claims    map[string]interface{}
+}
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &stubIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                s.URL,
+			"authorization_endpoint":                s.URL + "/authorize",
+			"token_endpoint":                        s.URL + "/token",
+			"jwks_uri":                              s.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		id, secret, _ := r.BasicAuth()
+		if id == "" {
+			id, secret = r.FormValue("client_id"), r.FormValue("client_secret")
+		}
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if id != "syllabus" || secret != "shh" || r.FormValue("code") != "good-code" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != s.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		claims := map[string]interface{}{
+			"iss":   s.URL,
+			"aud":   "syllabus",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": s.nonce,
+		}
+		for k, v := range s.claims {
+			claims[k] = v
+		}
+		payload, _ := json.Marshal(claims)
+		signer, _ := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key},
+			(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"))
+		signed, _ := signer.Sign(payload)
+		idToken, _ := signed.CompactSerialize()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// login runs the flow through both handlers and returns the callback response
+func (s *stubIssuer) login(t *testing.T, h *AuthHandlers, claims map[string]interface{}, tamper func(q url.Values)) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.HandleOIDCLogin(rec, httptest.NewRequest("GET", "/auth/oidc/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login status = %d, want redirect", rec.Code)
+	}
+	authURL, _ := url.Parse(rec.Header().Get("Location"))
+	q := authURL.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("authorization request without PKCE: %s", authURL)
+	}
+	s.challenge, s.nonce, s.claims = q.Get("code_challenge"), q.Get("nonce"), claims
+
+	callback := url.Values{"code": {"good-code"}, "state": {q.Get("state")}}
+	if tamper != nil {
+		tamper(callback)
+	}
+	req := httptest.NewRequest("GET", "/auth/oidc/callback?"+callback.Encode(), nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	rec = httptest.NewRecorder()
+	h.HandleOIDCCallback(rec, req)
+	return rec
+}
+
+func sessionFrom(rec *httptest.ResponseRecorder) string {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "session_token" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func TestOIDCLogin(t *testing.T) {
+	issuer := newStubIssuer(t)
+	store := newTestStore(t, t.TempDir())
+	if _, err := store.CreateUser("carol", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewOIDCProvider(context.Background(), OIDCConfig{
+		Issuer:       issuer.URL,
+		ClientID:     "syllabus",
+		ClientSecret: "shh",
+		RedirectURL:  "http://syllabus.test/auth/oidc/callback",
+		AdminValues:  []string{"syllabus-admins"},
+	}, store)
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	h := NewAuthHandlers(store)
+	h.OIDC = provider
+	h.DisablePasswordLogin = true
+
+	// First login provisions the user, with admin from the groups claim
+	rec := issuer.login(t, h, map[string]interface{}{
+		"sub": "dave-sub", "preferred_username": "dave", "groups": []string{"family", "syllabus-admins"},
+	}, nil)
+	token := sessionFrom(rec)
+	if rec.Code != http.StatusOK || token == "" {
+		t.Fatalf("callback = %d without a session: %s", rec.Code, rec.Body.String())
+	}
+	dave, err := store.GetUserBySession(token)
+	if err != nil || dave.Username != "dave" || dave.Role != RoleAdmin {
+		t.Fatalf("session user = %+v, %v; want admin dave", dave, err)
+	}
+
+	// Later logins reuse the user and follow the claim, even after a rename
+	rec = issuer.login(t, h, map[string]interface{}{
+		"sub": "dave-sub", "preferred_username": "david", "groups": []string{"family"},
+	}, nil)
+	again, err := store.GetUserBySession(sessionFrom(rec))
+	if err != nil || again.ID != dave.ID || again.Role != RoleViewer {
+		t.Errorf("second login user = %+v, %v; want dave demoted to the default role", again, err)
+	}
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		tamper func(q url.Values)
+		want   string
+	}{
+		{"state mismatch", map[string]interface{}{"sub": "x", "preferred_username": "x"},
+			func(q url.Values) { q.Set("state", "forged") }, "/login?error=sso"},
+		{"wrong code", map[string]interface{}{"sub": "x", "preferred_username": "x"},
+			func(q url.Values) { q.Set("code", "bad-code") }, "/login?error=sso"},
+		{"local username taken", map[string]interface{}{"sub": "carol-sub", "preferred_username": "carol"},
+			nil, "/login?error=sso_conflict"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := issuer.login(t, h, tt.claims, tt.tamper)
+			if got := rec.Header().Get("Location"); got != tt.want || sessionFrom(rec) != "" {
+				t.Errorf("callback redirected to %q (session %q), want %q and no session", got, sessionFrom(rec), tt.want)
+			}
+		})
+	}
+
+	// Password login is off
+	req := httptest.NewRequest("POST", "/login", nil)
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	h.HandleLogin(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("password login status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Permission names something a role allows. Routes require one with
+// Middleware.RequirePermission, and templates check them with User.Can.
+type Permission string
+
+const (
+	PermView           Permission = "view"            // Dashboard, API reads, iCal and one's own reading progress
+	PermManageSeries   Permission = "manage_series"   // Add and remove series, trigger and cancel scrapes
+	PermManageSettings Permission = "manage_settings" // Server-wide settings such as the auto-refresh interval
+	PermManageUsers    Permission = "manage_users"    // Create, delete and reset users
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+
+// rolePermissions lists what each role may do
+var rolePermissions = map[UserRole][]Permission{
+	RoleViewer: {PermView},
+	RoleEditor: {PermView, PermManageSeries},
+	RoleAdmin:  {PermView, PermManageSeries, PermManageSettings, PermManageUsers},
+}
+
+// Can reports whether the user's role grants a permission
+func (u *User) Can(perm Permission) bool {
+	for _, p := range rolePermissions[u.Role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role UserRole) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// permissionScope is the API token scope a permission needs
+func permissionScope(perm Permission) TokenScope {
+	switch perm {
+	case PermView:
+		return ScopeRead
+	case PermManageSeries:
+		return ScopeManage
+	}
+	return ScopeAdmin
+}
+
+// maxScope is the broadest API token scope a role may create
+func maxScope(role UserRole) TokenScope {
+	switch role {
+	case RoleAdmin:
+		return ScopeAdmin
+	case RoleEditor:
+		return ScopeManage
+	}
+	return ScopeRead
+}
+
+// roleFromGroups maps groups asserted by an identity provider to a role.
+// Members of an admin group are admins and members of an editor group are
+// editors; everyone else gets defaultRole.
+func roleFromGroups(groups, adminGroups, editorGroups []string, defaultRole UserRole) UserRole {
+	if slices.ContainsFunc(groups, func(g string) bool { return slices.Contains(adminGroups, g) }) {
+		return RoleAdmin
+	}
+	if slices.ContainsFunc(groups, func(g string) bool { return slices.Contains(editorGroups, g) }) {
+		return RoleEditor
+	}
+	return defaultRole
+}
+
+// defaultRoleOr checks the default role configured for an identity provider,
+// which is viewer when unset
+func defaultRoleOr(role UserRole) (UserRole, error) {
+	if role == "" {
+		return RoleViewer, nil
+	}
+	if !ValidRole(role) {
+		return "", fmt.Errorf("invalid default role %q", role)
+	}
+	return role, nil
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequirePermission(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	sessions := make(map[UserRole]string)
+	for _, role := range []UserRole{RoleViewer, RoleEditor, RoleAdmin} {
+		user, err := store.CreateUserWithRole(string(role), "secret", role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session, err := store.CreateSession(user.ID, "Firefox", "10.0.0.2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions[role] = session.Token
+	}
+	if _, err := store.CreateUserWithRole("mallory", "secret", "superuser"); err != ErrInvalidRole {
+		t.Errorf("created user with unknown role: %v", err)
+	}
+
+	m := NewMiddleware(store)
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	tests := []struct {
+		role UserRole
+		perm Permission
+		want int
+	}{
+		{RoleViewer, PermView, http.StatusOK},
+		{RoleViewer, PermManageSeries, http.StatusForbidden},
+		{RoleViewer, PermManageSettings, http.StatusForbidden},
+		{RoleEditor, PermManageSeries, http.StatusOK},
+		{RoleEditor, PermManageSettings, http.StatusForbidden},
+		{RoleEditor, PermManageUsers, http.StatusForbidden},
+		{RoleAdmin, PermManageSettings, http.StatusOK},
+		{RoleAdmin, PermManageUsers, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role)+" "+string(tt.perm), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.RequirePermission(tt.perm, noop)(rec, sessionRequest("POST", "/api/add-series", "{}", sessions[tt.role]))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	// A token is limited by its scope even when the role allows more
+	admin, _ := store.GetUser("admin")
+	_, secret, err := store.CreateAPIToken(admin, "cron", ScopeManage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for perm, want := range map[Permission]int{PermManageSeries: http.StatusOK, PermManageSettings: http.StatusForbidden} {
+		req := httptest.NewRequest("POST", "/api/auto-refresh", nil)
+		req.Header.Set("Authorization", "Bearer "+secret)
+		rec := httptest.NewRecorder()
+		m.RequirePermission(perm, noop)(rec, req)
+		if rec.Code != want {
+			t.Errorf("manage token on %s = %d, want %d", perm, rec.Code, want)
+		}
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	admin, err := store.CreateUserWithRole("admin", "secret", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateUser("erin", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	h := NewAuthHandlers(store)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"promote to editor", `{"username":"erin","role":"editor"}`, http.StatusOK},
+		{"unknown role", `{"username":"erin","role":"user"}`, http.StatusBadRequest},
+		{"unknown user", `{"username":"nobody","role":"viewer"}`, http.StatusNotFound},
+		{"own role", `{"username":"admin","role":"viewer"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := sessionRequest("POST", "/api/users/role", tt.body, "")
+			rec := httptest.NewRecorder()
+			h.HandleSetUserRole(rec, req.WithContext(context.WithValue(req.Context(), UserContextKey, admin)))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+	if erin, _ := store.GetUser("erin"); erin.Role != RoleEditor {
+		t.Errorf("erin's role = %q, want %q", erin.Role, RoleEditor)
+	}
+}
+
+func TestRoleFromGroups(t *testing.T) {
+	admins := []string{"admins"}
+	tests := []struct {
+		name        string
+		groups      []string
+		editors     []string
+		defaultRole UserRole
+		want        UserRole
+	}{
+		{"admin group", []string{"family", "admins"}, nil, RoleViewer, RoleAdmin},
+		{"no editor groups configured", []string{"family"}, nil, RoleViewer, RoleViewer},
+		{"editor group", []string{"family", "librarians"}, []string{"librarians"}, RoleViewer, RoleEditor},
+		{"outside the editor groups", []string{"family"}, []string{"librarians"}, RoleViewer, RoleViewer},
+		{"no groups", nil, []string{"librarians"}, RoleViewer, RoleViewer},
+		{"editor by default", []string{"family"}, nil, RoleEditor, RoleEditor},
+		{"editor default with editor groups", []string{"family"}, []string{"librarians"}, RoleEditor, RoleEditor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roleFromGroups(tt.groups, admins, tt.editors, tt.defaultRole); got != tt.want {
+				t.Errorf("role = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,13 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// PersistentUser represents a user for file storage (includes password hash)
+// PersistentUser represents a user in the legacy users.json file (includes password hash)
 type PersistentUser struct {
 	ID           string    `json:"id"`
 	Username     string    `json:"username"`
@@ -20,99 +19,93 @@ type PersistentUser struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
-// PersistentData represents the data structure for file storage
-type PersistentData struct {
-	Users map[string]*PersistentUser `json:"users"`
+// PersistentAPIToken represents an API token in the legacy users.json file (includes the hash)
+type PersistentAPIToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Scope      TokenScope `json:"scope"`
+	Hint       string     `json:"hint"`
+	Hash       string     `json:"hash"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
 }
 
-// SaveToFile saves users to a JSON file
-func (s *Store) SaveToFile(filename string) error {
-	s.mu.RLock()
-	data := PersistentData{
-		Users: make(map[string]*PersistentUser),
-	}
-	
-	// Copy users (excluding sessions as they should be temporary)
-	for username, user := range s.users {
-		data.Users[username] = &PersistentUser{
-			ID:           user.ID,
-			Username:     user.Username,
-			Role:         user.Role,
-			PasswordHash: user.PasswordHash,
-			ICalToken:    user.ICalToken,
-			CreatedAt:    user.CreatedAt,
-		}
-	}
-	s.mu.RUnlock()
-
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(filename)
-	if dir != "." && dir != "" {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
-		}
-	}
-
-	// Marshal to JSON
-	jsonData, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal data: %w", err)
-	}
-
-	// Write to file
-	if err := os.WriteFile(filename, jsonData, 0600); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+// PersistentData represents the data structure of the legacy users.json file
+type PersistentData struct {
+	Users  map[string]*PersistentUser `json:"users"`
+	Tokens []*PersistentAPIToken      `json:"tokens,omitempty"`
 }
 
-// LoadFromFile loads users from a JSON file
-func (s *Store) LoadFromFile(filename string) error {
-	// Check if file exists
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		// File doesn't exist, that's okay for first run
-		return nil
-	}
-
-	// Read file
+// ImportUsersFile copies users and API tokens from a users.json file written
+// by older versions into storage, then renames the file to <name>.imported so
+// the import runs only once. Users whose username already exists are skipped.
+// A missing file is not an error. Returns the number of users imported.
+func (s *Store) ImportUsersFile(filename string) (int, error) {
 	jsonData, err := os.ReadFile(filename)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Unmarshal JSON
 	var data PersistentData
 	if err := json.Unmarshal(jsonData, &data); err != nil {
-		return fmt.Errorf("failed to unmarshal data: %w", err)
+		return 0, fmt.Errorf("failed to unmarshal data: %w", err)
 	}
 
-	// Load users into store
-	s.mu.Lock()
-	needsSave := false
-	if data.Users != nil {
-		s.users = make(map[string]*User)
-		for username, persistentUser := range data.Users {
-			icalToken := persistentUser.ICalToken
-			if icalToken == "" {
-				icalToken = uuid.New().String()
-				needsSave = true
-			}
-			s.users[username] = &User{
-				ID:           persistentUser.ID,
-				Username:     persistentUser.Username,
-				Role:         persistentUser.Role,
-				PasswordHash: persistentUser.PasswordHash,
-				ICalToken:    icalToken,
-				CreatedAt:    persistentUser.CreatedAt,
-			}
+	imported := 0
+	for _, pu := range data.Users {
+		role := pu.Role
+		if role == roleLegacyUser {
+			role = RoleEditor
 		}
+		icalToken := pu.ICalToken
+		if icalToken == "" {
+			icalToken = uuid.New().String()
+		}
+		err := s.storage.CreateUser(&User{
+			ID:           pu.ID,
+			Username:     pu.Username,
+			Role:         role,
+			PasswordHash: pu.PasswordHash,
+			ICalToken:    icalToken,
+			CreatedAt:    pu.CreatedAt,
+		})
+		if err == ErrUserExists {
+			continue
+		} else if err != nil {
+			return imported, fmt.Errorf("failed to import user %s: %w", pu.Username, err)
+		}
+		imported++
 	}
-	s.mu.Unlock()
 
-	if needsSave {
-		s.save()
+	for _, t := range data.Tokens {
+		// Skip tokens already imported and tokens whose owner was not
+		if _, err := s.storage.GetAPITokenByHash(t.Hash); err != ErrTokenNotFound {
+			continue
+		}
+		if _, err := s.storage.GetUserByID(t.UserID); err != nil {
+			continue
+		}
+		err := s.storage.CreateAPIToken(&APIToken{
+			ID:         t.ID,
+			UserID:     t.UserID,
+			Name:       t.Name,
+			Scope:      t.Scope,
+			Hint:       t.Hint,
+			Hash:       t.Hash,
+			CreatedAt:  t.CreatedAt,
+			LastUsedAt: t.LastUsedAt,
+		})
+		if err != nil {
+			return imported, fmt.Errorf("failed to import api token %s: %w", t.Name, err)
+		}
 	}
 
-	return nil
-}
\ No newline at end of file
+	if err := os.Rename(filename, filename+".imported"); err != nil {
+		return imported, fmt.Errorf("failed to rename imported file: %w", err)
+	}
+
+	return imported, nil
+}
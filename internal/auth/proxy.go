@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ProxyAuthConfig configures authentication by a trusted reverse proxy such as
+// Authelia or oauth2-proxy, which passes the signed in user in a header
+type ProxyAuthConfig struct {
+	UserHeader     string   // Default: Remote-User
+	GroupsHeader   string   // Comma-separated groups, e.g. Remote-Groups; optional
+	TrustedProxies []string // CIDRs or addresses whose headers are believed
+	AdminGroups    []string // Groups that grant admin; if set, roles follow the groups header
+	EditorGroups   []string // Groups that grant editor
+	DefaultRole    UserRole // Role for everyone else, and for new users when roles are managed locally (default: viewer)
+}
+
+// ProxyAuth resolves users from headers set by a trusted proxy
+type ProxyAuth struct {
+	config  ProxyAuthConfig
+	trusted []netip.Prefix
+}
+
+// NewProxyAuth validates the trusted proxy list
+func NewProxyAuth(config ProxyAuthConfig) (*ProxyAuth, error) {
+	if config.UserHeader == "" {
+		config.UserHeader = "Remote-User"
+	}
+	if len(config.TrustedProxies) == 0 {
+		return nil, fmt.Errorf("proxy auth requires at least one trusted proxy")
+	}
+	defaultRole, err := defaultRoleOr(config.DefaultRole)
+	if err != nil {
+		return nil, err
+	}
+	config.DefaultRole = defaultRole
+
+	trusted, err := ParseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &ProxyAuth{config: config, trusted: trusted}, nil
+}
+
+// ParseTrustedProxies parses a list of CIDRs or single addresses
+func ParseTrustedProxies(list []string) ([]netip.Prefix, error) {
+	var trusted []netip.Prefix
+	for _, cidr := range list {
+		cidr = strings.TrimSpace(cidr)
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(cidr)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		trusted = append(trusted, prefix.Masked())
+	}
+	return trusted, nil
+}
+
+// isTrusted reports whether the request came directly from a trusted proxy
+func (p *ProxyAuth) isTrusted(r *http.Request) bool {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	return isTrustedAddr(p.trusted, addrPort.Addr().String())
+}
+
+// isTrustedAddr reports whether host is an address within trusted
+func isTrustedAddr(trusted []netip.Prefix, host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// identity returns the username and role asserted by the proxy. ok is false
+// when the request isn't from a trusted proxy or carries no user header.
+func (p *ProxyAuth) identity(r *http.Request) (username string, role UserRole, ok bool) {
+	username = strings.TrimSpace(r.Header.Get(p.config.UserHeader))
+	if username == "" || !p.isTrusted(r) {
+		return "", "", false
+	}
+	if len(p.config.AdminGroups) == 0 {
+		return username, "", true
+	}
+
+	var groups []string
+	if p.config.GroupsHeader != "" {
+		for _, group := range strings.Split(r.Header.Get(p.config.GroupsHeader), ",") {
+			groups = append(groups, strings.TrimSpace(group))
+		}
+	}
+	return username, roleFromGroups(groups, p.config.AdminGroups, p.config.EditorGroups, p.config.DefaultRole), true
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyAuth(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	proxy, err := NewProxyAuth(ProxyAuthConfig{
+		GroupsHeader:   "Remote-Groups",
+		TrustedProxies: []string{"10.0.0.0/8", "::1"},
+		AdminGroups:    []string{"admins"},
+	})
+	if err != nil {
+		t.Fatalf("new proxy auth: %v", err)
+	}
+	m := NewMiddleware(store)
+	m.Proxy = proxy
+
+	var got *User
+	ok := func(w http.ResponseWriter, r *http.Request) { got, _ = GetUserFromContext(r) }
+
+	tests := []struct {
+		name     string
+		remote   string
+		user     string
+		groups   string
+		want     int
+		wantUser string
+		wantRole UserRole
+	}{
+		{"trusted proxy", "10.1.2.3:4000", "erin", "family", http.StatusOK, "erin", RoleViewer},
+		{"trusted ipv6 proxy", "[::1]:4000", "erin", "family, admins", http.StatusOK, "erin", RoleAdmin},
+		{"groups follow the header", "10.1.2.3:4000", "erin", "", http.StatusOK, "erin", RoleViewer},
+		{"untrusted client", "192.168.1.5:4000", "admin", "admins", http.StatusSeeOther, "", ""},
+		{"trusted proxy without user", "10.1.2.3:4000", "", "", http.StatusSeeOther, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.user != "" {
+				req.Header.Set("Remote-User", tt.user)
+			}
+			req.Header.Set("Remote-Groups", tt.groups)
+			rec := httptest.NewRecorder()
+			m.RequireAuth(ok)(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.wantUser == "" {
+				if got != nil {
+					t.Errorf("request user = %s, want none", got.Username)
+				}
+				return
+			}
+			if got == nil || got.Username != tt.wantUser || got.Role != tt.wantRole {
+				t.Errorf("request user = %+v, want %s (%s)", got, tt.wantUser, tt.wantRole)
+			}
+		})
+	}
+
+	// Users are provisioned once
+	if users, _ := store.ListUsers(); len(users) != 1 {
+		t.Errorf("users = %d, want 1", len(users))
+	}
+
+	if _, err := NewProxyAuth(ProxyAuthConfig{TrustedProxies: []string{"not-an-ip"}}); err == nil {
+		t.Error("invalid trusted proxy accepted")
+	}
+	if _, err := NewProxyAuth(ProxyAuthConfig{TrustedProxies: []string{"10.0.0.0/8"}, DefaultRole: "owner"}); err == nil {
+		t.Error("invalid default role accepted")
+	}
+}
+
+func TestProxyAuthDefaultRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		adminGroups []string
+		defaultRole UserRole
+		want        UserRole
+	}{
+		{"roles managed locally", nil, "", RoleViewer},
+		{"roles managed locally, editor default", nil, RoleEditor, RoleEditor},
+		{"roles from groups", []string{"admins"}, "", RoleViewer},
+		{"roles from groups, editor default", []string{"admins"}, RoleEditor, RoleEditor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t, t.TempDir())
+			proxy, err := NewProxyAuth(ProxyAuthConfig{
+				GroupsHeader:   "Remote-Groups",
+				TrustedProxies: []string{"10.0.0.0/8"},
+				AdminGroups:    tt.adminGroups,
+				DefaultRole:    tt.defaultRole,
+			})
+			if err != nil {
+				t.Fatalf("new proxy auth: %v", err)
+			}
+			m := NewMiddleware(store)
+			m.Proxy = proxy
+
+			var got *User
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = "10.1.2.3:4000"
+			req.Header.Set("Remote-User", "erin")
+			req.Header.Set("Remote-Groups", "family")
+			m.RequireAuth(func(w http.ResponseWriter, r *http.Request) { got, _ = GetUserFromContext(r) })(httptest.NewRecorder(), req)
+			if got == nil || got.Role != tt.want {
+				t.Errorf("new user = %+v, want role %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Storage persists users, sessions and API tokens. Lookups return
+// ErrUserNotFound, ErrSessionNotFound or ErrTokenNotFound when nothing matches.
+type Storage interface {
+	CreateUser(user *User) error // ErrUserExists if the username is taken
+	GetUserByID(id string) (*User, error)
+	GetUserByUsername(username string) (*User, error)
+	GetUserByICalToken(token string) (*User, error)
+	ListUsers() ([]*User, error)
+	UpdateUser(user *User) error
+	DeleteUser(id string) error // Also removes the user's sessions, tokens and identities
+
+	GetUserByIdentity(issuer, subject string) (*User, error)
+	LinkIdentity(userID, issuer, subject string) error
+
+	ReplaceRecoveryCodes(userID string, hashes []string) error
+	UseRecoveryCode(userID, hash string) error // ErrInvalidCode if unknown or used
+	CountRecoveryCodes(userID string) (int, error)
+
+	GetSetting(key string) (string, error) // "" if unset
+	SetSetting(key, value string) error
+
+	CreateSession(session *Session) error
+	GetSession(token string) (*Session, error)
+	ListSessions(userID string) ([]*Session, error)
+	TouchSession(token string, at time.Time) error
+	DeleteSession(token string) error
+	DeleteSessionByID(userID, sessionID string) error
+	DeleteUserSessions(userID, exceptToken string) (int, error)
+	DeleteExpiredSessions(now time.Time) (int, error)
+
+	CreateAPIToken(token *APIToken) error
+	GetAPITokenByHash(hash string) (*APIToken, error)
+	ListAPITokens(userID string) ([]*APIToken, error)
+	DeleteAPIToken(userID, tokenID string) error
+	TouchAPIToken(tokenID string, at time.Time) error
+}
+
+// sqlStorage keeps auth data in the application's SQLite database, in the
+// users, sessions and api_tokens tables created by the migrations
+type sqlStorage struct {
+	db *sql.DB
+}
+
+// NewSQLStorage returns a Storage backed by the application database
+func NewSQLStorage(db *sql.DB) Storage {
+	return &sqlStorage{db: db}
+}
+
+const userColumns = `id, username, role, password_hash, ical_token, created_at, must_change_password,
+	totp_enabled, totp_secret, totp_last_step`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
+	var u User
+	err := row.Scan(&u.ID, &u.Username, &u.Role, &u.PasswordHash, &u.ICalToken, &u.CreatedAt, &u.MustChangePassword,
+		&u.TOTPEnabled, &u.TOTPSecret, &u.TOTPLastStep)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to scan user: %w", err)
+	}
+	return &u, nil
+}
+
+func (st *sqlStorage) CreateUser(user *User) error {
+	_, err := st.db.Exec(`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		user.ID, user.Username, user.Role, user.PasswordHash, user.ICalToken, user.CreatedAt.UTC(), user.MustChangePassword,
+		user.TOTPEnabled, user.TOTPSecret, user.TOTPLastStep)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: users.username") {
+		return ErrUserExists
+	} else if err != nil {
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+	return nil
+}
+
+func (st *sqlStorage) GetUserByID(id string) (*User, error) {
+	return scanUser(st.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+}
+
+func (st *sqlStorage) GetUserByUsername(username string) (*User, error) {
+	return scanUser(st.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE username = ?`, username))
+}
+
+func (st *sqlStorage) GetUserByICalToken(token string) (*User, error) {
+	return scanUser(st.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE ical_token = ?`, token))
+}
+
+func (st *sqlStorage) ListUsers() ([]*User, error) {
+	rows, err := st.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY username`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (st *sqlStorage) UpdateUser(user *User) error {
+	res, err := st.db.Exec(`UPDATE users SET username = ?, role = ?, password_hash = ?, ical_token = ?, must_change_password = ?,
+		totp_enabled = ?, totp_secret = ?, totp_last_step = ? WHERE id = ?`,
+		user.Username, user.Role, user.PasswordHash, user.ICalToken, user.MustChangePassword,
+		user.TOTPEnabled, user.TOTPSecret, user.TOTPLastStep, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return expectOne(res, ErrUserNotFound)
+}
+
+func (st *sqlStorage) DeleteUser(id string) error {
+	res, err := st.db.Exec(`DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return expectOne(res, ErrUserNotFound)
+}
+
+func (st *sqlStorage) GetUserByIdentity(issuer, subject string) (*User, error) {
+	return scanUser(st.db.QueryRow(`SELECT u.id, u.username, u.role, u.password_hash, u.ical_token, u.created_at, u.must_change_password,
+		u.totp_enabled, u.totp_secret, u.totp_last_step
+		FROM users u JOIN user_identities i ON i.user_id = u.id
+		WHERE i.issuer = ? AND i.subject = ?`, issuer, subject))
+}
+
+func (st *sqlStorage) LinkIdentity(userID, issuer, subject string) error {
+	_, err := st.db.Exec(`INSERT INTO user_identities (issuer, subject, user_id) VALUES (?, ?, ?)`, issuer, subject, userID)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
+
+func (st *sqlStorage) ReplaceRecoveryCodes(userID string, hashes []string) error {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, hash := range hashes {
+		if _, err := tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, hash); err != nil {
+			return fmt.Errorf("failed to insert recovery code: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (st *sqlStorage) UseRecoveryCode(userID, hash string) error {
+	res, err := st.db.Exec(`UPDATE recovery_codes SET used_at = ?
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`, time.Now().UTC(), userID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return expectOne(res, ErrInvalidCode)
+}
+
+func (st *sqlStorage) CountRecoveryCodes(userID string) (int, error) {
+	var n int
+	err := st.db.QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL`, userID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return n, nil
+}
+
+func (st *sqlStorage) GetSetting(key string) (string, error) {
+	var value string
+	err := st.db.QueryRow(`SELECT value FROM runtime_settings WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get setting %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func (st *sqlStorage) SetSetting(key, value string) error {
+	_, err := st.db.Exec(`INSERT OR REPLACE INTO runtime_settings (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)`, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to set setting %s: %w", key, err)
+	}
+	return nil
+}
+
+func (st *sqlStorage) CreateSession(session *Session) error {
+	_, err := st.db.Exec(`INSERT INTO sessions (`+sessionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.Token, session.ID, session.UserID, session.UserAgent, session.IP,
+		session.ExpiresAt.UTC(), session.CreatedAt.UTC(), session.LastSeenAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to insert session: %w", err)
+	}
+	return nil
+}
+
+const sessionColumns = `token, id, user_id, user_agent, ip, expires_at, created_at, last_seen_at`
+
+func scanSession(row interface{ Scan(...interface{}) error }) (*Session, error) {
+	var s Session
+	var lastSeen sql.NullTime
+	err := row.Scan(&s.Token, &s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.ExpiresAt, &s.CreatedAt, &lastSeen)
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to scan session: %w", err)
+	}
+	s.LastSeenAt = s.CreatedAt
+	if lastSeen.Valid {
+		s.LastSeenAt = lastSeen.Time
+	}
+	return &s, nil
+}
+
+func (st *sqlStorage) GetSession(token string) (*Session, error) {
+	return scanSession(st.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE token = ?`, token))
+}
+
+func (st *sqlStorage) ListSessions(userID string) ([]*Session, error) {
+	rows, err := st.db.Query(`SELECT `+sessionColumns+` FROM sessions
+		WHERE user_id = ? ORDER BY COALESCE(last_seen_at, created_at) DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (st *sqlStorage) TouchSession(token string, at time.Time) error {
+	if _, err := st.db.Exec(`UPDATE sessions SET last_seen_at = ? WHERE token = ?`, at.UTC(), token); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+func (st *sqlStorage) DeleteSession(token string) error {
+	if _, err := st.db.Exec(`DELETE FROM sessions WHERE token = ?`, token); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+func (st *sqlStorage) DeleteSessionByID(userID, sessionID string) error {
+	res, err := st.db.Exec(`DELETE FROM sessions WHERE id = ? AND user_id = ?`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return expectOne(res, ErrSessionNotFound)
+}
+
+func (st *sqlStorage) DeleteUserSessions(userID, exceptToken string) (int, error) {
+	res, err := st.db.Exec(`DELETE FROM sessions WHERE user_id = ? AND token != ?`, userID, exceptToken)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (st *sqlStorage) DeleteExpiredSessions(now time.Time) (int, error) {
+	res, err := st.db.Exec(`DELETE FROM sessions WHERE expires_at <= ?`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+const tokenColumns = `id, user_id, name, scope, hint, hash, created_at, last_used_at`
+
+func scanAPIToken(row interface{ Scan(...interface{}) error }) (*APIToken, error) {
+	var t APIToken
+	err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Scope, &t.Hint, &t.Hash, &t.CreatedAt, &t.LastUsedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTokenNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to scan api token: %w", err)
+	}
+	return &t, nil
+}
+
+func (st *sqlStorage) CreateAPIToken(token *APIToken) error {
+	_, err := st.db.Exec(`INSERT INTO api_tokens (`+tokenColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		token.ID, token.UserID, token.Name, token.Scope, token.Hint, token.Hash, token.CreatedAt.UTC(), token.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert api token: %w", err)
+	}
+	return nil
+}
+
+func (st *sqlStorage) GetAPITokenByHash(hash string) (*APIToken, error) {
+	return scanAPIToken(st.db.QueryRow(`SELECT `+tokenColumns+` FROM api_tokens WHERE hash = ?`, hash))
+}
+
+func (st *sqlStorage) ListAPITokens(userID string) ([]*APIToken, error) {
+	rows, err := st.db.Query(`SELECT `+tokenColumns+` FROM api_tokens WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []*APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (st *sqlStorage) DeleteAPIToken(userID, tokenID string) error {
+	res, err := st.db.Exec(`DELETE FROM api_tokens WHERE id = ? AND user_id = ?`, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete api token: %w", err)
+	}
+	return expectOne(res, ErrTokenNotFound)
+}
+
+func (st *sqlStorage) TouchAPIToken(tokenID string, at time.Time) error {
+	if _, err := st.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, at.UTC(), tokenID); err != nil {
+		return fmt.Errorf("failed to update api token: %w", err)
+	}
+	return nil
+}
+
+// expectOne returns notFound if a statement matched no rows
+func expectOne(res sql.Result, notFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
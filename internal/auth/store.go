@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"log"
 	"sync"
 	"time"
 
@@ -9,136 +10,103 @@ import (
 )
 
 var (
-	ErrUserNotFound     = errors.New("user not found")
-	ErrSessionNotFound  = errors.New("session not found")
-	ErrSessionExpired   = errors.New("session expired")
-	ErrUserExists       = errors.New("user already exists")
-	ErrInvalidPassword  = errors.New("invalid password")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrSessionNotFound   = errors.New("session not found")
+	ErrSessionExpired    = errors.New("session expired")
+	ErrUserExists        = errors.New("user already exists")
+	ErrInvalidPassword   = errors.New("invalid password")
+	ErrPasswordUnchanged = errors.New("new password must differ from the current one")
 )
 
-// Store handles user and session storage
+// Store handles users, sessions and API tokens on top of a Storage backend
 type Store struct {
-	users      map[string]*User    // username -> user
-	sessions   map[string]*Session // token -> session
-	mu         sync.RWMutex
-	dataFile   string              // path to persistence file
-	autoSave   bool                // whether to auto-save changes
-}
+	storage Storage
 
-// NewStore creates a new authentication store
-func NewStore() *Store {
-	return NewStoreWithFile("")
+	mu         sync.Mutex
+	challenges map[string]*loginChallenge // Logins awaiting a second factor
 }
 
-// NewStoreWithFile creates a new authentication store with file persistence
-func NewStoreWithFile(dataFile string) *Store {
-	store := &Store{
-		users:    make(map[string]*User),
-		sessions: make(map[string]*Session),
-		dataFile: dataFile,
-		autoSave: dataFile != "",
-	}
-	
-	// Load existing data if file is specified
-	if dataFile != "" {
-		if err := store.LoadFromFile(dataFile); err != nil {
-			// Log error but don't fail - we can continue with empty store
-			// In a production app, you might want to handle this differently
-		}
-	}
-	
-	// Start cleanup goroutine for expired sessions
+// NewStore creates a new authentication store backed by storage
+func NewStore(storage Storage) *Store {
+	store := &Store{storage: storage, challenges: make(map[string]*loginChallenge)}
+
+	// Start cleanup goroutine for expired sessions and login challenges
 	go store.cleanupExpiredSessions()
-	
-	return store
-}
 
-// save persists the store to file if auto-save is enabled
-func (s *Store) save() {
-	if s.autoSave && s.dataFile != "" {
-		// Save asynchronously to avoid blocking
-		go func() {
-			if err := s.SaveToFile(s.dataFile); err != nil {
-				// In production, you'd want to log this properly
-			}
-		}()
-	}
+	return store
 }
 
-// CreateUser creates a new user
+// CreateUser creates a new viewer
 func (s *Store) CreateUser(username, password string) (*User, error) {
-	return s.CreateUserWithRole(username, password, RoleUser)
+	return s.CreateUserWithRole(username, password, RoleViewer)
 }
 
 // CreateUserWithRole creates a new user with a specific role
 func (s *Store) CreateUserWithRole(username, password string, role UserRole) (*User, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	
+	if !ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
 	// Check if user already exists
-	if _, exists := s.users[username]; exists {
+	if _, err := s.storage.GetUserByUsername(username); err == nil {
 		return nil, ErrUserExists
+	} else if err != ErrUserNotFound {
+		return nil, err
 	}
-	
+
 	// Hash password
 	hash, err := HashPassword(password)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// Create user
 	user := NewUser(username, hash, role)
-	s.users[username] = user
-	
-	// Save to file if persistence is enabled
-	s.save()
-	
+	if err := s.storage.CreateUser(user); err != nil {
+		return nil, err
+	}
+
 	return user, nil
 }
 
 // AuthenticateUser authenticates a user with username/password
 func (s *Store) AuthenticateUser(username, password string) (*User, error) {
-	s.mu.RLock()
-	user, exists := s.users[username]
-	s.mu.RUnlock()
-	
-	if !exists {
-		return nil, ErrUserNotFound
-	}
-	
+	user, err := s.storage.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
 	if !VerifyPassword(password, user.PasswordHash) {
 		return nil, ErrInvalidPassword
 	}
-	
+
 	return user, nil
 }
 
-// CreateSession creates a new session for a user
-func (s *Store) CreateSession(userID string) (*Session, error) {
+// CreateSession creates a new session for a user, recording the client's
+// user agent and address
+func (s *Store) CreateSession(userID, userAgent, ip string) (*Session, error) {
 	session := NewSession(userID)
-	
-	s.mu.Lock()
-	s.sessions[session.Token] = session
-	s.mu.Unlock()
-	
+	session.UserAgent = userAgent
+	session.IP = ip
+	if err := s.storage.CreateSession(session); err != nil {
+		return nil, err
+	}
 	return session, nil
 }
 
 // GetSession retrieves a session by token
 func (s *Store) GetSession(token string) (*Session, error) {
-	s.mu.RLock()
-	session, exists := s.sessions[token]
-	s.mu.RUnlock()
-	
-	if !exists {
-		return nil, ErrSessionNotFound
-	}
-	
+	session, err := s.storage.GetSession(token)
+	if err != nil {
+		return nil, err
+	}
+
 	if session.IsExpired() {
 		s.DeleteSession(token)
 		return nil, ErrSessionExpired
 	}
-	
+
 	return session, nil
 }
 
@@ -148,139 +116,201 @@ func (s *Store) GetUserBySession(token string) (*User, error) {
 	if err != nil {
 		return nil, err
 	}
-	
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	
-	for _, user := range s.users {
-		if user.ID == session.UserID {
-			return user, nil
+
+	// Record activity, persisting at most once a minute per session
+	if now := time.Now(); now.Sub(session.LastSeenAt) > time.Minute {
+		if err := s.storage.TouchSession(token, now); err != nil {
+			log.Printf("error recording session activity: %v", err)
 		}
 	}
-	
-	return nil, ErrUserNotFound
+
+	return s.storage.GetUserByID(session.UserID)
+}
+
+// ListSessions returns a user's sessions, most recently active first
+func (s *Store) ListSessions(userID string) ([]*Session, error) {
+	return s.storage.ListSessions(userID)
+}
+
+// RevokeSession ends one of a user's sessions
+func (s *Store) RevokeSession(userID, sessionID string) error {
+	return s.storage.DeleteSessionByID(userID, sessionID)
+}
+
+// RevokeAllSessions ends all of a user's sessions except the one with
+// exceptToken, which may be empty. Returns the number ended.
+func (s *Store) RevokeAllSessions(userID, exceptToken string) (int, error) {
+	return s.storage.DeleteUserSessions(userID, exceptToken)
 }
 
 // DeleteSession deletes a session
 func (s *Store) DeleteSession(token string) {
-	s.mu.Lock()
-	delete(s.sessions, token)
-	s.mu.Unlock()
+	if err := s.storage.DeleteSession(token); err != nil {
+		log.Printf("error deleting session: %v", err)
+	}
 }
 
 // GetUser gets a user by username
 func (s *Store) GetUser(username string) (*User, error) {
-	s.mu.RLock()
-	user, exists := s.users[username]
-	s.mu.RUnlock()
-	
-	if !exists {
-		return nil, ErrUserNotFound
-	}
-	
-	return user, nil
+	return s.storage.GetUserByUsername(username)
 }
 
-// ListUsers returns all users (admin only)
-func (s *Store) ListUsers() []*User {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	
-	users := make([]*User, 0, len(s.users))
-	for _, user := range s.users {
-		users = append(users, user)
-	}
-	
-	return users
+// ListUsers returns all users (admin only), ordered by username
+func (s *Store) ListUsers() ([]*User, error) {
+	return s.storage.ListUsers()
 }
 
-// ResetUserPassword resets a user's password (admin only)
+// ResetUserPassword resets a user's password (admin only). The user must
+// choose a new password at their next login, and their sessions are ended.
 func (s *Store) ResetUserPassword(username, newPassword string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	
-	// Check if user exists
-	user, exists := s.users[username]
-	if !exists {
-		return ErrUserNotFound
-	}
-	
+	user, err := s.storage.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+
 	// Hash new password
 	hash, err := HashPassword(newPassword)
 	if err != nil {
 		return err
 	}
-	
-	// Update password
+
 	user.PasswordHash = hash
-	
-	// Save to file if persistence is enabled
-	s.save()
-	
-	return nil
+	user.MustChangePassword = true
+	if err := s.storage.UpdateUser(user); err != nil {
+		return err
+	}
+	_, err = s.storage.DeleteUserSessions(user.ID, "")
+	return err
 }
 
-// DeleteUser deletes a user by username
-func (s *Store) DeleteUser(username string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	
-	// Check if user exists
-	if _, exists := s.users[username]; !exists {
-		return ErrUserNotFound
-	}
-	
-	// Delete user
-	delete(s.users, username)
-	
-	// Save to file if persistence is enabled
-	s.save()
-	
-	return nil
+// ChangePassword changes a user's own password after checking the current
+// one, clears any forced change, and ends the user's other sessions
+func (s *Store) ChangePassword(userID, currentPassword, newPassword, currentToken string) error {
+	user, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !VerifyPassword(currentPassword, user.PasswordHash) {
+		return ErrInvalidPassword
+	}
+	if newPassword == currentPassword {
+		return ErrPasswordUnchanged
+	}
+
+	hash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	user.MustChangePassword = false
+	if err := s.storage.UpdateUser(user); err != nil {
+		return err
+	}
+	_, err = s.storage.DeleteUserSessions(user.ID, currentToken)
+	return err
 }
 
-// GetUserByICalToken finds a user by their iCal subscription token
-func (s *Store) GetUserByICalToken(token string) (*User, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// SetUserRole changes a user's role (admin only)
+func (s *Store) SetUserRole(username string, role UserRole) (*User, error) {
+	if !ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	user, err := s.storage.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = role
+	if err := s.storage.UpdateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
 
-	for _, user := range s.users {
-		if user.ICalToken == token {
-			return user, nil
-		}
+// RequirePasswordChange makes a user choose a new password at next login
+func (s *Store) RequirePasswordChange(username string) error {
+	user, err := s.storage.GetUserByUsername(username)
+	if err != nil {
+		return err
 	}
+	user.MustChangePassword = true
+	return s.storage.UpdateUser(user)
+}
 
-	return nil, ErrUserNotFound
+// DeleteUser deletes a user by username, along with their sessions and API
+// tokens
+func (s *Store) DeleteUser(username string) error {
+	user, err := s.storage.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+	return s.storage.DeleteUser(user.ID)
+}
+
+// GetUserByICalToken finds a user by their iCal subscription token
+func (s *Store) GetUserByICalToken(token string) (*User, error) {
+	return s.storage.GetUserByICalToken(token)
 }
 
 // RegenerateICalToken generates a new iCal token for a user
 func (s *Store) RegenerateICalToken(username string) (string, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	user, exists := s.users[username]
-	if !exists {
-		return "", ErrUserNotFound
+	user, err := s.storage.GetUserByUsername(username)
+	if err != nil {
+		return "", err
 	}
 
 	user.ICalToken = uuid.New().String()
-	s.save()
+	if err := s.storage.UpdateUser(user); err != nil {
+		return "", err
+	}
 
 	return user.ICalToken, nil
 }
 
+// ensureUser returns the user with a username, creating one if needed, for
+// users vouched for by an identity provider or proxy. A non-empty role is
+// applied to new and existing users alike; otherwise new users get
+// defaultRole. Created users have no usable password until an admin sets one.
+func (s *Store) ensureUser(username string, role, defaultRole UserRole) (*User, error) {
+	user, err := s.storage.GetUserByUsername(username)
+	if err == nil {
+		if role != "" && user.Role != role {
+			user.Role = role
+			if err := s.storage.UpdateUser(user); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+	} else if err != ErrUserNotFound {
+		return nil, err
+	}
+
+	hash, err := HashPassword(randomHex(32))
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		role = defaultRole
+	}
+	user = NewUser(username, hash, role)
+	if err := s.storage.CreateUser(user); err != nil {
+		return nil, err
+	}
+	log.Printf("provisioned user %s", username)
+	return user, nil
+}
+
 // cleanupExpiredSessions runs periodically to clean up expired sessions
 func (s *Store) cleanupExpiredSessions() {
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
-	
+
 	for range ticker.C {
-		s.mu.Lock()
-		for token, session := range s.sessions {
-			if session.IsExpired() {
-				delete(s.sessions, token)
-			}
+		if n, err := s.storage.DeleteExpiredSessions(time.Now()); err != nil {
+			log.Printf("error cleaning up expired sessions: %v", err)
+		} else if n > 0 {
+			log.Printf("cleaned up %d expired sessions", n)
 		}
-		s.mu.Unlock()
+		s.cleanupExpiredChallenges()
 	}
-}
\ No newline at end of file
+}
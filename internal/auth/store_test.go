@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/database"
+)
+
+// newTestStore returns a store on the database in dir. Opening the same dir
+// twice simulates a restart.
+func newTestStore(t *testing.T, dir string) *Store {
+	t.Helper()
+	db, err := database.New(dir)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewStore(NewSQLStorage(db.DB))
+}
+
+func TestSessionsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestStore(t, dir)
+	user, err := store.CreateUser("alice", "secret")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	session, err := store.CreateSession(user.ID, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	reopened := newTestStore(t, dir)
+	got, err := reopened.GetUserBySession(session.Token)
+	if err != nil || got.ID != user.ID {
+		t.Fatalf("session after restart = %v, %v; want alice", got, err)
+	}
+
+	// Deleting the user ends their sessions
+	if err := reopened.DeleteUser("alice"); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if _, err := reopened.GetSession(session.Token); err != ErrSessionNotFound {
+		t.Errorf("session of deleted user = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	db, err := database.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+	storage := NewSQLStorage(db.DB)
+	store := NewStore(storage)
+
+	user, err := store.CreateUser("alice", "secret")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	live, _ := store.CreateSession(user.ID, "test", "127.0.0.1")
+	expired := NewSession(user.ID)
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := storage.CreateSession(expired); err != nil {
+		t.Fatalf("create expired session: %v", err)
+	}
+
+	n, err := storage.DeleteExpiredSessions(time.Now())
+	if err != nil || n != 1 {
+		t.Errorf("DeleteExpiredSessions = %d, %v; want 1", n, err)
+	}
+	if _, err := store.GetSession(live.Token); err != nil {
+		t.Errorf("live session was removed: %v", err)
+	}
+	if _, err := store.GetSession(expired.Token); err != ErrSessionNotFound {
+		t.Errorf("expired session lookup = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestImportUsersFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "users.json")
+	hash, _ := HashPassword("secret")
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	data := PersistentData{
+		Users: map[string]*PersistentUser{
+			"admin": {ID: "admin-id", Username: "admin", Role: RoleAdmin, PasswordHash: hash, ICalToken: "ical-admin", CreatedAt: created},
+			"bob":   {ID: "bob-id", Username: "bob", Role: "user", PasswordHash: hash, CreatedAt: created},
+		},
+		Tokens: []*PersistentAPIToken{
+			{ID: "tok-1", UserID: "bob-id", Name: "cron", Scope: ScopeRead, Hint: "abcd", Hash: hashToken("syl_bob"), CreatedAt: created},
+		},
+	}
+	raw, _ := json.Marshal(data)
+	if err := os.WriteFile(file, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newTestStore(t, dir)
+	n, err := store.ImportUsersFile(file)
+	if err != nil || n != 2 {
+		t.Fatalf("import = %d, %v; want 2 users", n, err)
+	}
+
+	if _, err := store.AuthenticateUser("bob", "secret"); err != nil {
+		t.Errorf("imported user can't log in: %v", err)
+	}
+	if u, err := store.GetUserByICalToken("ical-admin"); err != nil || u.ID != "admin-id" {
+		t.Errorf("iCal token after import = %v, %v; want admin", u, err)
+	}
+	if bob, _ := store.GetUser("bob"); bob == nil || bob.ICalToken == "" || !bob.CreatedAt.Equal(created) || bob.Role != RoleEditor {
+		t.Errorf("bob after import = %+v; want an editor with an iCal token and the original created_at", bob)
+	}
+	if u, _, err := store.GetUserByAPIToken("syl_bob"); err != nil || u.ID != "bob-id" {
+		t.Errorf("API token after import = %v, %v; want bob", u, err)
+	}
+
+	// The file is moved aside so the import only runs once
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("users.json still present after import: %v", err)
+	}
+	if _, err := os.Stat(file + ".imported"); err != nil {
+		t.Errorf("users.json.imported missing: %v", err)
+	}
+	if n, err := store.ImportUsersFile(file); err != nil || n != 0 {
+		t.Errorf("second import = %d, %v; want a no-op", n, err)
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxFailuresPerLogin = 5  // Failed logins for one username from one address before that pair is locked
+	maxFailuresPerUser  = 20 // Failed logins for one username from any address before it's locked everywhere
+	maxFailuresPerIP    = 20 // Failed logins from one address before it's locked
+	failureWindow       = 15 * time.Minute
+	lockoutDuration     = 15 * time.Minute
+)
+
+// LoginThrottle counts failed logins per client address, per username and
+// address pair, and per username, and locks any of them out for a while once
+// it has too many within the window. A few failures only lock the pair, so
+// one address can't lock an account's owner out from another; a username
+// failing from many addresses at once is locked everywhere.
+type LoginThrottle struct {
+	mu       sync.Mutex
+	failures map[string]*failureRecord
+	now      func() time.Time
+}
+
+type failureRecord struct {
+	count       int
+	first       time.Time
+	lockedUntil time.Time
+}
+
+// NewLoginThrottle creates a throttle with no recorded failures
+func NewLoginThrottle() *LoginThrottle {
+	return &LoginThrottle{failures: make(map[string]*failureRecord), now: time.Now}
+}
+
+// Allow reports whether a login from ip for username may be attempted, and
+// if not, how long until it may. username may be empty.
+func (t *LoginThrottle) Allow(ip, username string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	var wait time.Duration
+	for _, key := range throttleKeys(ip, username) {
+		if record, ok := t.failures[key.name]; ok && record.lockedUntil.After(now) {
+			if remaining := record.lockedUntil.Sub(now); remaining > wait {
+				wait = remaining
+			}
+		}
+	}
+	return wait, wait == 0
+}
+
+// Failure records a failed login from ip for username, which may be empty
+func (t *LoginThrottle) Failure(ip, username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.prune(now)
+	for _, key := range throttleKeys(ip, username) {
+		record, ok := t.failures[key.name]
+		if !ok || now.Sub(record.first) > failureWindow {
+			record = &failureRecord{first: now}
+			t.failures[key.name] = record
+		}
+		record.count++
+
+		if record.count >= key.limit {
+			record.lockedUntil = now.Add(lockoutDuration)
+			record.count = 0
+			record.first = now
+		}
+	}
+}
+
+// Success forgets the failures for a username from ip after a successful
+// login. Failures from the address are kept, so guessing one account's
+// password doesn't reset the count for the others, and so are the
+// username's failures from elsewhere.
+func (t *LoginThrottle) Success(ip, username string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, key := range throttleKeys(ip, username) {
+		if key.limit == maxFailuresPerLogin {
+			delete(t.failures, key.name)
+		}
+	}
+}
+
+// prune drops records that are neither locked nor within the window
+func (t *LoginThrottle) prune(now time.Time) {
+	for key, record := range t.failures {
+		if now.After(record.lockedUntil) && now.Sub(record.first) > failureWindow {
+			delete(t.failures, key)
+		}
+	}
+}
+
+// throttleKey is a failure counter and the number of failures that lock it
+type throttleKey struct {
+	name  string
+	limit int
+}
+
+func throttleKeys(ip, username string) []throttleKey {
+	var keys []throttleKey
+	if ip != "" {
+		keys = append(keys, throttleKey{"ip:" + ip, maxFailuresPerIP})
+	}
+	if username = strings.ToLower(strings.TrimSpace(username)); username != "" {
+		keys = append(keys,
+			throttleKey{"login:" + username + "@" + ip, maxFailuresPerLogin},
+			throttleKey{"user:" + username, maxFailuresPerUser})
+	}
+	return keys
+}
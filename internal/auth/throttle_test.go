@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := NewLoginThrottle()
+	throttle.now = func() time.Time { return now }
+
+	// Failures for one username lock it from that address only
+	for i := 0; i < maxFailuresPerLogin; i++ {
+		if _, ok := throttle.Allow("10.0.0.1", "Alice"); !ok {
+			t.Fatalf("locked after %d failures, want %d", i, maxFailuresPerLogin)
+		}
+		throttle.Failure("10.0.0.1", "Alice")
+	}
+	if wait, ok := throttle.Allow("10.0.0.1", "alice"); ok || wait != lockoutDuration {
+		t.Errorf("alice after %d failures = %v, %v; want locked for %v", maxFailuresPerLogin, wait, ok, lockoutDuration)
+	}
+	if _, ok := throttle.Allow("10.0.0.2", "alice"); !ok {
+		t.Errorf("alice locked from another address")
+	}
+	if _, ok := throttle.Allow("10.0.0.1", "bob"); !ok {
+		t.Errorf("bob locked by alice's failures")
+	}
+
+	// The lockout is temporary
+	now = now.Add(lockoutDuration + time.Second)
+	if _, ok := throttle.Allow("10.0.0.1", "alice"); !ok {
+		t.Errorf("alice still locked after the lockout")
+	}
+
+	// Failures outside the window don't add up
+	for i := 0; i < maxFailuresPerLogin-1; i++ {
+		throttle.Failure("10.0.0.3", "carol")
+	}
+	now = now.Add(failureWindow + time.Second)
+	throttle.Failure("10.0.0.3", "carol")
+	if _, ok := throttle.Allow("10.0.0.3", "carol"); !ok {
+		t.Errorf("carol locked by failures outside the window")
+	}
+
+	// A success resets the username but not the address
+	throttle.Success("10.0.0.3", "carol")
+	for i := 0; i < maxFailuresPerIP-maxFailuresPerLogin; i++ {
+		throttle.Failure("10.0.0.3", "")
+	}
+	if _, ok := throttle.Allow("10.0.0.3", "carol"); !ok {
+		t.Errorf("address locked before %d failures", maxFailuresPerIP)
+	}
+	for i := 0; i < maxFailuresPerLogin; i++ {
+		throttle.Failure("10.0.0.3", "")
+	}
+	if _, ok := throttle.Allow("10.0.0.3", "dave"); ok {
+		t.Errorf("address not locked after %d failures", maxFailuresPerIP)
+	}
+}
+
+func TestLoginThrottleDistributedGuessing(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := NewLoginThrottle()
+	throttle.now = func() time.Time { return now }
+
+	// A few guesses from each of many addresses never lock a pair or an
+	// address, but add up against the username
+	for i := 0; i < maxFailuresPerUser; i++ {
+		ip := fmt.Sprintf("198.51.100.%d", i/(maxFailuresPerLogin-1))
+		if _, ok := throttle.Allow(ip, "alice"); !ok {
+			t.Fatalf("alice locked after %d failures, want %d", i, maxFailuresPerUser)
+		}
+		throttle.Failure(ip, "alice")
+		now = now.Add(time.Second)
+	}
+	if wait, ok := throttle.Allow("203.0.113.9", "Alice"); ok || wait <= 0 {
+		t.Errorf("alice from a fresh address = %v, %v; want locked", wait, ok)
+	}
+	if _, ok := throttle.Allow("203.0.113.9", "bob"); !ok {
+		t.Errorf("bob locked by alice's failures")
+	}
+
+	// A success from one address doesn't lift the lock
+	throttle.Success("203.0.113.9", "alice")
+	if _, ok := throttle.Allow("203.0.113.9", "alice"); ok {
+		t.Errorf("alice unlocked by a success")
+	}
+	now = now.Add(lockoutDuration + time.Second)
+	if _, ok := throttle.Allow("203.0.113.9", "alice"); !ok {
+		t.Errorf("alice still locked after the lockout")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"172.16.0.0/12", "10.0.0.5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &AuthHandlers{TrustedProxies: trusted}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted sender can't forward", "203.0.113.7:5000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "172.18.0.2:5000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed hops left of the client", "172.18.0.2:5000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "172.18.0.2:5000", []string{"198.51.100.1, 10.0.0.5"}, "198.51.100.1"},
+		{"repeated headers", "172.18.0.2:5000", []string{"1.2.3.4", "198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxy without the header", "172.18.0.2:5000", nil, "172.18.0.2"},
+		{"garbage in the header", "172.18.0.2:5000", []string{"198.51.100.1, not-an-ip"}, "172.18.0.2"},
+		{"ipv6 client", "[::ffff:172.18.0.2]:5000", []string{"2001:db8::1"}, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/login", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for _, header := range tt.forwarded {
+			r.Header.Add("X-Forwarded-For", header)
+		}
+		if got := h.clientIP(r); got != tt.want {
+			t.Errorf("%s: clientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTrustedProxies([]string{"not-a-cidr"}); err == nil {
+		t.Errorf("ParseTrustedProxies accepted an invalid entry")
+	}
+}
+
+// proxiedLogin posts a JSON login through a trusted proxy on behalf of client
+func proxiedLogin(t *testing.T, h *AuthHandlers, client, body string) (int, LoginResponse) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", client)
+	req.RemoteAddr = "172.18.0.2:40000"
+	rec := httptest.NewRecorder()
+	h.HandleLogin(rec, req)
+
+	var resp LoginResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("login response: %v", err)
+	}
+	return rec.Code, resp
+}
+
+func TestLoginLockoutBehindProxy(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	if _, err := store.CreateUser("grace", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	h := NewAuthHandlers(store)
+	h.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("172.16.0.0/12")}
+
+	for i := 0; i < maxFailuresPerLogin; i++ {
+		if status, _ := proxiedLogin(t, h, "198.51.100.66", `{"username":"grace","password":"wrong"}`); status != http.StatusUnauthorized {
+			t.Fatalf("failed login %d = %d, want %d", i+1, status, http.StatusUnauthorized)
+		}
+	}
+
+	// The guesser is locked out, but the proxy's other clients aren't
+	if status, _ := proxiedLogin(t, h, "198.51.100.66", `{"username":"grace","password":"secret"}`); status != http.StatusTooManyRequests {
+		t.Errorf("login from the guessing client = %d, want %d", status, http.StatusTooManyRequests)
+	}
+	if status, resp := proxiedLogin(t, h, "198.51.100.7", `{"username":"grace","password":"secret"}`); status != http.StatusOK || resp.Token == "" {
+		t.Errorf("login from another client = %d %+v, want a session", status, resp)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	if _, err := store.CreateUser("frank", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	h := NewAuthHandlers(store)
+
+	for i := 0; i < maxFailuresPerLogin; i++ {
+		if status, _ := jsonLogin(t, h.HandleLogin, "/login", `{"username":"frank","password":"wrong"}`); status != http.StatusUnauthorized {
+			t.Fatalf("failed login %d = %d, want %d", i+1, status, http.StatusUnauthorized)
+		}
+	}
+
+	// Even the right password is refused until the lockout ends
+	status, resp := jsonLogin(t, h.HandleLogin, "/login", `{"username":"frank","password":"secret"}`)
+	if status != http.StatusTooManyRequests || resp.Token != "" {
+		t.Errorf("login while locked = %d %+v, want %d", status, resp, http.StatusTooManyRequests)
+	}
+
+	h.throttle.now = func() time.Time { return time.Now().Add(lockoutDuration + time.Second) }
+	if status, resp := jsonLogin(t, h.HandleLogin, "/login", `{"username":"frank","password":"secret"}`); status != http.StatusOK || resp.Token == "" {
+		t.Errorf("login after lockout = %d %+v, want a session", status, resp)
+	}
+}
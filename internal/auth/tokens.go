@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenScope limits what a personal API token may do
+type TokenScope string
+
+const (
+	ScopeRead   TokenScope = "read"   // GET requests only
+	ScopeManage TokenScope = "manage" // Also add, remove and refresh series
+	ScopeAdmin  TokenScope = "admin"  // Also admin-only routes; admins only
+)
+
+// tokenPrefix marks syllabus API tokens so they are easy to recognise in
+// scripts and secret scanners
+const tokenPrefix = "syl_"
+
+var (
+	ErrTokenNotFound = errors.New("api token not found")
+	ErrInvalidScope  = errors.New("invalid token scope")
+)
+
+// APIToken is a named, revocable personal access token. Only a hash of the
+// secret is kept; the secret itself is shown once, when the token is created.
+type APIToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Scope      TokenScope `json:"scope"`
+	Hint       string     `json:"hint"` // Last characters of the secret, to tell tokens apart
+	Hash       string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Allows reports whether the token's scope covers the required scope
+func (t *APIToken) Allows(required TokenScope) bool {
+	return scopeRank(t.Scope) >= scopeRank(required)
+}
+
+func scopeRank(scope TokenScope) int {
+	switch scope {
+	case ScopeRead:
+		return 1
+	case ScopeManage:
+		return 2
+	case ScopeAdmin:
+		return 3
+	}
+	return 0
+}
+
+// hashToken returns the stored form of a token secret. Secrets are random
+// 256-bit values, so a plain SHA-256 is enough and keeps lookups cheap.
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken mints a token for a user and returns it with its secret.
+// The scope can't exceed what the user's role allows: read for viewers,
+// manage for editors and admin for admins.
+func (s *Store) CreateAPIToken(user *User, name string, scope TokenScope) (*APIToken, string, error) {
+	if scopeRank(scope) == 0 || scopeRank(scope) > scopeRank(maxScope(user.Role)) {
+		return nil, "", ErrInvalidScope
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	secret := tokenPrefix + hex.EncodeToString(raw)
+
+	token := &APIToken{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(name),
+		Scope:     scope,
+		Hint:      secret[len(secret)-4:],
+		Hash:      hashToken(secret),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.storage.CreateAPIToken(token); err != nil {
+		return nil, "", err
+	}
+
+	return token, secret, nil
+}
+
+// ListAPITokens returns a user's tokens, oldest first
+func (s *Store) ListAPITokens(userID string) ([]*APIToken, error) {
+	return s.storage.ListAPITokens(userID)
+}
+
+// RevokeAPIToken deletes one of a user's tokens
+func (s *Store) RevokeAPIToken(userID, tokenID string) error {
+	return s.storage.DeleteAPIToken(userID, tokenID)
+}
+
+// GetUserByAPIToken resolves a token secret to its token and owner
+func (s *Store) GetUserByAPIToken(secret string) (*User, *APIToken, error) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return nil, nil, ErrTokenNotFound
+	}
+
+	token, err := s.storage.GetAPITokenByHash(hashToken(secret))
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.storage.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Record use, persisting at most once a minute per token
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > time.Minute {
+		if err := s.storage.TouchAPIToken(token.ID, now); err != nil {
+			log.Printf("error recording use of api token %s: %v", token.ID, err)
+		}
+		token.LastUsedAt = &now
+	}
+	return user, token, nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPITokenScopes(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	user, err := store.CreateUserWithRole("alice", "secret", RoleEditor)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	_, readSecret, err := store.CreateAPIToken(user, "dashboard", ScopeRead)
+	if err != nil {
+		t.Fatalf("create read token: %v", err)
+	}
+	manage, manageSecret, err := store.CreateAPIToken(user, "cron", ScopeManage)
+	if err != nil {
+		t.Fatalf("create manage token: %v", err)
+	}
+	if _, _, err := store.CreateAPIToken(user, "root", ScopeAdmin); err != ErrInvalidScope {
+		t.Errorf("non-admin created an admin token: %v", err)
+	}
+	viewer, err := store.CreateUser("victor", "secret")
+	if err != nil {
+		t.Fatalf("create viewer: %v", err)
+	}
+	if _, _, err := store.CreateAPIToken(viewer, "cron", ScopeManage); err != ErrInvalidScope {
+		t.Errorf("viewer created a manage token: %v", err)
+	}
+
+	m := NewMiddleware(store)
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		if u, _ := GetUserFromContext(r); u == nil || u.ID != user.ID {
+			t.Errorf("%s %s: request user = %v, want alice", r.Method, r.URL.Path, u)
+		}
+	}
+	handlers := NewAuthHandlers(store)
+
+	tests := []struct {
+		name    string
+		method  string
+		handler http.HandlerFunc
+		secret  string
+		want    int
+	}{
+		{"read token can read", "GET", m.RequireAuth(ok), readSecret, http.StatusOK},
+		{"read token can't write", "POST", m.RequireAuth(ok), readSecret, http.StatusForbidden},
+		{"manage token can write", "POST", m.RequireAuth(ok), manageSecret, http.StatusOK},
+		{"manage token can't use admin routes", "GET", m.RequireAdmin(ok), manageSecret, http.StatusForbidden},
+		{"tokens can't mint tokens", "POST", m.RequireAuth(handlers.HandleCreateToken), manageSecret, http.StatusForbidden},
+		{"unknown token", "GET", m.RequireAuth(ok), "syl_0000", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/series", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.secret)
+			rec := httptest.NewRecorder()
+			tt.handler(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	// Revoked tokens stop working
+	if err := store.RevokeAPIToken(user.ID, manage.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, _, err := store.GetUserByAPIToken(manageSecret); err != ErrTokenNotFound {
+		t.Errorf("revoked token still resolves: %v", err)
+	}
+	if got, _ := store.ListAPITokens(user.ID); len(got) != 1 || got[0].Name != "dashboard" {
+		t.Errorf("tokens after revoke = %v, want only dashboard", got)
+	}
+}
+
+func TestAPITokensPersistHashed(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestStore(t, dir)
+	user, err := store.CreateUser("alice", "secret")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(user, "cron", ScopeRead)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	reopened := newTestStore(t, dir)
+	got, _, err := reopened.GetUserByAPIToken(secret)
+	if err != nil || got.ID != user.ID {
+		t.Errorf("token after restart = %v, %v; want alice", got, err)
+	}
+	tokens, err := reopened.ListAPITokens(user.ID)
+	if err != nil || len(tokens) != 1 {
+		t.Fatalf("tokens after restart = %v, %v; want one", tokens, err)
+	}
+	if tokens[0].Hash == secret || tokens[0].Hash != hashToken(secret) {
+		t.Error("token secret not stored as a hash")
+	}
+}
@@ -0,0 +1,712 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// mfaChallengeCookie carries a browser's login challenge from the password
+// step to /login/2fa
+const mfaChallengeCookie = "mfa_challenge"
+
+const (
+	totpIssuer           = "syllabus"
+	totpPeriod           = 30
+	recoveryCodeCount    = 10
+	challengeTTL         = 5 * time.Minute
+	maxChallengeAttempts = 5
+
+	// settingRequireAdminTOTP is the runtime setting that makes admins enroll
+	settingRequireAdminTOTP = "require_admin_2fa"
+)
+
+var (
+	ErrInvalidCode          = errors.New("invalid two-factor code")
+	ErrChallengeNotFound    = errors.New("login challenge not found or expired")
+	ErrTOTPAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotPending       = errors.New("two-factor enrollment has not been started")
+	ErrTOTPRequiredForAdmin = errors.New("two-factor authentication is required for admins")
+)
+
+// loginChallenge is a login that passed the password check and awaits a
+// second factor. Challenges are short-lived and kept in memory.
+type loginChallenge struct {
+	userID    string
+	expiresAt time.Time
+	attempts  int
+	enroll    *otp.Key // Secret to confirm, for admins who must enroll
+}
+
+// BeginTOTPEnrollment generates a new secret for a user, pending until a
+// code from it is confirmed with EnableTOTP
+func (s *Store) BeginTOTPEnrollment(userID string) (*otp.Key, error) {
+	user, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: totpIssuer, AccountName: user.Username, Period: totpPeriod})
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = key.Secret()
+	if err := s.storage.UpdateUser(user); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EnableTOTP confirms enrollment with a code from the pending secret and
+// returns a fresh set of single-use recovery codes
+func (s *Store) EnableTOTP(userID, code string) ([]string, error) {
+	user, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotPending
+	}
+	if err := s.checkTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	if err := s.storage.UpdateUser(user); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(userID)
+}
+
+// DisableTOTP turns off two-factor authentication for a user after checking
+// their password. Admins can't while 2FA is required for them.
+func (s *Store) DisableTOTP(userID, password string) error {
+	user, err := s.storage.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !VerifyPassword(password, user.PasswordHash) {
+		return ErrInvalidPassword
+	}
+	if user.IsAdmin() && s.AdminTOTPRequired() {
+		return ErrTOTPRequiredForAdmin
+	}
+	return s.clearTOTP(user)
+}
+
+// ResetTOTP turns off two-factor authentication for a user who lost their
+// authenticator (admin only). Admins who must use 2FA enroll again at their
+// next login.
+func (s *Store) ResetTOTP(username string) error {
+	user, err := s.storage.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+	return s.clearTOTP(user)
+}
+
+// clearTOTP removes a user's secret and recovery codes
+func (s *Store) clearTOTP(user *User) error {
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := s.storage.UpdateUser(user); err != nil {
+		return err
+	}
+	return s.storage.ReplaceRecoveryCodes(user.ID, nil)
+}
+
+// RecoveryCodesRemaining returns how many unused recovery codes a user has
+func (s *Store) RecoveryCodesRemaining(userID string) (int, error) {
+	return s.storage.CountRecoveryCodes(userID)
+}
+
+// AdminTOTPRequired reports whether admins must use two-factor authentication
+func (s *Store) AdminTOTPRequired() bool {
+	value, err := s.storage.GetSetting(settingRequireAdminTOTP)
+	return err == nil && value == "true"
+}
+
+// SetAdminTOTPRequired sets whether admins must use two-factor authentication.
+// When turned on, admins without it are logged out so they enroll at their
+// next password login.
+func (s *Store) SetAdminTOTPRequired(required bool) error {
+	value := "false"
+	if required {
+		value = "true"
+	}
+	if err := s.storage.SetSetting(settingRequireAdminTOTP, value); err != nil || !required {
+		return err
+	}
+
+	users, err := s.storage.ListUsers()
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.IsAdmin() && !user.TOTPEnabled {
+			if _, err := s.storage.DeleteUserSessions(user.ID, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NeedsSecondFactor reports whether a password login must be completed with
+// CompleteTwoFactorLogin: users with 2FA, and admins who must enroll
+func (s *Store) NeedsSecondFactor(user *User) bool {
+	return user.TOTPEnabled || (user.IsAdmin() && s.AdminTOTPRequired())
+}
+
+// BeginTwoFactorLogin records that a user passed the password check and
+// returns the challenge to complete with CompleteTwoFactorLogin. For users
+// who must enroll it also returns the new secret to confirm.
+func (s *Store) BeginTwoFactorLogin(user *User) (string, *otp.Key, error) {
+	challenge := &loginChallenge{userID: user.ID, expiresAt: time.Now().Add(challengeTTL)}
+	if !user.TOTPEnabled {
+		key, err := s.BeginTOTPEnrollment(user.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		challenge.enroll = key
+	}
+
+	token := randomHex(32)
+	s.mu.Lock()
+	s.challenges[token] = challenge
+	s.mu.Unlock()
+	return token, challenge.enroll, nil
+}
+
+// PendingEnrollment returns the secret a login challenge must confirm, or
+// nil if the challenge is unknown or for a user who already has 2FA
+func (s *Store) PendingEnrollment(token string) *otp.Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if challenge, ok := s.challenges[token]; ok && time.Now().Before(challenge.expiresAt) {
+		return challenge.enroll
+	}
+	return nil
+}
+
+// CompleteTwoFactorLogin checks a TOTP or recovery code against a login
+// challenge, enabling 2FA first if the challenge is an enrollment, in which
+// case the new recovery codes are returned. A challenge allows a few
+// attempts and is consumed on success.
+func (s *Store) CompleteTwoFactorLogin(token, code string) (*User, []string, error) {
+	s.mu.Lock()
+	challenge, ok := s.challenges[token]
+	if ok && (time.Now().After(challenge.expiresAt) || challenge.attempts >= maxChallengeAttempts) {
+		delete(s.challenges, token)
+		ok = false
+	}
+	if ok {
+		challenge.attempts++
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil, ErrChallengeNotFound
+	}
+
+	var codes []string
+	var err error
+	if challenge.enroll != nil {
+		codes, err = s.EnableTOTP(challenge.userID, code)
+	} else {
+		var user *User
+		if user, err = s.storage.GetUserByID(challenge.userID); err == nil {
+			err = s.verifySecondFactor(user, code)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.challenges, token)
+	s.mu.Unlock()
+
+	user, err := s.storage.GetUserByID(challenge.userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, codes, nil
+}
+
+// verifySecondFactor accepts a current TOTP code or an unused recovery code
+func (s *Store) verifySecondFactor(user *User, code string) error {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if isDigits(code) {
+		return s.checkTOTP(user, code)
+	}
+	return s.storage.UseRecoveryCode(user.ID, hashToken(normalizeRecoveryCode(code)))
+}
+
+// checkTOTP validates a code from the user's secret, allowing one step of
+// clock skew either way, and records its step so it can't be used again
+func (s *Store) checkTOTP(user *User, code string) error {
+	if len(code) != 6 || user.TOTPSecret == "" {
+		return ErrInvalidCode
+	}
+
+	now := time.Now().Unix() / totpPeriod
+	for step := now - 1; step <= now+1; step++ {
+		if step <= user.TOTPLastStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(user.TOTPSecret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			user.TOTPLastStep = step
+			return s.storage.UpdateUser(user)
+		}
+	}
+	return ErrInvalidCode
+}
+
+// newRecoveryCodes replaces a user's recovery codes and returns the new ones
+func (s *Store) newRecoveryCodes(userID string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := randomHex(5)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	if err := s.storage.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// cleanupExpiredChallenges drops login challenges that can no longer be completed
+func (s *Store) cleanupExpiredChallenges() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, challenge := range s.challenges {
+		if time.Now().After(challenge.expiresAt) {
+			delete(s.challenges, token)
+		}
+	}
+}
+
+// totpQRCode renders a provisioning URI as a PNG data URL
+func totpQRCode(key *otp.Key) (string, error) {
+	img, err := key.Image(200, 200)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newTOTPSetupResponse describes a secret for an authenticator app
+func newTOTPSetupResponse(key *otp.Key) *TOTPSetupResponse {
+	qrCode, err := totpQRCode(key)
+	if err != nil {
+		log.Printf("error rendering totp qr code: %v", err)
+	}
+	return &TOTPSetupResponse{Success: true, Secret: key.Secret(), URI: key.URL(), QRCode: qrCode}
+}
+
+// beginTwoFactorLogin starts the second login step for a user who passed the
+// password check. Browsers keep the challenge in a cookie.
+func (h *AuthHandlers) beginTwoFactorLogin(w http.ResponseWriter, r *http.Request, user *User, asJSON bool) {
+	challenge, key, err := h.store.BeginTwoFactorLogin(user)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if asJSON {
+		response := LoginResponse{
+			Success:           false,
+			Message:           "Two-factor code required",
+			TwoFactorRequired: true,
+			Challenge:         challenge,
+		}
+		if key != nil {
+			response.Message = "Two-factor authentication is required; enroll with the code from this secret"
+			response.TOTPSetup = newTOTPSetupResponse(key)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     mfaChallengeCookie,
+		Value:    challenge,
+		Path:     "/login",
+		MaxAge:   int(challengeTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/login?step=2fa", http.StatusSeeOther)
+}
+
+// HandleTwoFactorLogin completes a password login with a TOTP or recovery
+// code, or for admins who must enroll, with the first code from their new secret
+func (h *AuthHandlers) HandleTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req TwoFactorLoginRequest
+	asJSON := r.Header.Get("Content-Type") == "application/json"
+	if asJSON {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+			return
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		req.Code = r.FormValue("code")
+		if cookie, err := r.Cookie(mfaChallengeCookie); err == nil {
+			req.Challenge = cookie.Value
+		}
+	}
+
+	ip := h.clientIP(r)
+	if wait, ok := h.throttle.Allow(ip, ""); !ok {
+		loginLocked(w, r, wait, asJSON)
+		return
+	}
+
+	user, recoveryCodes, err := h.store.CompleteTwoFactorLogin(req.Challenge, req.Code)
+	if err != nil {
+		if err != ErrInvalidCode && err != ErrChallengeNotFound {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		h.throttle.Failure(ip, "")
+		if asJSON {
+			message := "Invalid two-factor code"
+			if err == ErrChallengeNotFound {
+				message = "Login expired; sign in again"
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(LoginResponse{Success: false, Message: message})
+			return
+		}
+		if err == ErrChallengeNotFound {
+			http.Redirect(w, r, "/login?error=expired", http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, "/login?step=2fa&error=code", http.StatusSeeOther)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: mfaChallengeCookie, Value: "", Path: "/login", MaxAge: -1})
+	h.startSession(w, r, user, asJSON, recoveryCodes)
+}
+
+// HandleTOTPStatus reports the current user's two-factor state
+func (h *AuthHandlers) HandleTOTPStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := sessionUser(w, r, "Two-factor authentication can only be managed from a logged-in session")
+	if !ok {
+		return
+	}
+
+	remaining, err := h.store.RecoveryCodesRemaining(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to load two-factor status", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TOTPStatusResponse{
+		Enabled:                user.TOTPEnabled,
+		Required:               user.IsAdmin() && h.store.AdminTOTPRequired(),
+		RecoveryCodesRemaining: remaining,
+	})
+}
+
+// HandleTOTPSetup starts enrollment with a new secret for the current user
+func (h *AuthHandlers) HandleTOTPSetup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := sessionUser(w, r, "Two-factor authentication can only be managed from a logged-in session")
+	if !ok {
+		return
+	}
+
+	key, err := h.store.BeginTOTPEnrollment(user.ID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		message := "Failed to start two-factor enrollment"
+		if err == ErrTOTPAlreadyEnabled {
+			statusCode = http.StatusConflict
+			message = "Two-factor authentication is already enabled"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newTOTPSetupResponse(key))
+}
+
+// HandleTOTPEnable confirms enrollment with a code and returns recovery codes
+func (h *AuthHandlers) HandleTOTPEnable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := sessionUser(w, r, "Two-factor authentication can only be managed from a logged-in session")
+	if !ok {
+		return
+	}
+
+	var req TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Code is required",
+		})
+		return
+	}
+
+	codes, err := h.store.EnableTOTP(user.ID, strings.TrimSpace(req.Code))
+	if err != nil {
+		var statusCode int
+		var message string
+
+		switch err {
+		case ErrInvalidCode:
+			statusCode = http.StatusBadRequest
+			message = "Invalid code; check your device's clock and try again"
+		case ErrTOTPNotPending:
+			statusCode = http.StatusBadRequest
+			message = "Start enrollment first"
+		case ErrTOTPAlreadyEnabled:
+			statusCode = http.StatusConflict
+			message = "Two-factor authentication is already enabled"
+		default:
+			statusCode = http.StatusInternalServerError
+			message = "Failed to enable two-factor authentication"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":        true,
+		"message":        "Two-factor authentication enabled; store these recovery codes safely, they won't be shown again",
+		"recovery_codes": codes,
+	})
+}
+
+// HandleTOTPDisable turns off two-factor authentication for the current user
+func (h *AuthHandlers) HandleTOTPDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := sessionUser(w, r, "Two-factor authentication can only be managed from a logged-in session")
+	if !ok {
+		return
+	}
+
+	var req TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Password is required",
+		})
+		return
+	}
+
+	if err := h.store.DisableTOTP(user.ID, req.Password); err != nil {
+		var statusCode int
+		var message string
+
+		switch err {
+		case ErrInvalidPassword:
+			statusCode = http.StatusForbidden
+			message = "Password is incorrect"
+		case ErrTOTPRequiredForAdmin:
+			statusCode = http.StatusForbidden
+			message = "Two-factor authentication is required for admins"
+		default:
+			statusCode = http.StatusInternalServerError
+			message = "Failed to disable two-factor authentication"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// HandleResetTOTP turns off two-factor authentication for a user (admin only)
+func (h *AuthHandlers) HandleResetTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Username is required",
+		})
+		return
+	}
+
+	if err := h.store.ResetTOTP(req.Username); err != nil {
+		statusCode := http.StatusInternalServerError
+		message := "Failed to reset two-factor authentication"
+		if err == ErrUserNotFound {
+			statusCode = http.StatusNotFound
+			message = "User not found"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Two-factor authentication reset",
+	})
+}
+
+// HandleRequireAdminTOTP turns the two-factor requirement for admins on or
+// off (admin only). An admin must enroll before requiring it of everyone.
+func (h *AuthHandlers) HandleRequireAdminTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Required bool `json:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Invalid request format",
+		})
+		return
+	}
+
+	if user, ok := GetUserFromContext(r); ok && req.Required && !user.TOTPEnabled {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Enable two-factor authentication for your own account first",
+		})
+		return
+	}
+
+	if err := h.store.SetAdminTOTPRequired(req.Required); err != nil {
+		http.Error(w, "Failed to save setting", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"required": req.Required,
+	})
+}
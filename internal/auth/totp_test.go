@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// jsonLogin posts a JSON body to a login handler and decodes the response
+func jsonLogin(t *testing.T, handler http.HandlerFunc, path, body string) (int, LoginResponse) {
+	t.Helper()
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	var resp LoginResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("%s response: %v", path, err)
+	}
+	return rec.Code, resp
+}
+
+// codeAt returns the TOTP code for a secret at an offset from now
+func codeAt(t *testing.T, secret string, offset time.Duration) string {
+	t.Helper()
+	code, err := totp.GenerateCode(secret, time.Now().Add(offset))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTOTPLogin(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	user, err := store.CreateUser("erin", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewAuthHandlers(store)
+
+	key, err := store.BeginTOTPEnrollment(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.EnableTOTP(user.ID, "000000"); err != ErrInvalidCode {
+		t.Errorf("enable with wrong code = %v, want ErrInvalidCode", err)
+	}
+	enrollCode := codeAt(t, key.Secret(), 0)
+	recoveryCodes, err := store.EnableTOTP(user.ID, enrollCode)
+	if err != nil || len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("enable = %d codes, %v", len(recoveryCodes), err)
+	}
+
+	// The password alone only gets a challenge
+	status, resp := jsonLogin(t, h.HandleLogin, "/login", `{"username":"erin","password":"secret"}`)
+	if status != http.StatusOK || !resp.TwoFactorRequired || resp.Challenge == "" || resp.Token != "" {
+		t.Fatalf("password step = %d %+v, want a challenge and no session", status, resp)
+	}
+	challenge := resp.Challenge
+
+	tests := []struct {
+		name string
+		code string
+		want int
+	}{
+		{"wrong code", "123456", http.StatusUnauthorized},
+		{"replayed enrollment code", enrollCode, http.StatusUnauthorized},
+		{"next code", codeAt(t, key.Secret(), totpPeriod*time.Second), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"challenge":"` + challenge + `","code":"` + tt.code + `"}`
+			status, resp := jsonLogin(t, h.HandleTwoFactorLogin, "/login/2fa", body)
+			if status != tt.want {
+				t.Errorf("second step = %d %+v, want %d", status, resp, tt.want)
+			}
+			if status == http.StatusOK && resp.Token == "" {
+				t.Errorf("second step succeeded without a session")
+			}
+		})
+	}
+
+	// The challenge is spent; a recovery code works once on a new one
+	body := `{"challenge":"` + challenge + `","code":"` + recoveryCodes[0] + `"}`
+	if status, _ := jsonLogin(t, h.HandleTwoFactorLogin, "/login/2fa", body); status != http.StatusUnauthorized {
+		t.Errorf("reused challenge = %d, want %d", status, http.StatusUnauthorized)
+	}
+	for i, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		_, resp := jsonLogin(t, h.HandleLogin, "/login", `{"username":"erin","password":"secret"}`)
+		body := `{"challenge":"` + resp.Challenge + `","code":"` + strings.ToUpper(recoveryCodes[0]) + `"}`
+		if status, _ := jsonLogin(t, h.HandleTwoFactorLogin, "/login/2fa", body); status != want {
+			t.Errorf("recovery code use %d = %d, want %d", i+1, status, want)
+		}
+	}
+	if n, _ := store.RecoveryCodesRemaining(user.ID); n != recoveryCodeCount-1 {
+		t.Errorf("recovery codes remaining = %d, want %d", n, recoveryCodeCount-1)
+	}
+
+	// A challenge allows only a few guesses
+	_, resp = jsonLogin(t, h.HandleLogin, "/login", `{"username":"erin","password":"secret"}`)
+	for i := 0; i < maxChallengeAttempts; i++ {
+		store.CompleteTwoFactorLogin(resp.Challenge, "000000")
+	}
+	if _, _, err := store.CompleteTwoFactorLogin(resp.Challenge, codeAt(t, key.Secret(), 0)); err != ErrChallengeNotFound {
+		t.Errorf("challenge after %d failures = %v, want ErrChallengeNotFound", maxChallengeAttempts, err)
+	}
+
+	// Disabling needs the password
+	if err := store.DisableTOTP(user.ID, "nope"); err != ErrInvalidPassword {
+		t.Errorf("disable with wrong password = %v, want ErrInvalidPassword", err)
+	}
+	if err := store.DisableTOTP(user.ID, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if status, resp := jsonLogin(t, h.HandleLogin, "/login", `{"username":"erin","password":"secret"}`); status != http.StatusOK || resp.Token == "" {
+		t.Errorf("login after disabling = %d %+v, want a session", status, resp)
+	}
+}
+
+func TestAdminTOTPRequired(t *testing.T) {
+	store := newTestStore(t, t.TempDir())
+	admin, err := store.CreateUserWithRole("root", "secret", RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _ := store.CreateSession(admin.ID, "Firefox", "10.0.0.2")
+	h := NewAuthHandlers(store)
+
+	// Requiring 2FA ends the sessions of admins without it
+	if err := store.SetAdminTOTPRequired(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetSession(session.Token); err != ErrSessionNotFound {
+		t.Errorf("unenrolled admin session = %v, want ErrSessionNotFound", err)
+	}
+
+	// Their next password login enrolls them
+	status, resp := jsonLogin(t, h.HandleLogin, "/login", `{"username":"root","password":"secret"}`)
+	if status != http.StatusOK || !resp.TwoFactorRequired || resp.TOTPSetup == nil || resp.TOTPSetup.Secret == "" {
+		t.Fatalf("password step = %d %+v, want an enrollment challenge", status, resp)
+	}
+	body := `{"challenge":"` + resp.Challenge + `","code":"` + codeAt(t, resp.TOTPSetup.Secret, 0) + `"}`
+	status, resp = jsonLogin(t, h.HandleTwoFactorLogin, "/login/2fa", body)
+	if status != http.StatusOK || resp.Token == "" || len(resp.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("enrollment step = %d %+v, want a session and recovery codes", status, resp)
+	}
+
+	if err := store.DisableTOTP(admin.ID, "secret"); err != ErrTOTPRequiredForAdmin {
+		t.Errorf("admin disabling required 2FA = %v, want ErrTOTPRequiredForAdmin", err)
+	}
+
+	// An admin reset makes them enroll again
+	if err := store.ResetTOTP("root"); err != nil {
+		t.Fatal(err)
+	}
+	if _, resp := jsonLogin(t, h.HandleLogin, "/login", `{"username":"root","password":"secret"}`); resp.TOTPSetup == nil {
+		t.Errorf("login after reset = %+v, want an enrollment challenge", resp)
+	}
+
+	// Enabling the requirement needs the acting admin to have 2FA
+	req := httptest.NewRequest("POST", "/api/users/require-2fa", strings.NewReader(`{"required":true}`))
+	reset, _ := store.GetUser("root")
+	rec := httptest.NewRecorder()
+	h.HandleRequireAdminTOTP(rec, req.WithContext(context.WithValue(req.Context(), UserContextKey, reset)))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("require 2FA without own 2FA = %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
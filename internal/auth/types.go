@@ -10,8 +10,13 @@ import (
 type UserRole string
 
 const (
-	RoleAdmin UserRole = "admin"
-	RoleUser  UserRole = "user"
+	RoleViewer UserRole = "viewer" // Read-only
+	RoleEditor UserRole = "editor" // Also manages series and scrapes
+	RoleAdmin  UserRole = "admin"  // Also manages users and settings
+
+	// roleLegacyUser is the role every non-admin had before viewers and
+	// editors; such users are editors now
+	roleLegacyUser UserRole = "user"
 )
 
 // User represents a user in the system
@@ -22,15 +27,25 @@ type User struct {
 	PasswordHash string    `json:"-"`          // Never serialize in API responses
 	ICalToken    string    `json:"ical_token"` // Token for iCal subscription auth
 	CreatedAt    time.Time `json:"created_at"`
+
+	// MustChangePassword sends the user to /change-password before anything else
+	MustChangePassword bool `json:"must_change_password"`
+
+	TOTPEnabled  bool   `json:"totp_enabled"`
+	TOTPSecret   string `json:"-"` // Pending during enrollment, active once TOTPEnabled
+	TOTPLastStep int64  `json:"-"` // Time step of the last accepted code, to prevent replay
 }
 
 // Session represents a user session
 type Session struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Token      string    `json:"token"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
 }
 
 // LoginRequest represents a login request
@@ -39,19 +54,30 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
-// LoginResponse represents a login response
+// LoginResponse represents a login response. When TwoFactorRequired is set,
+// the login is completed by posting Challenge and a code to /login/2fa.
 type LoginResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message,omitempty"`
-	Token   string `json:"token,omitempty"`
-	User    *User  `json:"user,omitempty"`
+	Success           bool               `json:"success"`
+	Message           string             `json:"message,omitempty"`
+	Token             string             `json:"token,omitempty"`
+	User              *User              `json:"user,omitempty"`
+	TwoFactorRequired bool               `json:"two_factor_required,omitempty"`
+	Challenge         string             `json:"challenge,omitempty"`      // Send with the code to /login/2fa
+	TOTPSetup         *TOTPSetupResponse `json:"totp_setup,omitempty"`     // Secret to enroll, if 2FA is required
+	RecoveryCodes     []string           `json:"recovery_codes,omitempty"` // Shown once, after enrolling
+}
+
+// TwoFactorLoginRequest represents the second step of a login
+type TwoFactorLoginRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"` // TOTP code or recovery code
 }
 
 // CreateUserRequest represents a user creation request
 type CreateUserRequest struct {
 	Username string   `json:"username"`
 	Password string   `json:"password"`
-	Role     UserRole `json:"role,omitempty"` // Optional, defaults to user
+	Role     UserRole `json:"role,omitempty"` // Optional, defaults to viewer
 }
 
 // CreateUserResponse represents a user creation response
@@ -63,7 +89,8 @@ type CreateUserResponse struct {
 
 // ListUsersResponse represents a user list response
 type ListUsersResponse struct {
-	Users []*User `json:"users"`
+	Users           []*User `json:"users"`
+	RequireAdmin2FA bool    `json:"require_admin_2fa"`
 }
 
 // ResetPasswordRequest represents a password reset request
@@ -78,10 +105,83 @@ type ResetPasswordResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// CreateTokenRequest represents an API token creation request
+type CreateTokenRequest struct {
+	Name  string     `json:"name"`
+	Scope TokenScope `json:"scope"`
+}
+
+// CreateTokenResponse represents an API token creation response. Secret is
+// only ever returned here.
+type CreateTokenResponse struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message,omitempty"`
+	Secret  string    `json:"secret,omitempty"`
+	Token   *APIToken `json:"token,omitempty"`
+}
+
+// ListTokensResponse represents an API token list response
+type ListTokensResponse struct {
+	Tokens []*APIToken `json:"tokens"`
+}
+
+// RevokeTokenRequest represents an API token revocation request
+type RevokeTokenRequest struct {
+	ID string `json:"id"`
+}
+
+// ChangePasswordRequest represents a request to change one's own password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// SessionInfo describes a session without its token
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // The session making the request
+}
+
+// ListSessionsResponse represents the current user's sessions
+type ListSessionsResponse struct {
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+// RevokeSessionRequest represents a session revocation request
+type RevokeSessionRequest struct {
+	ID string `json:"id"`
+}
+
+// TOTPSetupResponse carries a new TOTP secret for enrollment
+type TOTPSetupResponse struct {
+	Success bool   `json:"success"`
+	Secret  string `json:"secret"`
+	URI     string `json:"uri"`               // otpauth:// provisioning URI
+	QRCode  string `json:"qr_code,omitempty"` // PNG data URL of the URI
+}
+
+// TOTPCodeRequest carries a TOTP code, or for disabling, the password
+type TOTPCodeRequest struct {
+	Code     string `json:"code,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// TOTPStatusResponse describes the current user's two-factor state
+type TOTPStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"` // Admins must enroll
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
 // NewUser creates a new user with a generated ID
 func NewUser(username, passwordHash string, role UserRole) *User {
 	if role == "" {
-		role = RoleUser
+		role = RoleViewer
 	}
 	return &User{
 		ID:           uuid.New().String(),
@@ -100,12 +200,14 @@ func (u *User) IsAdmin() bool {
 
 // NewSession creates a new session for a user
 func NewSession(userID string) *Session {
+	now := time.Now()
 	return &Session{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		Token:     uuid.New().String(),
-		ExpiresAt: time.Now().Add(24 * time.Hour), // 24 hour sessions
-		CreatedAt: time.Now(),
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		Token:      uuid.New().String(),
+		ExpiresAt:  now.Add(24 * time.Hour), // 24 hour sessions
+		CreatedAt:  now,
+		LastSeenAt: now,
 	}
 }
 
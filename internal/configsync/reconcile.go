@@ -0,0 +1,256 @@
+// Package configsync keeps the series in the database and the audiobooks list
+// in books.yaml in step, in both directions.
+package configsync
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/models"
+	"github.com/michaeldvinci/syllabus/internal/scraper"
+)
+
+// Action is what a reconcile does to one series
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionUpdate Action = "update"
+	ActionRemove Action = "remove"
+)
+
+// Change is one difference between books.yaml and the database
+type Change struct {
+	Action Action
+	Entry  models.SeriesIDs // The file's entry; empty for removals
+	Series *database.Series // The stored series; nil for additions
+}
+
+func (c Change) String() string {
+	switch c.Action {
+	case ActionAdd:
+		return fmt.Sprintf("add %q (audible %q, amazon %q)", c.Entry.Title, c.Entry.AudibleID, c.Entry.AmazonASIN)
+	case ActionUpdate:
+		return fmt.Sprintf("update %q (audible %q -> %q, amazon %q -> %q)", c.Entry.Title,
+			stringValue(c.Series.AudibleID), c.Entry.AudibleID, stringValue(c.Series.AmazonASIN), c.Entry.AmazonASIN)
+	default:
+		return fmt.Sprintf("remove %q (ID %d)", c.Series.Title, c.Series.ID)
+	}
+}
+
+// Reconciler brings the series table in line with books.yaml. An entry that
+// hasn't changed since it was last loaded leaves its series alone, so edits
+// made in the UI stick; series only ever added in the UI are never removed.
+type Reconciler struct {
+	DB      *database.Service
+	Scraper *scraper.BackgroundScraper // Scrapes added and updated series; optional
+	Writer  *Writer                    // UI write-back to wait for; optional
+	DryRun  bool                       // Log the changes without applying them
+	OnAdded func(*database.Series)     // Called for each series the file adds
+}
+
+// Reconcile applies the file's entries to the database and returns the changes
+func (r *Reconciler) Reconcile(entries []models.SeriesIDs) ([]Change, error) {
+	if r.Writer != nil {
+		r.Writer.mu.Lock()
+		defer r.Writer.mu.Unlock()
+	}
+	entries = uniqueEntries(entries)
+	series, err := r.DB.GetAllSeries()
+	if err != nil {
+		return nil, err
+	}
+	configEntries, err := r.DB.GetConfigEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := Diff(entries, series, configEntries)
+	if len(entries) == 0 && len(configEntries) > 0 {
+		// Most likely the file was read halfway through being saved
+		log.Printf("config sync: books.yaml lists no series, not removing the %d loaded from it", len(configEntries))
+		changes = withoutRemovals(changes)
+	}
+	for _, change := range changes {
+		if r.DryRun {
+			log.Printf("config sync (dry run): would %s", change)
+		} else {
+			log.Printf("config sync: %s", change)
+		}
+	}
+	if r.DryRun {
+		return changes, nil
+	}
+
+	// Entries that haven't changed are left alone, changed ones are applied to
+	// the series Diff matched them with, and all of them record which entry
+	// their series came from
+	changed := make(map[string]bool)
+	updates := make(map[string]*database.Series)
+	for _, change := range changes {
+		if change.Action != ActionRemove {
+			changed[change.Entry.Title] = true
+		}
+		if change.Action == ActionUpdate {
+			updates[change.Entry.Title] = change.Series
+		}
+	}
+	for _, e := range entries {
+		var s *database.Series
+		var created bool
+		var err error
+		if target := updates[e.Title]; target != nil {
+			s, err = r.DB.ApplyConfigEntry(target.ID, e)
+		} else {
+			s, created, err = r.DB.UpsertConfigSeries(e)
+		}
+		if err != nil {
+			log.Printf("config sync: error loading %s: %v", e.Title, err)
+			continue
+		}
+		if created && r.OnAdded != nil {
+			r.OnAdded(s)
+		}
+		if changed[e.Title] {
+			r.queue(s)
+		}
+	}
+	for _, change := range changes {
+		if change.Action != ActionRemove {
+			continue
+		}
+		if err := r.DB.DeleteSeries(change.Series.ID); err != nil {
+			log.Printf("config sync: error removing %s: %v", change.Series.Title, err)
+		}
+	}
+	return changes, nil
+}
+
+// queue scrapes a series from every provider it's tracked on
+func (r *Reconciler) queue(s *database.Series) {
+	if r.Scraper == nil {
+		return
+	}
+	if s.AudibleID != nil {
+		if err := r.Scraper.QueueSeriesUpdate(s.ID, database.ProviderAudible); err != nil {
+			log.Printf("config sync: error queuing audible scrape for %s: %v", s.Title, err)
+		}
+	}
+	if s.AmazonASIN != nil {
+		if err := r.Scraper.QueueSeriesUpdate(s.ID, database.ProviderAmazon); err != nil {
+			log.Printf("config sync: error queuing amazon scrape for %s: %v", s.Title, err)
+		}
+	}
+}
+
+// Diff works out what loading entries would change. configEntries holds the
+// entry each series was last loaded from, as returned by GetConfigEntries.
+// Titles in entries must be unique.
+func Diff(entries []models.SeriesIDs, series []database.Series, configEntries map[int]database.ConfigEntry) []Change {
+	byEntry := make(map[database.ConfigEntry]*database.Series)
+	byTitle := make(map[string]*database.Series)
+	for i := range series {
+		s := &series[i]
+		if entry, ok := configEntries[s.ID]; ok {
+			byEntry[entry] = s
+		}
+		byTitle[s.Title] = s
+	}
+
+	// Unchanged entries keep their series before any changed entry is matched
+	kept := make(map[int]bool)
+	for _, e := range entries {
+		if s := byEntry[database.NewConfigEntry(e)]; s != nil {
+			kept[s.ID] = true
+		}
+	}
+
+	var changes []Change
+	for _, e := range entries {
+		key := database.NewConfigEntry(e)
+		if byEntry[key] != nil {
+			continue
+		}
+		s := match(e, series, byTitle, configEntries, kept)
+		if s == nil {
+			changes = append(changes, Change{Action: ActionAdd, Entry: e})
+			continue
+		}
+		kept[s.ID] = true
+		// A series loaded from an earlier version of the entry is updated even
+		// with the same IDs, since only its overrides may have changed
+		loaded, fromFile := configEntries[s.ID]
+		if stringValue(s.AudibleID) != e.AudibleID || stringValue(s.AmazonASIN) != e.AmazonASIN ||
+			stringValue(s.AudibleURL) != e.AudibleURL || (fromFile && loaded != key) {
+			changes = append(changes, Change{Action: ActionUpdate, Entry: e, Series: s})
+		}
+	}
+
+	for i := range series {
+		s := &series[i]
+		if _, fromFile := configEntries[s.ID]; fromFile && !kept[s.ID] {
+			changes = append(changes, Change{Action: ActionRemove, Series: s})
+		}
+	}
+	return changes
+}
+
+// match finds the series a changed entry belongs to: the one with its title,
+// else the one last loaded from an entry with its title or, failing that, its
+// IDs. The stored entry is what finds a series renamed in the UI, which would
+// otherwise be removed and added again, losing its history. Series already
+// kept by another entry are skipped.
+func match(e models.SeriesIDs, series []database.Series, byTitle map[string]*database.Series, configEntries map[int]database.ConfigEntry, kept map[int]bool) *database.Series {
+	if s := byTitle[e.Title]; s != nil && !kept[s.ID] {
+		return s
+	}
+	for i := range series {
+		if was, ok := configEntries[series[i].ID]; ok && !kept[series[i].ID] && was.Title == e.Title {
+			return &series[i]
+		}
+	}
+	if e.AudibleID == "" && e.AmazonASIN == "" {
+		return nil
+	}
+	for i := range series {
+		was, ok := configEntries[series[i].ID]
+		if ok && !kept[series[i].ID] && was.AudibleID == e.AudibleID && was.AmazonASIN == e.AmazonASIN {
+			return &series[i]
+		}
+	}
+	return nil
+}
+
+// uniqueEntries drops entries repeating an earlier entry's title, since
+// titles are unique in the database
+func uniqueEntries(entries []models.SeriesIDs) []models.SeriesIDs {
+	seen := make(map[string]bool)
+	unique := make([]models.SeriesIDs, 0, len(entries))
+	for _, e := range entries {
+		if seen[e.Title] {
+			log.Printf("config sync: ignoring repeated entry for %s", e.Title)
+			continue
+		}
+		seen[e.Title] = true
+		unique = append(unique, e)
+	}
+	return unique
+}
+
+func withoutRemovals(changes []Change) []Change {
+	var kept []Change
+	for _, change := range changes {
+		if change.Action != ActionRemove {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,245 @@
+package configsync
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+func newTestService(t *testing.T) *database.Service {
+	t.Helper()
+	db, err := database.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return database.NewService(db)
+}
+
+func entry(title, audibleID, amazonASIN string) models.SeriesIDs {
+	e := models.SeriesIDs{Title: title, AudibleID: audibleID, AmazonASIN: amazonASIN}
+	if audibleID != "" {
+		e.AudibleURL = "https://www.audible.com/series/" + audibleID
+	}
+	if amazonASIN != "" {
+		e.AmazonURL = "https://www.amazon.com/dp/" + amazonASIN
+	}
+	return e
+}
+
+// titles returns the stored series' titles, sorted
+func titles(t *testing.T, svc *database.Service) []string {
+	t.Helper()
+	series, err := svc.GetAllSeries()
+	if err != nil {
+		t.Fatalf("GetAllSeries: %v", err)
+	}
+	var out []string
+	for _, s := range series {
+		out = append(out, s.Title)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestReconcile(t *testing.T) {
+	svc := newTestService(t)
+	var added []string
+	r := &Reconciler{DB: svc, OnAdded: func(s *database.Series) { added = append(added, s.Title) }}
+	reconcile := func(entries ...models.SeriesIDs) []string {
+		t.Helper()
+		changes, err := r.Reconcile(entries)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		var out []string
+		for _, c := range changes {
+			title := c.Entry.Title
+			if c.Action == ActionRemove {
+				title = c.Series.Title
+			}
+			out = append(out, string(c.Action)+" "+title)
+		}
+		return out
+	}
+	expect := func(got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("changes = %v, want %v", got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("changes = %v, want %v", got, want)
+			}
+		}
+	}
+
+	// A series only ever added in the UI isn't the file's to remove
+	if _, err := svc.UpsertSeries("Added In The UI", "B0UIADDED1", "", ""); err != nil {
+		t.Fatalf("UpsertSeries: %v", err)
+	}
+
+	saga, chronicle, cycle := entry("The Example Saga", "B0AUDSERIE", ""), entry("The Example Chronicle", "", "B0AMZCHRON"), entry("The Example Cycle", "B0AUDCYCLE", "")
+	expect(reconcile(saga, chronicle, saga), "add The Example Saga", "add The Example Chronicle")
+	expect(added, "The Example Saga", "The Example Chronicle")
+	expect(reconcile(saga, chronicle))
+
+	// Edits in the UI stick while the file's entry is unchanged
+	stored, err := svc.GetSeriesByTitle("The Example Saga")
+	if err != nil || stored == nil {
+		t.Fatalf("GetSeriesByTitle: %v", err)
+	}
+	if _, _, err := svc.UpdateSeries(stored.ID, "The Example Saga (Unabridged)", "B0AUDSERIE", "", ""); err != nil {
+		t.Fatalf("UpdateSeries: %v", err)
+	}
+	expect(reconcile(saga, chronicle))
+
+	// Changed entries update their series; missing ones are removed
+	chronicle.AudibleID, chronicle.AudibleURL = "B0AUDCHRON", "https://www.audible.com/series/B0AUDCHRON"
+	r.DryRun = true
+	expect(reconcile(chronicle, cycle), "update The Example Chronicle", "add The Example Cycle", "remove The Example Saga (Unabridged)")
+	expect(titles(t, svc), "Added In The UI", "The Example Chronicle", "The Example Saga (Unabridged)")
+
+	r.DryRun = false
+	expect(reconcile(chronicle, cycle), "update The Example Chronicle", "add The Example Cycle", "remove The Example Saga (Unabridged)")
+	expect(titles(t, svc), "Added In The UI", "The Example Chronicle", "The Example Cycle")
+	updated, err := svc.GetSeriesByTitle("The Example Chronicle")
+	if err != nil || updated == nil || updated.AudibleID == nil || *updated.AudibleID != "B0AUDCHRON" {
+		t.Errorf("updated series = %+v (%v)", updated, err)
+	}
+
+	// Pinning a value in the file updates the series too
+	count := 12
+	cycle.AudibleOverride.Count = &count
+	expect(reconcile(chronicle, cycle), "update The Example Cycle")
+	stats, err := svc.GetAllSeriesStats()
+	if err != nil {
+		t.Fatalf("GetAllSeriesStats: %v", err)
+	}
+	for _, s := range stats {
+		if s.Title == "The Example Cycle" && s.AudibleCount != 12 {
+			t.Errorf("pinned count = %d, want 12", s.AudibleCount)
+		}
+	}
+	expect(reconcile(chronicle, cycle))
+
+	// A file caught halfway through a save doesn't empty the library
+	expect(reconcile())
+	expect(titles(t, svc), "Added In The UI", "The Example Chronicle", "The Example Cycle")
+}
+
+func TestReconcileRenamedSeries(t *testing.T) {
+	svc := newTestService(t)
+	r := &Reconciler{DB: svc}
+	reconcile := func(entries ...models.SeriesIDs) []Change {
+		t.Helper()
+		changes, err := r.Reconcile(entries)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		return changes
+	}
+	stored := func() *database.Series {
+		t.Helper()
+		series, err := svc.GetAllSeries()
+		if err != nil || len(series) != 1 {
+			t.Fatalf("GetAllSeries = %+v, %v; want one series", series, err)
+		}
+		return &series[0]
+	}
+
+	saga := entry("The Example Saga", "B0AUDSERIE", "")
+	reconcile(saga)
+	original := stored()
+	info := models.SeriesInfo{AudibleCount: 1, AudibleBooks: []models.Book{{Title: "Book One", Position: 1}}}
+	if err := svc.UpdateSeriesBooks(original.ID, database.ProviderAudible, info); err != nil {
+		t.Fatalf("UpdateSeriesBooks: %v", err)
+	}
+	if _, _, err := svc.UpdateSeries(original.ID, "The Example Saga (Unabridged)", "B0AUDSERIE", saga.AudibleURL, ""); err != nil {
+		t.Fatalf("UpdateSeries: %v", err)
+	}
+
+	// Editing the entry after the rename updates the renamed series in place
+	saga.AmazonASIN = "B0AMZSERIE"
+	changes := reconcile(saga)
+	if len(changes) != 1 || changes[0].Action != ActionUpdate || changes[0].Series.ID != original.ID {
+		t.Fatalf("changes = %v, want an update of series %d", changes, original.ID)
+	}
+	s := stored()
+	if s.ID != original.ID || s.Title != "The Example Saga (Unabridged)" || stringValue(s.AmazonASIN) != "B0AMZSERIE" || stringValue(s.AudibleID) != "B0AUDSERIE" {
+		t.Errorf("series = %+v, want the rename kept and the new amazon ASIN", s)
+	}
+	books, err := svc.GetSeriesBooks(original.ID)
+	if err != nil || len(books) != 1 {
+		t.Errorf("books = %+v, %v; want the audible book kept", books, err)
+	}
+	if changes := reconcile(saga); len(changes) != 0 {
+		t.Errorf("reloading the same entry = %v, want no changes", changes)
+	}
+
+	// So does retitling the entry, found by the IDs it was loaded with
+	saga.Title = "The Example Saga: Complete"
+	changes = reconcile(saga)
+	if len(changes) != 1 || changes[0].Action != ActionUpdate {
+		t.Fatalf("changes = %v, want a single update", changes)
+	}
+	if s := stored(); s.ID != original.ID || s.Title != "The Example Saga: Complete" {
+		t.Errorf("series = %+v, want series %d retitled by the file", s, original.ID)
+	}
+}
+
+func TestReconcileOverridesAfterUpgrade(t *testing.T) {
+	svc := newTestService(t)
+	r := &Reconciler{DB: svc}
+
+	// Loaded and renamed before overrides were read, so the stored entry has
+	// none even though the entry already set aud_num
+	series, err := svc.UpsertSeries("The Example Saga (Unabridged)", "B0AUDSERIE", "", "")
+	if err != nil {
+		t.Fatalf("UpsertSeries: %v", err)
+	}
+	if err := svc.SetConfigEntry(series.ID, database.ConfigEntry{Title: "The Example Saga", AudibleID: "B0AUDSERIE"}); err != nil {
+		t.Fatalf("SetConfigEntry: %v", err)
+	}
+	count, pinned := 3, 9
+	if err := svc.SetSeriesOverrides(series.ID, models.Override{}, models.Override{Count: &pinned}); err != nil {
+		t.Fatalf("SetSeriesOverrides: %v", err)
+	}
+	saga := entry("The Example Saga", "B0AUDSERIE", "")
+	saga.AudibleOverride.Count = &count
+
+	check := func(wantAudible, wantAmazon string) {
+		t.Helper()
+		all, err := svc.GetAllSeries()
+		if err != nil || len(all) != 1 || all[0].ID != series.ID || all[0].Title != "The Example Saga (Unabridged)" {
+			t.Fatalf("series = %+v, %v; want series %d with its rename", all, err, series.ID)
+		}
+		audible, amazon, err := svc.GetSeriesOverrides(series.ID)
+		if err != nil || audible.String() != wantAudible || amazon.String() != wantAmazon {
+			t.Errorf("overrides = %v / %v (%v), want %s / %s", audible, amazon, err, wantAudible, wantAmazon)
+		}
+	}
+
+	// The entry's overrides are loaded into the renamed series, and the one
+	// pinned in the UI stays
+	changes, err := r.Reconcile([]models.SeriesIDs{saga})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != ActionUpdate || changes[0].Series.ID != series.ID {
+		t.Fatalf("changes = %v, want an update of series %d", changes, series.ID)
+	}
+	check("3,,", "9,,")
+	if changes, err := r.Reconcile([]models.SeriesIDs{saga}); err != nil || len(changes) != 0 {
+		t.Errorf("reloading the same entry = %v, %v; want no changes", changes, err)
+	}
+
+	// Dropping aud_num from the file clears it
+	saga.AudibleOverride = models.Override{}
+	if _, err := r.Reconcile([]models.SeriesIDs{saga}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	check(",,", "9,,")
+}
@@ -0,0 +1,260 @@
+package configsync
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/models"
+	"github.com/michaeldvinci/syllabus/internal/utils"
+)
+
+// Writer writes series added or edited in the UI back to books.yaml. It edits
+// the parsed document rather than re-encoding the config, so comments, key
+// order and entries' other fields are kept; blank lines aren't.
+type Writer struct {
+	path string
+	mu   sync.Mutex // Held while saving and by a Reconciler sharing the Writer
+}
+
+// NewWriter returns a Writer for the config file at path
+func NewWriter(path string) *Writer {
+	return &Writer{path: path}
+}
+
+// SaveSeries writes a series' title, URLs and overrides to the file. The
+// entry the series was last loaded from (its config entry, empty if none) is
+// updated, or else the entry with the same title; failing both, an entry is
+// appended. URLs pointing at the listing an entry already has, and overrides
+// it already pins, are left as written. It returns the series' new config
+// entry, after passing it to record (if not nil) with the file still locked,
+// so a Reconciler sharing the Writer can't load the new file before the entry
+// is stored and take the write for an edit.
+func (w *Writer) SaveSeries(configEntry database.ConfigEntry, series models.SeriesIDs, record func(database.ConfigEntry) error) (database.ConfigEntry, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return database.ConfigEntry{}, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return database.ConfigEntry{}, fmt.Errorf("parse %s: %w", w.path, err)
+	}
+	list, err := audiobooksNode(&doc)
+	if err != nil {
+		return database.ConfigEntry{}, fmt.Errorf("%s: %w", w.path, err)
+	}
+
+	item := findEntry(list, configEntry, series.Title)
+	if item == nil {
+		item = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		list.Content = append(list.Content, item)
+		list.Style = 0 // An empty list may have been written as []
+	}
+	current := loadEntry(item)
+	if current.Title != series.Title {
+		setField(item, "title", series.Title)
+	}
+	if current.AudibleID != series.AudibleID {
+		setField(item, "audible", series.AudibleURL)
+	}
+	if current.AmazonASIN != series.AmazonASIN {
+		setField(item, "amazon", series.AmazonURL)
+	}
+	setOverride(item, "aud", current.AudibleOverride, series.AudibleOverride)
+	setOverride(item, "amzn", current.AmazonOverride, series.AmazonOverride)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return database.ConfigEntry{}, err
+	}
+	if err := enc.Close(); err != nil {
+		return database.ConfigEntry{}, err
+	}
+	if err := writeFileAtomic(w.path, buf.Bytes()); err != nil {
+		return database.ConfigEntry{}, err
+	}
+	entry := entryKey(item)
+	if record != nil {
+		if err := record(entry); err != nil {
+			return entry, fmt.Errorf("record config entry: %w", err)
+		}
+	}
+	return entry, nil
+}
+
+// audiobooksNode returns the audiobooks sequence, adding it if it's missing
+func audiobooksNode(doc *yaml.Node) (*yaml.Node, error) {
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config isn't a mapping")
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "audiobooks" {
+			continue
+		}
+		value := root.Content[i+1]
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			*value = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		}
+		if value.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("audiobooks isn't a list")
+		}
+		return value, nil
+	}
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "audiobooks"}, list)
+	return list, nil
+}
+
+// findEntry returns the entry loaded as configEntry, else the one with title
+func findEntry(list *yaml.Node, configEntry database.ConfigEntry, title string) *yaml.Node {
+	for _, item := range list.Content {
+		if item.Kind == yaml.MappingNode && configEntry.Title != "" && entryKey(item) == configEntry {
+			return item
+		}
+	}
+	for _, item := range list.Content {
+		if item.Kind == yaml.MappingNode && field(item, "title") == title {
+			return item
+		}
+	}
+	return nil
+}
+
+// loadEntry reads an item the way the file is loaded, ignoring invalid
+// overrides
+func loadEntry(item *yaml.Node) models.SeriesIDs {
+	var e models.Entry
+	if err := item.Decode(&e); err != nil {
+		e = models.Entry{Title: field(item, "title"), Audible: field(item, "audible"), Amazon: field(item, "amazon")}
+	}
+	ids, _ := utils.EntryToSeriesIDs(e)
+	return ids
+}
+
+// entryKey is the config entry an item loads as
+func entryKey(item *yaml.Node) database.ConfigEntry {
+	return database.NewConfigEntry(loadEntry(item))
+}
+
+func field(item *yaml.Node, key string) string {
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		if item.Content[i].Value == key {
+			return item.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// setOverride writes a provider's pinned values (prefix aud or amzn) where
+// they differ from what the item pins now
+func setOverride(item *yaml.Node, prefix string, current, want models.Override) {
+	if !sameCount(current.Count, want.Count) {
+		if want.Count == nil {
+			setField(item, prefix+"_num", "")
+		} else {
+			setScalar(item, prefix+"_num", strconv.Itoa(*want.Count), "!!int")
+		}
+	}
+	if !sameDate(current.LatestDate, want.LatestDate) {
+		setField(item, prefix+"_last", formatDate(want.LatestDate))
+	}
+	if !sameDate(current.NextDate, want.NextDate) {
+		setField(item, prefix+"_next", formatDate(want.NextDate))
+	}
+}
+
+func sameCount(a, b *int) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
+
+func sameDate(a, b *time.Time) bool {
+	return formatDate(a) == formatDate(b)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// setField sets a mapping's string field, removing it when value is empty
+func setField(item *yaml.Node, key, value string) {
+	setScalar(item, key, value, "!!str")
+}
+
+// setScalar sets a mapping's field to a scalar with the given tag, removing
+// it when value is empty. Strings are written quoted.
+func setScalar(item *yaml.Node, key, value, tag string) {
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		if item.Content[i].Value != key {
+			continue
+		}
+		if value == "" {
+			item.Content = append(item.Content[:i], item.Content[i+2:]...)
+			return
+		}
+		node := item.Content[i+1]
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, tag, value
+		if tag != "!!str" {
+			node.Style = 0
+		}
+		return
+	}
+	if value != "" {
+		style := yaml.DoubleQuotedStyle
+		if tag != "!!str" {
+			style = 0
+		}
+		item.Content = append(item.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value, Style: style})
+	}
+}
+
+// writeFileAtomic replaces a file so readers see either the old contents or
+// the new, never a partial write
+func writeFileAtomic(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
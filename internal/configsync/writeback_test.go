@@ -0,0 +1,221 @@
+package configsync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/models"
+	"github.com/michaeldvinci/syllabus/internal/utils"
+)
+
+const testConfig = `# My series
+settings:
+  server_port: 8080 # Default port
+
+audiobooks:
+  # Space opera
+  - title: "The Example Saga"
+    audible: "[Audible](https://www.audible.com/series/The-Example-Saga-Audiobooks/B0AUDSERIE)"
+    amazon: "https://www.amazon.com/dp/B0AMZSERIE"
+    aud_num: 3 # Pinned
+  - title: "The Example Chronicle"
+    amazon: "https://www.amazon.com/dp/B0AMZCHRON"
+`
+
+func TestSaveSeries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0640); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWriter(path)
+
+	// Renaming keeps the entry's comments, other fields and the link it
+	// already had for the same listing
+	saga := utils.ToSeriesIDs([]models.Entry{{
+		Title:   "The Example Saga",
+		Audible: "https://www.audible.com/series/B0AUDSERIE",
+		Amazon:  "https://www.amazon.com/dp/B0AMZSERIE",
+		AudNum:  3,
+	}})[0]
+	sagaEntry := database.NewConfigEntry(saga)
+	saga.Title, saga.AmazonASIN = "The Example Saga (Unabridged)", "B0AMZOTHER"
+	saga.AmazonURL = "https://www.amazon.co.uk/Example-Saga-Unabridged/dp/B0AMZOTHER"
+	key, err := w.SaveSeries(sagaEntry, saga, nil)
+	if err != nil {
+		t.Fatalf("SaveSeries: %v", err)
+	}
+	if want := database.NewConfigEntry(saga); key != want {
+		t.Errorf("entry = %+v, want %+v", key, want)
+	}
+
+	// An unknown series is appended
+	if _, err := w.SaveSeries(database.ConfigEntry{}, entry("The Example Cycle", "B0AUDCYCLE", ""), nil); err != nil {
+		t.Fatalf("SaveSeries: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Blank lines are the one thing yaml.v3 doesn't keep
+	want := `# My series
+settings:
+  server_port: 8080 # Default port
+audiobooks:
+  # Space opera
+  - title: "The Example Saga (Unabridged)"
+    audible: "[Audible](https://www.audible.com/series/The-Example-Saga-Audiobooks/B0AUDSERIE)"
+    amazon: "https://www.amazon.co.uk/Example-Saga-Unabridged/dp/B0AMZOTHER"
+    aud_num: 3 # Pinned
+  - title: "The Example Chronicle"
+    amazon: "https://www.amazon.com/dp/B0AMZCHRON"
+  - title: "The Example Cycle"
+    audible: "https://www.audible.com/series/B0AUDCYCLE"
+`
+	if string(got) != want {
+		t.Errorf("config =\n%s\nwant\n%s", got, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v (%v), want 0640", info.Mode().Perm(), err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".books.yaml.*")); len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+
+	// The file still loads, and as the entries SaveSeries reported
+	cfg, err := utils.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	series := utils.ToSeriesIDs(cfg.Audiobooks)
+	if len(series) != 3 || database.NewConfigEntry(series[0]) != key {
+		t.Errorf("loaded %+v", series)
+	}
+}
+
+func TestSaveSeriesToEmptyList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.yaml")
+	if err := os.WriteFile(path, []byte("settings:\n  server_port: 8080\naudiobooks: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWriter(path).SaveSeries(database.ConfigEntry{}, entry("The Example Saga", "", "B0AMZSERIE"), nil); err != nil {
+		t.Fatalf("SaveSeries: %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	want := "settings:\n  server_port: 8080\naudiobooks:\n  - title: \"The Example Saga\"\n    amazon: \"https://www.amazon.com/dp/B0AMZSERIE\"\n"
+	if string(got) != want {
+		t.Errorf("config =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSaveSeriesOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := utils.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	saga := utils.ToSeriesIDs(cfg.Audiobooks)[0]
+
+	// The unchanged count keeps its comment; a cleared one is removed
+	next, _ := time.Parse("2006-01-02", "2099-06-15")
+	saga.AudibleOverride.NextDate = &next
+	saga.AmazonOverride.Count = saga.AudibleOverride.Count
+	key, err := NewWriter(path).SaveSeries(database.NewConfigEntry(utils.ToSeriesIDs(cfg.Audiobooks)[0]), saga, nil)
+	if err != nil {
+		t.Fatalf("SaveSeries: %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	want := `    aud_num: 3 # Pinned
+    aud_next: "2099-06-15"
+    amzn_num: 3
+`
+	if !strings.Contains(string(got), want) {
+		t.Errorf("config =\n%s\nwant it to contain\n%s", got, want)
+	}
+
+	cfg, err = utils.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if loaded := utils.ToSeriesIDs(cfg.Audiobooks)[0]; database.NewConfigEntry(loaded) != key || loaded.AudibleOverride.String() != "3,,2099-06-15" {
+		t.Errorf("loaded %+v as %+v, want %+v", loaded, database.NewConfigEntry(loaded), key)
+	}
+
+	saga.AudibleOverride = models.Override{}
+	if _, err := NewWriter(path).SaveSeries(key, saga, nil); err != nil {
+		t.Fatalf("SaveSeries: %v", err)
+	}
+	if got, _ := os.ReadFile(path); strings.Contains(string(got), "aud_") {
+		t.Errorf("cleared overrides still written:\n%s", got)
+	}
+}
+
+func TestSaveSeriesBeforeReconcile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "books.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	load := func() []models.SeriesIDs {
+		t.Helper()
+		cfg, err := utils.LoadConfig(path)
+		if err != nil {
+			t.Fatalf("LoadConfig: %v", err)
+		}
+		return utils.ToSeriesIDs(cfg.Audiobooks)
+	}
+	svc := newTestService(t)
+	w := NewWriter(path)
+	r := &Reconciler{DB: svc, Writer: w}
+	if _, err := r.Reconcile(load()); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	saga, err := svc.GetSeriesByTitle("The Example Saga")
+	if err != nil || saga == nil {
+		t.Fatalf("GetSeriesByTitle = %v, %v", saga, err)
+	}
+	entries, err := svc.GetConfigEntries()
+	if err != nil {
+		t.Fatalf("GetConfigEntries: %v", err)
+	}
+	renamed := load()[0]
+	renamed.Title = "The Example Saga (Unabridged)"
+	if _, _, err := svc.UpdateSeries(saga.ID, renamed.Title, renamed.AudibleID, renamed.AudibleURL, renamed.AmazonASIN); err != nil {
+		t.Fatalf("UpdateSeries: %v", err)
+	}
+
+	// The file watcher reacts to the rename while the entry is being recorded,
+	// and waits for it rather than seeing a changed entry
+	reconciled := make(chan []Change)
+	_, err = w.SaveSeries(entries[saga.ID], renamed, func(entry database.ConfigEntry) error {
+		go func() {
+			changes, err := r.Reconcile(load())
+			if err != nil {
+				t.Errorf("Reconcile: %v", err)
+			}
+			reconciled <- changes
+		}()
+		select {
+		case changes := <-reconciled:
+			t.Fatalf("reconciled %v before the entry was recorded", changes)
+		case <-time.After(50 * time.Millisecond):
+		}
+		return svc.SetConfigEntry(saga.ID, entry)
+	})
+	if err != nil {
+		t.Fatalf("SaveSeries: %v", err)
+	}
+	if changes := <-reconciled; len(changes) != 0 {
+		t.Errorf("reconciling the written file = %v, want no changes", changes)
+	}
+	if got := titles(t, svc); strings.Join(got, ", ") != "The Example Chronicle, The Example Saga (Unabridged)" {
+		t.Errorf("series = %v", got)
+	}
+}
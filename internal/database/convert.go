@@ -7,6 +7,7 @@ import (
 // ToSeriesInfo converts database SeriesStats to models.SeriesInfo for compatibility
 func (stats SeriesStats) ToSeriesInfo() models.SeriesInfo {
 	info := models.SeriesInfo{
+		ID:    stats.ID,
 		Title: stats.Title,
 		
 		// Audible data
@@ -26,11 +27,33 @@ func (stats SeriesStats) ToSeriesInfo() models.SeriesInfo {
 		// IDs
 		AudibleID:  stringValue(stats.AudibleID),
 		AmazonASIN: stringValue(stats.AmazonASIN),
+		
+		// Staleness and last scrape failures
+		AudibleStaleSince: stats.AudibleStaleSince,
+		AmazonStaleSince:  stats.AmazonStaleSince,
+		AudibleError:      stats.AudibleLastError.ToFailure(),
+		AmazonError:       stats.AmazonLastError.ToFailure(),
+		
+		// Pinned values
+		AudibleOverride: stats.AudibleOverride,
+		AmazonOverride:  stats.AmazonOverride,
 	}
 	
 	return info
 }
 
+// ToFailure converts a failed scrape job to a models.ScrapeFailure, returning nil for a nil job
+func (j *ScrapeJob) ToFailure() *models.ScrapeFailure {
+	if j == nil {
+		return nil
+	}
+	return &models.ScrapeFailure{
+		Category: models.ErrorCategory(stringValue(j.ErrorCategory)),
+		Message:  stringValue(j.ErrorMessage),
+		FailedAt: j.CompletedAt,
+	}
+}
+
 // ToSeriesInfoSlice converts a slice of SeriesStats to models.SeriesInfo
 func ToSeriesInfoSlice(stats []SeriesStats) []models.SeriesInfo {
 	infos := make([]models.SeriesInfo, len(stats))
@@ -40,6 +63,42 @@ func ToSeriesInfoSlice(stats []SeriesStats) []models.SeriesInfo {
 	return infos
 }
 
+// ToModel converts a stored Book to the provider-neutral models.Book
+func (b Book) ToModel() models.Book {
+	book := models.Book{
+		Title:       b.Title,
+		ASIN:        stringValue(b.ASIN),
+		ReleaseDate: b.ReleaseDate,
+		IsPreorder:  b.IsPreorder,
+	}
+	if b.BookNumber != nil {
+		book.Position = *b.BookNumber
+	}
+	return book
+}
+
+// ToSeriesInfoSliceWithBooks converts stats and attaches each series' books,
+// split by provider
+func ToSeriesInfoSliceWithBooks(stats []SeriesStats, books []Book) []models.SeriesInfo {
+	audible := make(map[int][]models.Book)
+	amazon := make(map[int][]models.Book)
+	for _, b := range books {
+		switch b.Provider {
+		case ProviderAudible:
+			audible[b.SeriesID] = append(audible[b.SeriesID], b.ToModel())
+		case ProviderAmazon:
+			amazon[b.SeriesID] = append(amazon[b.SeriesID], b.ToModel())
+		}
+	}
+
+	infos := ToSeriesInfoSlice(stats)
+	for i, stat := range stats {
+		infos[i].AudibleBooks = audible[stat.ID]
+		infos[i].AmazonBooks = amazon[stat.ID]
+	}
+	return infos
+}
+
 // stringValue safely converts *string to string
 func stringValue(s *string) string {
 	if s == nil {
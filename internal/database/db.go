@@ -2,24 +2,36 @@ package database
 
 import (
 	"database/sql"
-	"embed"
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-//go:embed schema.sql
-var schemaFS embed.FS
-
 // DB wraps the database connection with our business logic
 type DB struct {
 	*sql.DB
 }
 
-// New creates a new database connection and applies migrations
+// New opens the database and applies any pending migrations
 func New(dataDir string) (*DB, error) {
+	db, err := Open(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.MigrateUp(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Open opens the database without touching its schema
+func Open(dataDir string) (*DB, error) {
 	// Ensure data directory exists
 	dbPath := filepath.Join(dataDir, "syllabus.db")
 	
@@ -36,28 +48,22 @@ func New(dataDir string) (*DB, error) {
 	sqlDB.SetMaxIdleConns(5)   // Keep some connections idle for reuse
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	db := &DB{sqlDB}
-
-	// Apply schema
-	if err := db.migrate(); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
-	}
-
-	return db, nil
+	return &DB{sqlDB}, nil
 }
 
-// migrate applies the database schema
-func (db *DB) migrate() error {
-	schema, err := schemaFS.ReadFile("schema.sql")
-	if err != nil {
-		return fmt.Errorf("failed to read schema: %w", err)
+// OpenReadOnly opens an existing database for reading only. It fails with an
+// error wrapping fs.ErrNotExist if the database hasn't been created yet.
+func OpenReadOnly(dataDir string) (*DB, error) {
+	dbPath := filepath.Join(dataDir, "syllabus.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	if _, err := db.Exec(string(schema)); err != nil {
-		return fmt.Errorf("failed to apply schema: %w", err)
+	sqlDB, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-
-	return nil
+	return &DB{sqlDB}, nil
 }
 
 // Close closes the database connection
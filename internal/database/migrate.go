@@ -0,0 +1,309 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Migration is one embedded schema change. Migrations are applied in version
+// order, each in its own transaction, and recorded in schema_migrations.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationStatus reports whether a migration has been applied to a database
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time // nil while the migration is pending
+	Legacy    bool       // Predates schema_migrations; recorded by the next migrate up
+}
+
+// legacyMarkers lists a column added by each migration that predates
+// schema_migrations. Databases created before versioned migrations are
+// stamped with the last migration whose marker column they already have.
+var legacyMarkers = []struct {
+	version       int
+	table, column string
+}{
+	{2, "books", "asin"},
+	{3, "scrape_jobs", "error_category"},
+	{4, "series", "audible_stale_since"},
+	{5, "scrape_jobs", "lease_owner"},
+	{6, "scrape_jobs", "attempts"},
+}
+
+// Migrations returns the embedded migrations in version order. Files are
+// named NNNN_description.sql.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, desc, ok := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s is not named NNNN_description.sql", entry.Name())
+		}
+
+		body, err := migrationsFS.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: desc, SQL: string(body)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration and returns how many were applied
+func (db *DB) MigrateUp() (int, error) {
+	return db.migrateTo(-1)
+}
+
+// MigrationStatus lists every embedded migration and when it was applied. It
+// only reads the database: migrations a legacy database already has are
+// marked Legacy, and a database that was never migrated has them all pending.
+func (db *DB) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time)
+	versioned, err := db.hasTable("schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if versioned {
+		if applied, err = db.appliedMigrations(); err != nil {
+			return nil, err
+		}
+	}
+	legacy := 0
+	if len(applied) == 0 {
+		if legacy, err = db.legacyVersion(); err != nil {
+			return nil, err
+		}
+	}
+
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status[i].Migration = m
+		if at, ok := applied[m.Version]; ok {
+			status[i].AppliedAt = &at
+		} else {
+			status[i].Legacy = m.Version <= legacy
+		}
+	}
+	return status, nil
+}
+
+// migrateTo applies pending migrations up to and including target, or all of
+// them when target is negative
+func (db *DB) migrateTo(target int) (int, error) {
+	if err := db.prepareMigrations(); err != nil {
+		return 0, err
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, m := range migrations {
+		if target >= 0 && m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := db.applyMigration(m); err != nil {
+			return count, err
+		}
+		log.Printf("applied migration %04d_%s", m.Version, m.Name)
+		count++
+	}
+	return count, nil
+}
+
+// prepareMigrations creates the schema_migrations table and stamps databases
+// created before versioned migrations with the migrations they already have
+func (db *DB) prepareMigrations() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+	    version INTEGER PRIMARY KEY,
+	    name TEXT NOT NULL,
+	    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var stamped int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&stamped); err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	if stamped > 0 {
+		return nil
+	}
+
+	version, err := db.legacyVersion()
+	if err != nil || version == 0 {
+		return err
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, m := range migrations {
+		if m.Version > version {
+			break
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+			return fmt.Errorf("failed to stamp migration %d: %w", m.Version, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to stamp legacy schema: %w", err)
+	}
+
+	log.Printf("adopted existing database at schema version %d", version)
+	return nil
+}
+
+// legacyVersion returns the last migration a database created before
+// versioned migrations already has, or 0 if it has no schema at all
+func (db *DB) legacyVersion() (int, error) {
+	legacy, err := db.hasTable("series")
+	if err != nil || !legacy {
+		return 0, err
+	}
+
+	version := 1
+	for _, marker := range legacyMarkers {
+		ok, err := db.hasColumn(marker.table, marker.column)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		version = marker.version
+	}
+	return version, nil
+}
+
+// appliedMigrations returns when each applied migration ran, by version
+func (db *DB) appliedMigrations() (map[int]time.Time, error) {
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration runs a migration in a transaction and records it. Foreign
+// keys are switched off for the duration so migrations can rebuild tables
+// (e.g. to change a CHECK constraint) without cascading deletes; SQLite only
+// allows that outside a transaction, hence the dedicated connection.
+func (db *DB) applyMigration(m Migration) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+	}
+
+	// With enforcement off, make sure the migration left no dangling references
+	var violations int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_foreign_key_check`).Scan(&violations); err != nil {
+		return fmt.Errorf("failed to check foreign keys after migration %d: %w", m.Version, err)
+	}
+	if violations > 0 {
+		return fmt.Errorf("migration %04d_%s left %d foreign key violations", m.Version, m.Name, violations)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+	return tx.Commit()
+}
+
+// hasTable reports whether a table exists
+func (db *DB) hasTable(table string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up table %s: %w", table, err)
+	}
+	return n > 0, nil
+}
+
+// hasColumn reports whether a table has a column
+func (db *DB) hasColumn(table, column string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,280 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+// openTestDB opens an empty database in a temporary directory
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// schemaOf describes every table's columns and the names of all other schema
+// objects. Columns are compared as a set, since ALTER TABLE appends them and
+// legacy databases created them inline.
+func schemaOf(t *testing.T, db *DB) map[string][]string {
+	t.Helper()
+	rows, err := db.Query(`SELECT type, name FROM sqlite_master WHERE name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		t.Fatalf("query sqlite_master: %v", err)
+	}
+	var objects [][2]string
+	for rows.Next() {
+		var typ, name string
+		if err := rows.Scan(&typ, &name); err != nil {
+			t.Fatalf("scan sqlite_master: %v", err)
+		}
+		objects = append(objects, [2]string{typ, name})
+	}
+	rows.Close()
+
+	schema := make(map[string][]string)
+	for _, obj := range objects {
+		key := obj[0] + " " + obj[1]
+		schema[key] = nil
+		if obj[0] != "table" {
+			continue
+		}
+
+		cols, err := db.Query(`SELECT name, type, "notnull", COALESCE(dflt_value, ''), pk FROM pragma_table_info(?)`, obj[1])
+		if err != nil {
+			t.Fatalf("table info %s: %v", obj[1], err)
+		}
+		for cols.Next() {
+			var name, typ, dflt string
+			var notNull, pk int
+			if err := cols.Scan(&name, &typ, &notNull, &dflt, &pk); err != nil {
+				t.Fatalf("scan table info %s: %v", obj[1], err)
+			}
+			schema[key] = append(schema[key], fmt.Sprintf("%s %s notnull=%d default=%s pk=%d", name, typ, notNull, dflt, pk))
+		}
+		cols.Close()
+		sort.Strings(schema[key])
+	}
+	return schema
+}
+
+// freshSchema returns the schema of a new database with every migration applied
+func freshSchema(t *testing.T) map[string][]string {
+	t.Helper()
+	db := openTestDB(t)
+	if _, err := db.MigrateUp(); err != nil {
+		t.Fatalf("migrate fresh database: %v", err)
+	}
+	return schemaOf(t, db)
+}
+
+// checkUpgraded verifies db ended up fully migrated with the same schema as a
+// fresh database, and that the service queries still work against it
+func checkUpgraded(t *testing.T, db *DB, want map[string][]string) {
+	t.Helper()
+	status, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatalf("migration status: %v", err)
+	}
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			t.Errorf("migration %04d_%s still pending", s.Version, s.Name)
+		}
+	}
+
+	if got := schemaOf(t, db); !reflect.DeepEqual(got, want) {
+		t.Errorf("upgraded schema differs from a fresh database\n got: %v\nwant: %v", got, want)
+	}
+
+	stats, err := NewService(db).GetAllSeriesStats()
+	if err != nil {
+		t.Fatalf("GetAllSeriesStats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Title != "Existing Series" {
+		t.Errorf("existing series lost during upgrade: %+v", stats)
+	}
+}
+
+func TestMigrateFromEveryVersion(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	want := freshSchema(t)
+
+	for _, from := range migrations {
+		t.Run(fmt.Sprintf("%04d_%s", from.Version, from.Name), func(t *testing.T) {
+			db := openTestDB(t)
+			if _, err := db.migrateTo(from.Version); err != nil {
+				t.Fatalf("migrate to %d: %v", from.Version, err)
+			}
+			if _, err := db.Exec(`INSERT INTO series (title, audible_id) VALUES ('Existing Series', 'B0EXISTING')`); err != nil {
+				t.Fatalf("insert series: %v", err)
+			}
+
+			if _, err := db.MigrateUp(); err != nil {
+				t.Fatalf("migrate up from %d: %v", from.Version, err)
+			}
+			checkUpgraded(t, db, want)
+
+			// A second run has nothing left to do
+			if n, err := db.MigrateUp(); err != nil || n != 0 {
+				t.Errorf("second MigrateUp = %d, %v; want 0, nil", n, err)
+			}
+		})
+	}
+}
+
+// Databases created before schema_migrations existed were built from
+// schema.sql plus ad-hoc ALTER TABLEs. testdata/legacy holds schema.sql as it
+// stood at each migration, named after the version it should be adopted at.
+func TestAdoptLegacySchema(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "legacy", "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no legacy schemas found: %v", err)
+	}
+	want := freshSchema(t)
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".sql")
+		t.Run(name, func(t *testing.T) {
+			version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+			if err != nil {
+				t.Fatalf("legacy schema %s has no version prefix", name)
+			}
+			schema, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("read %s: %v", file, err)
+			}
+
+			db := openTestDB(t)
+			if _, err := db.Exec(string(schema)); err != nil {
+				t.Fatalf("apply legacy schema: %v", err)
+			}
+			if _, err := db.Exec(`INSERT INTO series (title, audible_id) VALUES ('Existing Series', 'B0EXISTING')`); err != nil {
+				t.Fatalf("insert series: %v", err)
+			}
+
+			if err := db.prepareMigrations(); err != nil {
+				t.Fatalf("adopt legacy schema: %v", err)
+			}
+			var adopted int
+			if err := db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&adopted); err != nil {
+				t.Fatalf("read adopted version: %v", err)
+			}
+			if adopted != version {
+				t.Errorf("adopted at version %d, want %d", adopted, version)
+			}
+
+			if _, err := db.MigrateUp(); err != nil {
+				t.Fatalf("migrate up: %v", err)
+			}
+			checkUpgraded(t, db, want)
+		})
+	}
+}
+
+func TestMigrationStatusIsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenReadOnly(dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("OpenReadOnly on a missing database = %v, want fs.ErrNotExist", err)
+	}
+
+	schema, err := os.ReadFile(filepath.Join("testdata", "legacy", "04_stale_since.sql"))
+	if err != nil {
+		t.Fatalf("read legacy schema: %v", err)
+	}
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("apply legacy schema: %v", err)
+	}
+	db.Close()
+
+	ro, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatalf("open read-only: %v", err)
+	}
+	defer ro.Close()
+	status, err := ro.MigrationStatus()
+	if err != nil {
+		t.Fatalf("migration status: %v", err)
+	}
+	for _, s := range status {
+		if s.AppliedAt != nil || s.Legacy != (s.Version <= 4) {
+			t.Errorf("migration %04d: applied %v, legacy %v", s.Version, s.AppliedAt, s.Legacy)
+		}
+	}
+	if ok, err := ro.hasTable("schema_migrations"); err != nil || ok {
+		t.Errorf("status created schema_migrations (%v)", err)
+	}
+}
+
+// Config entries used to be stored as "|"-joined keys; 0018 turns them into
+// JSON without losing a "|" in the title.
+func TestMigrateConfigEntryKeys(t *testing.T) {
+	tests := []struct {
+		key  string
+		want ConfigEntry
+	}{
+		{"The Example Saga|B0AUDSERIE|B0AMZSERIE", ConfigEntry{Title: "The Example Saga", AudibleID: "B0AUDSERIE", AmazonASIN: "B0AMZSERIE"}},
+		{"Audible Only|B0AUDSERIE|", ConfigEntry{Title: "Audible Only", AudibleID: "B0AUDSERIE"}},
+		{"No IDs||", ConfigEntry{Title: "No IDs"}},
+		{"Saga | Part One|B0AUDSERIE|", ConfigEntry{Title: "Saga | Part One", AudibleID: "B0AUDSERIE"}},
+		{"Saga | Part Two||B0AMZSERIE|3,,|,,", ConfigEntry{Title: "Saga | Part Two", AmazonASIN: "B0AMZSERIE", AudibleOverride: "3,,"}},
+		{"Pinned|B0AUDPINNED|B0AMZPINNED|,,|,2024-01-10,2099-03-01", ConfigEntry{Title: "Pinned", AudibleID: "B0AUDPINNED",
+			AmazonASIN: "B0AMZPINNED", AmazonOverride: ",2024-01-10,2099-03-01"}},
+	}
+
+	db := openTestDB(t)
+	if _, err := db.migrateTo(17); err != nil {
+		t.Fatalf("migrate to 17: %v", err)
+	}
+	ids := make([]int, len(tests))
+	for i, tt := range tests {
+		res, err := db.Exec(`INSERT INTO series (title, config_entry) VALUES (?, ?)`, tt.want.Title, tt.key)
+		if err != nil {
+			t.Fatalf("insert series: %v", err)
+		}
+		id, _ := res.LastInsertId()
+		ids[i] = int(id)
+	}
+	if _, err := db.MigrateUp(); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	entries, err := NewService(db).GetConfigEntries()
+	if err != nil {
+		t.Fatalf("GetConfigEntries: %v", err)
+	}
+	for i, tt := range tests {
+		if got := entries[ids[i]]; got != tt.want {
+			t.Errorf("%q migrated to %+v, want %+v", tt.key, got, tt.want)
+		}
+	}
+
+	// The entry a migrated key came from is still recognised as unchanged,
+	// so a rename made since isn't undone
+	if _, err := db.Exec(`UPDATE series SET title = 'Renamed Saga' WHERE id = ?`, ids[3]); err != nil {
+		t.Fatalf("rename series: %v", err)
+	}
+	series, created, err := NewService(db).UpsertConfigSeries(models.SeriesIDs{Title: "Saga | Part One", AudibleID: "B0AUDSERIE"})
+	if err != nil || created || series.ID != ids[3] || series.Title != "Renamed Saga" {
+		t.Errorf("UpsertConfigSeries after migrating = %+v, %v, %v; want series %d unchanged", series, created, err, ids[3])
+	}
+}
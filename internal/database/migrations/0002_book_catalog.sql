@@ -0,0 +1,32 @@
+-- Per-book catalogs: store each book's ASIN and pick the earliest preorder as "next"
+
+ALTER TABLE books ADD COLUMN asin TEXT;
+
+DROP VIEW IF EXISTS series_stats;
+CREATE VIEW series_stats AS
+SELECT 
+    s.id,
+    s.title,
+    s.audible_id,
+    s.amazon_asin,
+    s.updated_at,
+    
+    -- Audible stats (use scraped count, not calculated count)
+    s.audible_scraped_count as audible_count,
+    MAX(CASE WHEN b.provider = 'audible' AND b.is_latest = 1 THEN b.title END) as audible_latest_title,
+    MAX(CASE WHEN b.provider = 'audible' AND b.is_latest = 1 THEN b.release_date END) as audible_latest_date,
+    (SELECT n.title FROM books n WHERE n.series_id = s.id AND n.provider = 'audible' AND n.is_preorder = 1
+        ORDER BY n.release_date IS NULL, n.release_date LIMIT 1) as audible_next_title,
+    (SELECT MIN(n.release_date) FROM books n WHERE n.series_id = s.id AND n.provider = 'audible' AND n.is_preorder = 1) as audible_next_date,
+    
+    -- Amazon stats (use scraped count, not calculated count)
+    s.amazon_scraped_count as amazon_count,
+    MAX(CASE WHEN b.provider = 'amazon' AND b.is_latest = 1 THEN b.title END) as amazon_latest_title,
+    MAX(CASE WHEN b.provider = 'amazon' AND b.is_latest = 1 THEN b.release_date END) as amazon_latest_date,
+    (SELECT n.title FROM books n WHERE n.series_id = s.id AND n.provider = 'amazon' AND n.is_preorder = 1
+        ORDER BY n.release_date IS NULL, n.release_date LIMIT 1) as amazon_next_title,
+    (SELECT MIN(n.release_date) FROM books n WHERE n.series_id = s.id AND n.provider = 'amazon' AND n.is_preorder = 1) as amazon_next_date
+    
+FROM series s
+LEFT JOIN books b ON s.id = b.series_id
+GROUP BY s.id, s.title, s.audible_id, s.amazon_asin, s.updated_at, s.audible_scraped_count, s.amazon_scraped_count;
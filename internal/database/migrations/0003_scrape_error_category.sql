@@ -0,0 +1,3 @@
+-- Typed scraper errors recorded on failed jobs
+
+ALTER TABLE scrape_jobs ADD COLUMN error_category TEXT;
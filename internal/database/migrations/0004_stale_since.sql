@@ -0,0 +1,4 @@
+-- When each provider's data last failed to refresh, NULL while it is fresh
+
+ALTER TABLE series ADD COLUMN audible_stale_since DATETIME;
+ALTER TABLE series ADD COLUMN amazon_stale_since DATETIME;
@@ -0,0 +1,4 @@
+-- Workers lease jobs from the database; expired leases are claimed again
+
+ALTER TABLE scrape_jobs ADD COLUMN lease_owner TEXT;
+ALTER TABLE scrape_jobs ADD COLUMN lease_expires_at DATETIME;
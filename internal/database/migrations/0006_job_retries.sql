@@ -0,0 +1,4 @@
+-- Transient failures are retried with backoff
+
+ALTER TABLE scrape_jobs ADD COLUMN attempts INTEGER DEFAULT 0;
+ALTER TABLE scrape_jobs ADD COLUMN next_attempt_at DATETIME;
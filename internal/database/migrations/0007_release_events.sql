@@ -0,0 +1,18 @@
+-- Append-only history of release changes observed while scraping: books
+-- appearing, release dates moving and preorders going live
+
+CREATE TABLE release_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    series_id INTEGER NOT NULL,
+    provider TEXT NOT NULL CHECK (provider IN ('audible', 'amazon')),
+    event_type TEXT NOT NULL CHECK (event_type IN ('announced', 'date_changed', 'released')),
+    book_title TEXT NOT NULL,
+    book_number INTEGER,
+    asin TEXT,
+    old_release_date DATE,
+    new_release_date DATE,
+    observed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (series_id) REFERENCES series(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_release_events_series ON release_events(series_id, observed_at);
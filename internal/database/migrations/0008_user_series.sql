@@ -0,0 +1,13 @@
+-- Per-user watchlists. Series and their scraped data stay shared; a row here
+-- puts a series on one user's list. Users lived outside the database when
+-- this was added, so user_id had no foreign key until 0017_user_foreign_keys.
+
+CREATE TABLE user_series (
+    user_id TEXT NOT NULL,
+    series_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, series_id),
+    FOREIGN KEY (series_id) REFERENCES series(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_series_series ON user_series(series_id);
@@ -0,0 +1,14 @@
+-- Per-user reading progress: how far through a series a user owns and has
+-- listened to (audio, tracked against Audible) or read (ebook, tracked
+-- against Amazon). Book numbers are series positions; 0 means none.
+
+CREATE TABLE reading_progress (
+    user_id TEXT NOT NULL,
+    series_id INTEGER NOT NULL,
+    format TEXT NOT NULL CHECK (format IN ('audio', 'ebook')),
+    owned_through INTEGER NOT NULL DEFAULT 0 CHECK (owned_through >= 0),
+    finished_through INTEGER NOT NULL DEFAULT 0 CHECK (finished_through >= 0),
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, series_id, format),
+    FOREIGN KEY (series_id) REFERENCES series(id) ON DELETE CASCADE
+);
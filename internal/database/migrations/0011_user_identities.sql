@@ -0,0 +1,14 @@
+-- Links users to accounts at an OpenID Connect provider. A user signs in
+-- through the issuer's stable subject identifier, so renaming the account at
+-- the provider doesn't create a second user.
+
+CREATE TABLE user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
@@ -0,0 +1,10 @@
+-- Forced password changes, and the device, address and last activity of
+-- each session so users can review and revoke their logins.
+
+ALTER TABLE users ADD COLUMN must_change_password INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN last_seen_at DATETIME;
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
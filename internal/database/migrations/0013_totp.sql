@@ -0,0 +1,16 @@
+-- Optional TOTP two-factor authentication. totp_secret holds the pending
+-- secret during enrollment and the active one once totp_enabled is set;
+-- totp_last_step stops a code being used twice. Recovery codes are stored
+-- as SHA-256 hashes and can each be used once.
+
+ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE recovery_codes (
+    user_id TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at DATETIME,
+    PRIMARY KEY (user_id, code_hash),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- Viewer, editor and admin roles replace user and admin. Existing users
+-- keep what they could do before, which is what editors can do now.
+
+UPDATE users SET role = 'editor' WHERE role = 'user';
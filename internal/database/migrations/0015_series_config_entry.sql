@@ -0,0 +1,5 @@
+-- The books.yaml entry a series was last loaded from. Reloading the file
+-- leaves a series alone while its entry is unchanged, so renames and URL
+-- fixes made in the UI aren't undone.
+
+ALTER TABLE series ADD COLUMN config_entry TEXT;
@@ -0,0 +1,43 @@
+-- Counts and release dates pinned by hand for series a storefront lists
+-- wrongly. Scrapes keep storing what the storefront says; series_stats
+-- reports the pinned value instead while one is set.
+
+ALTER TABLE series ADD COLUMN audible_count_override INTEGER;
+ALTER TABLE series ADD COLUMN audible_latest_override DATETIME;
+ALTER TABLE series ADD COLUMN audible_next_override DATETIME;
+ALTER TABLE series ADD COLUMN amazon_count_override INTEGER;
+ALTER TABLE series ADD COLUMN amazon_latest_override DATETIME;
+ALTER TABLE series ADD COLUMN amazon_next_override DATETIME;
+
+DROP VIEW IF EXISTS series_stats;
+CREATE VIEW series_stats AS
+SELECT 
+    s.id,
+    s.title,
+    s.audible_id,
+    s.amazon_asin,
+    s.updated_at,
+    
+    -- Audible stats (pinned values win over scraped ones)
+    COALESCE(s.audible_count_override, s.audible_scraped_count) as audible_count,
+    MAX(CASE WHEN b.provider = 'audible' AND b.is_latest = 1 THEN b.title END) as audible_latest_title,
+    COALESCE(s.audible_latest_override,
+        MAX(CASE WHEN b.provider = 'audible' AND b.is_latest = 1 THEN b.release_date END)) as audible_latest_date,
+    (SELECT n.title FROM books n WHERE n.series_id = s.id AND n.provider = 'audible' AND n.is_preorder = 1
+        ORDER BY n.release_date IS NULL, n.release_date LIMIT 1) as audible_next_title,
+    COALESCE(s.audible_next_override,
+        (SELECT MIN(n.release_date) FROM books n WHERE n.series_id = s.id AND n.provider = 'audible' AND n.is_preorder = 1)) as audible_next_date,
+    
+    -- Amazon stats (pinned values win over scraped ones)
+    COALESCE(s.amazon_count_override, s.amazon_scraped_count) as amazon_count,
+    MAX(CASE WHEN b.provider = 'amazon' AND b.is_latest = 1 THEN b.title END) as amazon_latest_title,
+    COALESCE(s.amazon_latest_override,
+        MAX(CASE WHEN b.provider = 'amazon' AND b.is_latest = 1 THEN b.release_date END)) as amazon_latest_date,
+    (SELECT n.title FROM books n WHERE n.series_id = s.id AND n.provider = 'amazon' AND n.is_preorder = 1
+        ORDER BY n.release_date IS NULL, n.release_date LIMIT 1) as amazon_next_title,
+    COALESCE(s.amazon_next_override,
+        (SELECT MIN(n.release_date) FROM books n WHERE n.series_id = s.id AND n.provider = 'amazon' AND n.is_preorder = 1)) as amazon_next_date
+    
+FROM series s
+LEFT JOIN books b ON s.id = b.series_id
+GROUP BY s.id, s.title, s.audible_id, s.amazon_asin, s.updated_at, s.audible_scraped_count, s.amazon_scraped_count;
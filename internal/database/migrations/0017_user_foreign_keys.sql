@@ -0,0 +1,40 @@
+-- Watchlists and reading progress belong to rows in users now that users are
+-- stored here, so deleting a user deletes them too. SQLite can't add a
+-- foreign key to an existing table, so both tables are rebuilt; rows left
+-- behind by users deleted before this migration are dropped on the way.
+
+CREATE TABLE user_series_new (
+    user_id TEXT NOT NULL,
+    series_id INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, series_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (series_id) REFERENCES series(id) ON DELETE CASCADE
+);
+
+INSERT INTO user_series_new (user_id, series_id, created_at)
+SELECT user_id, series_id, created_at FROM user_series
+WHERE user_id IN (SELECT id FROM users);
+
+DROP TABLE user_series;
+ALTER TABLE user_series_new RENAME TO user_series;
+CREATE INDEX idx_user_series_series ON user_series(series_id);
+
+CREATE TABLE reading_progress_new (
+    user_id TEXT NOT NULL,
+    series_id INTEGER NOT NULL,
+    format TEXT NOT NULL CHECK (format IN ('audio', 'ebook')),
+    owned_through INTEGER NOT NULL DEFAULT 0 CHECK (owned_through >= 0),
+    finished_through INTEGER NOT NULL DEFAULT 0 CHECK (finished_through >= 0),
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, series_id, format),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (series_id) REFERENCES series(id) ON DELETE CASCADE
+);
+
+INSERT INTO reading_progress_new (user_id, series_id, format, owned_through, finished_through, updated_at)
+SELECT user_id, series_id, format, owned_through, finished_through, updated_at FROM reading_progress
+WHERE user_id IN (SELECT id FROM users);
+
+DROP TABLE reading_progress;
+ALTER TABLE reading_progress_new RENAME TO reading_progress;
@@ -0,0 +1,43 @@
+-- Config entries were stored as title|audible|amazon, followed by
+-- |audible overrides|amazon overrides once either was pinned, and read back
+-- by splitting on "|". They're JSON now, so a title containing "|" can't be
+-- misread. IDs and overrides never contain "|" and overrides always contain
+-- commas, so old entries are split from the right.
+
+CREATE TEMP TABLE config_entry_parts AS
+WITH RECURSIVE split(id, rest, n, part) AS (
+    SELECT id, config_entry || '|', 0, NULL FROM series WHERE config_entry IS NOT NULL
+    UNION ALL
+    SELECT id, substr(rest, instr(rest, '|') + 1), n + 1, substr(rest, 1, instr(rest, '|') - 1)
+    FROM split WHERE rest <> ''
+)
+SELECT id, n, part FROM split WHERE n > 0;
+
+-- How many fields follow the title: the two IDs, plus the two overrides
+CREATE TEMP TABLE config_entry_shape AS
+SELECT p.id, p.n AS parts,
+    CASE WHEN p.n >= 5 AND instr(p.part, ',') > 0 THEN 4 ELSE 2 END AS fields
+FROM config_entry_parts p
+WHERE p.n = (SELECT MAX(q.n) FROM config_entry_parts q WHERE q.id = p.id);
+
+UPDATE series SET config_entry = (
+    SELECT json_object(
+        'title', CASE WHEN s.parts <= s.fields THEN series.config_entry
+            ELSE substr(series.config_entry, 1, length(series.config_entry) - s.fields -
+                (SELECT SUM(length(p.part)) FROM config_entry_parts p WHERE p.id = s.id AND p.n > s.parts - s.fields))
+            END,
+        'audible_id', CASE WHEN s.parts <= s.fields THEN ''
+            ELSE (SELECT p.part FROM config_entry_parts p WHERE p.id = s.id AND p.n = s.parts - s.fields + 1) END,
+        'amazon_asin', CASE WHEN s.parts <= s.fields THEN ''
+            ELSE (SELECT p.part FROM config_entry_parts p WHERE p.id = s.id AND p.n = s.parts - s.fields + 2) END,
+        'audible_override', CASE WHEN s.fields = 2 THEN ''
+            ELSE (SELECT COALESCE(NULLIF(p.part, ',,'), '') FROM config_entry_parts p WHERE p.id = s.id AND p.n = s.parts - 1) END,
+        'amazon_override', CASE WHEN s.fields = 2 THEN ''
+            ELSE (SELECT COALESCE(NULLIF(p.part, ',,'), '') FROM config_entry_parts p WHERE p.id = s.id AND p.n = s.parts) END
+    )
+    FROM config_entry_shape s WHERE s.id = series.id
+)
+WHERE config_entry IS NOT NULL;
+
+DROP TABLE config_entry_parts;
+DROP TABLE config_entry_shape;
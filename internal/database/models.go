@@ -1,7 +1,10 @@
 package database
 
 import (
+	"errors"
 	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/models"
 )
 
 // Series represents a book series in the database
@@ -22,6 +25,7 @@ type Book struct {
 	Provider    string     `db:"provider" json:"provider"`
 	Title       string     `db:"title" json:"title"`
 	BookNumber  *int       `db:"book_number" json:"book_number,omitempty"`
+	ASIN        *string    `db:"asin" json:"asin,omitempty"`
 	ReleaseDate *time.Time `db:"release_date" json:"release_date,omitempty"`
 	IsPreorder  bool       `db:"is_preorder" json:"is_preorder"`
 	IsLatest    bool       `db:"is_latest" json:"is_latest"`
@@ -31,15 +35,42 @@ type Book struct {
 
 // ScrapeJob represents a scraping operation
 type ScrapeJob struct {
-	ID           int        `db:"id" json:"id"`
-	SeriesID     int        `db:"series_id" json:"series_id"`
-	Provider     string     `db:"provider" json:"provider"`
-	Status       string     `db:"status" json:"status"`
-	StartedAt    *time.Time `db:"started_at" json:"started_at,omitempty"`
-	CompletedAt  *time.Time `db:"completed_at" json:"completed_at,omitempty"`
-	ErrorMessage *string    `db:"error_message" json:"error_message,omitempty"`
-	BookCount    int        `db:"book_count" json:"book_count"`
-	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	ID            int        `db:"id" json:"id"`
+	SeriesID      int        `db:"series_id" json:"series_id"`
+	Provider      string     `db:"provider" json:"provider"`
+	Status        string     `db:"status" json:"status"`
+	StartedAt     *time.Time `db:"started_at" json:"started_at,omitempty"`
+	CompletedAt   *time.Time `db:"completed_at" json:"completed_at,omitempty"`
+	ErrorMessage  *string    `db:"error_message" json:"error_message,omitempty"`
+	ErrorCategory *string    `db:"error_category" json:"error_category,omitempty"`
+	BookCount     int        `db:"book_count" json:"book_count"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	NextAttemptAt *time.Time `db:"next_attempt_at" json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ReleaseEvent records a change to a book's release observed by a scrape
+type ReleaseEvent struct {
+	ID             int        `db:"id" json:"id"`
+	SeriesID       int        `db:"series_id" json:"series_id"`
+	Provider       string     `db:"provider" json:"provider"`
+	EventType      string     `db:"event_type" json:"event_type"`
+	BookTitle      string     `db:"book_title" json:"book_title"`
+	BookNumber     *int       `db:"book_number" json:"book_number,omitempty"`
+	ASIN           *string    `db:"asin" json:"asin,omitempty"`
+	OldReleaseDate *time.Time `db:"old_release_date" json:"old_release_date,omitempty"`
+	NewReleaseDate *time.Time `db:"new_release_date" json:"new_release_date,omitempty"`
+	ObservedAt     time.Time  `db:"observed_at" json:"observed_at"`
+}
+
+// ReadingProgress is how far through one format of a series a user owns and
+// has finished (listened to for audio, read for ebooks), by series position
+type ReadingProgress struct {
+	SeriesID        int       `db:"series_id" json:"series_id"`
+	Format          string    `db:"format" json:"format"`
+	OwnedThrough    int       `db:"owned_through" json:"owned_through"`
+	FinishedThrough int       `db:"finished_through" json:"finished_through"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
 }
 
 // SeriesStats represents aggregated series data from the view
@@ -63,6 +94,18 @@ type SeriesStats struct {
 	AmazonLatestDate  *time.Time `db:"amazon_latest_date" json:"amazon_latest_date,omitempty"`
 	AmazonNextTitle   *string    `db:"amazon_next_title" json:"amazon_next_title,omitempty"`
 	AmazonNextDate    *time.Time `db:"amazon_next_date" json:"amazon_next_date,omitempty"`
+	
+	// When each provider's data last failed to refresh, nil while it is fresh
+	AudibleStaleSince *time.Time `db:"audible_stale_since" json:"audible_stale_since,omitempty"`
+	AmazonStaleSince  *time.Time `db:"amazon_stale_since" json:"amazon_stale_since,omitempty"`
+	
+	// Values pinned by hand, already applied to the counts and dates above
+	AudibleOverride   models.Override `json:"-"`
+	AmazonOverride    models.Override `json:"-"`
+	
+	// Most recent failed scrape per provider, nil when the last scrape succeeded
+	AudibleLastError  *ScrapeJob `json:"audible_last_error,omitempty"`
+	AmazonLastError   *ScrapeJob `json:"amazon_last_error,omitempty"`
 }
 
 // JobStatus constants
@@ -73,8 +116,26 @@ const (
 	JobStatusFailed    = "failed"
 )
 
+// ErrLeaseLost is returned when a worker reports the result of a job it no
+// longer holds, because the job was cancelled or its lease expired
+var ErrLeaseLost = errors.New("scrape job lease lost")
+
+// ReleaseEvent types
+const (
+	EventAnnounced   = "announced"    // A book appeared in the provider's listing
+	EventDateChanged = "date_changed" // A book's release date moved
+	EventReleased    = "released"     // A preorder went on sale
+)
+
 // Provider constants
 const (
 	ProviderAudible = "audible"
 	ProviderAmazon  = "amazon"
+)
+
+// ReadingProgress formats. Audio progress is measured against the Audible
+// listing and ebook progress against Amazon.
+const (
+	FormatAudio = "audio"
+	FormatEbook = "ebook"
 )
\ No newline at end of file
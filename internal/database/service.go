@@ -2,8 +2,9 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log"
+	"strings"
 	"time"
 
 	"github.com/michaeldvinci/syllabus/internal/models"
@@ -22,12 +23,15 @@ func NewService(db *DB) *Service {
 // GetAllSeriesStats returns all series with their aggregated stats
 func (s *Service) GetAllSeriesStats() ([]SeriesStats, error) {
 	query := `SELECT 
-	    id, title, audible_id, amazon_asin, updated_at,
-	    audible_count, audible_latest_title, audible_latest_date,
-	    audible_next_title, audible_next_date,
-	    amazon_count, amazon_latest_title, amazon_latest_date,
-	    amazon_next_title, amazon_next_date
-	    FROM series_stats ORDER BY title`
+	    ss.id, ss.title, ss.audible_id, ss.amazon_asin, ss.updated_at,
+	    ss.audible_count, ss.audible_latest_title, ss.audible_latest_date,
+	    ss.audible_next_title, ss.audible_next_date,
+	    ss.amazon_count, ss.amazon_latest_title, ss.amazon_latest_date,
+	    ss.amazon_next_title, ss.amazon_next_date,
+	    s.audible_stale_since, s.amazon_stale_since,
+	    s.audible_count_override, s.audible_latest_override, s.audible_next_override,
+	    s.amazon_count_override, s.amazon_latest_override, s.amazon_next_override
+	    FROM series_stats ss JOIN series s ON s.id = ss.id ORDER BY ss.title`
 
 	rows, err := s.db.Query(query)
 	if err != nil {
@@ -47,6 +51,9 @@ func (s *Service) GetAllSeriesStats() ([]SeriesStats, error) {
 			&stat.AudibleNextTitle, &audibleNextDate,
 			&stat.AmazonCount, &stat.AmazonLatestTitle, &amazonLatestDate,
 			&stat.AmazonNextTitle, &amazonNextDate,
+			&stat.AudibleStaleSince, &stat.AmazonStaleSince,
+			&stat.AudibleOverride.Count, &stat.AudibleOverride.LatestDate, &stat.AudibleOverride.NextDate,
+			&stat.AmazonOverride.Count, &stat.AmazonOverride.LatestDate, &stat.AmazonOverride.NextDate,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan series stats: %w", err)
@@ -88,8 +95,30 @@ func (s *Service) GetAllSeriesStats() ([]SeriesStats, error) {
 
 		stats = append(stats, stat)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return stats, rows.Err()
+	failures, err := s.GetLastScrapeFailures()
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		for j := range failures {
+			f := &failures[j]
+			if f.SeriesID != stats[i].ID {
+				continue
+			}
+			switch f.Provider {
+			case ProviderAudible:
+				stats[i].AudibleLastError = f
+			case ProviderAmazon:
+				stats[i].AmazonLastError = f
+			}
+		}
+	}
+
+	return stats, nil
 }
 
 // UpsertSeries inserts or updates a series
@@ -145,7 +174,9 @@ func (s *Service) UpsertSeries(title, audibleID, audibleURL, amazonASIN string)
 	return &series, nil
 }
 
-// UpdateSeriesBooks updates all books for a series from scraped data
+// UpdateSeriesBooks updates all books for a series from scraped data. Values
+// pinned with SetSeriesOverrides are stored apart and left as they are, so
+// they keep taking precedence in series_stats.
 func (s *Service) UpdateSeriesBooks(seriesID int, provider string, info models.SeriesInfo) error {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -153,109 +184,291 @@ func (s *Service) UpdateSeriesBooks(seriesID int, provider string, info models.S
 	}
 	defer tx.Rollback()
 
-	// Update scraped counts in series table
+	if err := writeSeriesBooks(tx, seriesID, provider, info); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// writeSeriesBooks replaces a series' books for one provider inside tx
+func writeSeriesBooks(tx *sql.Tx, seriesID int, provider string, info models.SeriesInfo) error {
+	var err error
+
+	// Pick the provider-specific half of the scraped data
+	var (
+		count       int
+		books       []models.Book
+		latestTitle string
+		latestDate  *time.Time
+		nextTitle   string
+		nextDate    *time.Time
+	)
+	if provider == ProviderAudible {
+		count, books = info.AudibleCount, info.AudibleBooks
+		latestTitle, latestDate = info.AudibleLatestTitle, info.AudibleLatestDate
+		nextTitle, nextDate = info.AudibleNextTitle, info.AudibleNextDate
+	} else if provider == ProviderAmazon {
+		count, books = info.AmazonCount, info.AmazonBooks
+		latestTitle, latestDate = info.AmazonLatestTitle, info.AmazonLatestDate
+		nextTitle, nextDate = info.AmazonNextTitle, info.AmazonNextDate
+	}
+
+	// Update scraped counts in series table; fresh data is no longer stale
 	if provider == ProviderAudible {
-		_, err = tx.Exec(`UPDATE series SET audible_scraped_count = ? WHERE id = ?`, info.AudibleCount, seriesID)
+		_, err = tx.Exec(`UPDATE series SET audible_scraped_count = ?, audible_stale_since = NULL WHERE id = ?`, count, seriesID)
 		if err != nil {
 			return fmt.Errorf("failed to update audible scraped count: %w", err)
 		}
 	} else if provider == ProviderAmazon {
-		_, err = tx.Exec(`UPDATE series SET amazon_scraped_count = ? WHERE id = ?`, info.AmazonCount, seriesID)
+		_, err = tx.Exec(`UPDATE series SET amazon_scraped_count = ?, amazon_stale_since = NULL WHERE id = ?`, count, seriesID)
 		if err != nil {
 			return fmt.Errorf("failed to update amazon scraped count: %w", err)
 		}
 	}
 
+	// Snapshot the stored books so changes can be recorded as release events
+	previous, err := loadProviderBooks(tx, seriesID, provider)
+	if err != nil {
+		return err
+	}
+
 	// Clear existing books for this series/provider
 	_, err = tx.Exec(`DELETE FROM books WHERE series_id = ? AND provider = ?`, seriesID, provider)
 	if err != nil {
 		return fmt.Errorf("failed to clear existing books: %w", err)
 	}
 
-	// Insert books based on provider
-	if provider == ProviderAudible && info.AudibleCount > 0 {
-		// Insert all books in the series (placeholder titles for book 1 to count-1)
-		for i := 1; i <= info.AudibleCount; i++ {
-			title := fmt.Sprintf("Book %d", i)
-			isLatest := (i == info.AudibleCount)
-			var releaseDate *time.Time
-
-			// Use actual title and date for the latest book if available
-			if isLatest && info.AudibleLatestTitle != "" {
-				title = info.AudibleLatestTitle
-			}
-			if isLatest {
-				releaseDate = info.AudibleLatestDate
+	// No catalog from the provider - store just the latest and next books so
+	// the series_stats view still has something to report
+	if len(books) == 0 && count > 0 {
+		if latestTitle == "" {
+			latestTitle = fmt.Sprintf("Book %d", count)
+		}
+		books = []models.Book{{Title: latestTitle, Position: count, ReleaseDate: latestDate}}
+		if nextDate != nil {
+			if nextTitle == "" {
+				nextTitle = fmt.Sprintf("Book %d", count+1)
 			}
+			books = append(books, models.Book{Title: nextTitle, Position: count + 1, ReleaseDate: nextDate, IsPreorder: true})
+		}
+	}
 
-			_, err = tx.Exec(`
-				INSERT INTO books (series_id, provider, title, book_number, release_date, is_latest) 
-				VALUES (?, ?, ?, ?, ?, ?)`,
-				seriesID, provider, title, i, releaseDate, isLatest)
-			if err != nil {
-				return fmt.Errorf("failed to insert audible book %d: %w", i, err)
-			}
+	if len(books) > 0 {
+		if err := insertBookCatalog(tx, seriesID, provider, books, latestTitle); err != nil {
+			return err
 		}
+	}
 
-		// Insert next book if it's a preorder
-		if info.AudibleNextDate != nil {
-			nextTitle := info.AudibleNextTitle
-			if nextTitle == "" {
-				nextTitle = fmt.Sprintf("Book %d", info.AudibleCount+1)
-			}
-			_, err = tx.Exec(`
-				INSERT INTO books (series_id, provider, title, book_number, release_date, is_preorder) 
-				VALUES (?, ?, ?, ?, ?, ?)`,
-				seriesID, provider, nextTitle, info.AudibleCount+1,
-				info.AudibleNextDate, true)
-			if err != nil {
-				return fmt.Errorf("failed to insert audible next book: %w", err)
-			}
+	return recordReleaseEvents(tx, seriesID, provider, previous, books)
+}
+
+// loadProviderBooks returns the books currently stored for a series/provider
+func loadProviderBooks(tx *sql.Tx, seriesID int, provider string) ([]Book, error) {
+	rows, err := tx.Query(`SELECT title, book_number, asin, release_date, is_preorder
+	                       FROM books WHERE series_id = ? AND provider = ?`, seriesID, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query existing books: %w", err)
+	}
+	defer rows.Close()
+
+	var books []Book
+	for rows.Next() {
+		var b Book
+		var releaseDate sql.NullTime
+		if err := rows.Scan(&b.Title, &b.BookNumber, &b.ASIN, &releaseDate, &b.IsPreorder); err != nil {
+			return nil, fmt.Errorf("failed to scan existing book: %w", err)
+		}
+		if releaseDate.Valid {
+			b.ReleaseDate = &releaseDate.Time
 		}
+		books = append(books, b)
 	}
+	return books, rows.Err()
+}
 
-	if provider == ProviderAmazon && info.AmazonCount > 0 {
-		// Insert all books in the series (placeholder titles for book 1 to count-1)
-		for i := 1; i <= info.AmazonCount; i++ {
-			title := fmt.Sprintf("Book %d", i)
-			isLatest := (i == info.AmazonCount)
-			var releaseDate *time.Time
+// recordReleaseEvents compares a fresh scrape with the previously stored books
+// and appends an event for every new book, moved release date and preorder
+// that went on sale. The first scrape of a series only sets the baseline.
+func recordReleaseEvents(tx *sql.Tx, seriesID int, provider string, previous []Book, current []models.Book) error {
+	if len(previous) == 0 {
+		return nil
+	}
 
-			// Use actual title and date for the latest book if available
-			if isLatest && info.AmazonLatestTitle != "" {
-				title = info.AmazonLatestTitle
-			}
-			if isLatest {
-				releaseDate = info.AmazonLatestDate
-			}
+	for _, b := range current {
+		var eventType string
+		var oldDate *time.Time
+		old := matchStoredBook(previous, b)
+		switch {
+		case old == nil:
+			eventType = EventAnnounced
+		case old.IsPreorder && !b.IsPreorder:
+			eventType, oldDate = EventReleased, old.ReleaseDate
+		case old.ReleaseDate != nil && b.ReleaseDate != nil && !sameDay(*old.ReleaseDate, *b.ReleaseDate):
+			eventType, oldDate = EventDateChanged, old.ReleaseDate
+		default:
+			continue
+		}
 
-			_, err = tx.Exec(`
-				INSERT INTO books (series_id, provider, title, book_number, release_date, is_latest) 
-				VALUES (?, ?, ?, ?, ?, ?)`,
-				seriesID, provider, title, i, releaseDate, isLatest)
-			if err != nil {
-				return fmt.Errorf("failed to insert amazon book %d: %w", i, err)
-			}
+		var bookNumber *int
+		if b.Position > 0 {
+			n := b.Position
+			bookNumber = &n
+		}
+		_, err := tx.Exec(`
+			INSERT INTO release_events (series_id, provider, event_type, book_title, book_number, asin, old_release_date, new_release_date)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			seriesID, provider, eventType, b.Title, bookNumber, nilIfEmpty(b.ASIN), oldDate, b.ReleaseDate)
+		if err != nil {
+			return fmt.Errorf("failed to record %s event for %q: %w", eventType, b.Title, err)
 		}
+	}
 
-		// Insert next book if it's a preorder
-		if info.AmazonNextDate != nil {
-			nextTitle := info.AmazonNextTitle
-			if nextTitle == "" {
-				nextTitle = fmt.Sprintf("Book %d", info.AmazonCount+1)
+	return nil
+}
+
+// matchStoredBook finds the stored version of a scraped book by ASIN, then
+// series position, then title
+func matchStoredBook(previous []Book, b models.Book) *Book {
+	if b.ASIN != "" {
+		for i := range previous {
+			if stringValue(previous[i].ASIN) == b.ASIN {
+				return &previous[i]
 			}
-			_, err = tx.Exec(`
-				INSERT INTO books (series_id, provider, title, book_number, release_date, is_preorder) 
-				VALUES (?, ?, ?, ?, ?, ?)`,
-				seriesID, provider, nextTitle, info.AmazonCount+1,
-				info.AmazonNextDate, true)
-			if err != nil {
-				return fmt.Errorf("failed to insert amazon next book: %w", err)
+		}
+	}
+	if b.Position > 0 {
+		for i := range previous {
+			if previous[i].BookNumber != nil && *previous[i].BookNumber == b.Position {
+				return &previous[i]
 			}
 		}
 	}
+	for i := range previous {
+		if strings.EqualFold(previous[i].Title, b.Title) {
+			return &previous[i]
+		}
+	}
+	return nil
+}
 
-	return tx.Commit()
+// sameDay reports whether two release dates fall on the same calendar day
+func sameDay(a, b time.Time) bool {
+	return a.UTC().Format("2006-01-02") == b.UTC().Format("2006-01-02")
+}
+
+// insertBookCatalog writes one row per scraped book. The book matching the
+// provider's latest title (or the last released book) is flagged as latest.
+func insertBookCatalog(tx *sql.Tx, seriesID int, provider string, books []models.Book, latestTitle string) error {
+	latestIdx := -1
+	for i, b := range books {
+		if b.IsPreorder {
+			continue
+		}
+		if latestTitle != "" && b.Title == latestTitle {
+			latestIdx = i
+			break
+		}
+		latestIdx = i
+	}
+
+	// book_number is unique per series/provider, so unnumbered or duplicate
+	// positions (omnibus editions, novellas) are stored without a number
+	seen := make(map[int]bool)
+	for i, b := range books {
+		var bookNumber *int
+		if b.Position > 0 && !seen[b.Position] {
+			seen[b.Position] = true
+			n := b.Position
+			bookNumber = &n
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO books (series_id, provider, title, book_number, asin, release_date, is_preorder, is_latest) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			seriesID, provider, b.Title, bookNumber, nilIfEmpty(b.ASIN), b.ReleaseDate,
+			b.IsPreorder, i == latestIdx)
+		if err != nil {
+			return fmt.Errorf("failed to insert %s book %q: %w", provider, b.Title, err)
+		}
+	}
+
+	return nil
+}
+
+// GetAllBooks returns every stored book ordered by series, provider and position
+func (s *Service) GetAllBooks() ([]Book, error) {
+	return s.queryBooks(`SELECT id, series_id, provider, title, book_number, asin, release_date,
+	                            is_preorder, is_latest, created_at, updated_at
+	                     FROM books
+	                     ORDER BY series_id, provider, book_number IS NULL, book_number, id`)
+}
+
+// GetSeriesBooks returns a series' stored books ordered by provider and position
+func (s *Service) GetSeriesBooks(seriesID int) ([]Book, error) {
+	return s.queryBooks(`SELECT id, series_id, provider, title, book_number, asin, release_date,
+	                            is_preorder, is_latest, created_at, updated_at
+	                     FROM books WHERE series_id = ?
+	                     ORDER BY provider, book_number IS NULL, book_number, id`, seriesID)
+}
+
+func (s *Service) queryBooks(query string, args ...interface{}) ([]Book, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query books: %w", err)
+	}
+	defer rows.Close()
+
+	var books []Book
+	for rows.Next() {
+		var b Book
+		var releaseDate sql.NullTime
+		err := rows.Scan(&b.ID, &b.SeriesID, &b.Provider, &b.Title, &b.BookNumber, &b.ASIN,
+			&releaseDate, &b.IsPreorder, &b.IsLatest, &b.CreatedAt, &b.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan book: %w", err)
+		}
+		if releaseDate.Valid {
+			b.ReleaseDate = &releaseDate.Time
+		}
+		books = append(books, b)
+	}
+
+	return books, rows.Err()
+}
+
+// GetReleaseEvents returns a series' release history, newest first
+func (s *Service) GetReleaseEvents(seriesID int) ([]ReleaseEvent, error) {
+	query := `SELECT id, series_id, provider, event_type, book_title, book_number, asin,
+	                 old_release_date, new_release_date, observed_at
+	          FROM release_events
+	          WHERE series_id = ?
+	          ORDER BY observed_at DESC, id DESC`
+
+	rows, err := s.db.Query(query, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query release events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []ReleaseEvent
+	for rows.Next() {
+		var e ReleaseEvent
+		var oldDate, newDate sql.NullTime
+		err := rows.Scan(&e.ID, &e.SeriesID, &e.Provider, &e.EventType, &e.BookTitle, &e.BookNumber,
+			&e.ASIN, &oldDate, &newDate, &e.ObservedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan release event: %w", err)
+		}
+		if oldDate.Valid {
+			e.OldReleaseDate = &oldDate.Time
+		}
+		if newDate.Valid {
+			e.NewReleaseDate = &newDate.Time
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
 }
 
 // GetRuntimeSetting gets a runtime setting value from the database
@@ -328,10 +541,63 @@ func (s *Service) UpdateScrapeJob(jobID int, status string, errorMsg *string, bo
 	return nil
 }
 
+// FailScrapeJob marks a running job owner holds as failed, recording the error
+// and its category. A job cancelled or reclaimed in the meantime returns
+// ErrLeaseLost and is left as it is.
+func (s *Service) FailScrapeJob(jobID int, owner string, jobErr error) error {
+	query := `UPDATE scrape_jobs
+	          SET status = ?, completed_at = ?, error_message = ?, error_category = ?,
+	              lease_owner = NULL, lease_expires_at = NULL
+	          WHERE id = ? AND status = ? AND lease_owner = ?`
+	category := string(models.CategoryOf(jobErr))
+
+	result, err := s.db.Exec(query, JobStatusFailed, time.Now(), jobErr.Error(), nilIfEmpty(category), jobID, JobStatusRunning, owner)
+	if err != nil {
+		return fmt.Errorf("failed to update scrape job: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// GetLastScrapeFailures returns, for each series and provider whose most recent
+// finished scrape failed with a scraper error, that failed job
+func (s *Service) GetLastScrapeFailures() ([]ScrapeJob, error) {
+	query := `SELECT j.id, j.series_id, j.provider, j.status, j.started_at, j.completed_at,
+	                 j.error_message, j.error_category, j.book_count, j.attempts, j.next_attempt_at, j.created_at
+	          FROM scrape_jobs j
+	          WHERE j.status = ? AND j.error_category IS NOT NULL
+	            AND j.id = (SELECT MAX(k.id) FROM scrape_jobs k
+	                        WHERE k.series_id = j.series_id AND k.provider = j.provider
+	                          AND k.status IN (?, ?))`
+
+	rows, err := s.db.Query(query, JobStatusFailed, JobStatusCompleted, JobStatusFailed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scrape failures: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []ScrapeJob
+	for rows.Next() {
+		var job ScrapeJob
+		err := rows.Scan(&job.ID, &job.SeriesID, &job.Provider, &job.Status,
+			&job.StartedAt, &job.CompletedAt, &job.ErrorMessage, &job.ErrorCategory,
+			&job.BookCount, &job.Attempts, &job.NextAttemptAt, &job.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scrape job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
 // GetPendingScrapeJobs returns all pending scrape jobs
 func (s *Service) GetPendingScrapeJobs() ([]ScrapeJob, error) {
 	query := `SELECT id, series_id, provider, status, started_at, completed_at, 
-	                 error_message, book_count, created_at 
+	                 error_message, error_category, book_count, attempts, next_attempt_at, created_at 
 	          FROM scrape_jobs WHERE status = ? ORDER BY created_at`
 
 	rows, err := s.db.Query(query, JobStatusPending)
@@ -344,8 +610,35 @@ func (s *Service) GetPendingScrapeJobs() ([]ScrapeJob, error) {
 	for rows.Next() {
 		var job ScrapeJob
 		err := rows.Scan(&job.ID, &job.SeriesID, &job.Provider, &job.Status,
-			&job.StartedAt, &job.CompletedAt, &job.ErrorMessage,
-			&job.BookCount, &job.CreatedAt)
+			&job.StartedAt, &job.CompletedAt, &job.ErrorMessage, &job.ErrorCategory,
+			&job.BookCount, &job.Attempts, &job.NextAttemptAt, &job.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scrape job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// GetSeriesScrapeJobs returns a series' most recent scrape jobs, newest first
+func (s *Service) GetSeriesScrapeJobs(seriesID, limit int) ([]ScrapeJob, error) {
+	query := `SELECT id, series_id, provider, status, started_at, completed_at,
+	                 error_message, error_category, book_count, attempts, next_attempt_at, created_at
+	          FROM scrape_jobs WHERE series_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`
+
+	rows, err := s.db.Query(query, seriesID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scrape jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []ScrapeJob
+	for rows.Next() {
+		var job ScrapeJob
+		err := rows.Scan(&job.ID, &job.SeriesID, &job.Provider, &job.Status,
+			&job.StartedAt, &job.CompletedAt, &job.ErrorMessage, &job.ErrorCategory,
+			&job.BookCount, &job.Attempts, &job.NextAttemptAt, &job.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan scrape job: %w", err)
 		}
@@ -409,46 +702,183 @@ func (s *Service) GetSeriesByID(id int) (*Series, error) {
 	return &series, nil
 }
 
-// ClearAllBookData removes all book data to prevent cascading corruption
-func (s *Service) ClearAllBookData() error {
-	_, err := s.db.Exec(`DELETE FROM books`)
+// MarkSeriesStale records that a provider's stored data for a series could not
+// be refreshed. The timestamp of the first failed refresh is kept.
+func (s *Service) MarkSeriesStale(seriesID int, provider string) error {
+	var query string
+	switch provider {
+	case ProviderAudible:
+		query = `UPDATE series SET audible_stale_since = COALESCE(audible_stale_since, ?) WHERE id = ?`
+	case ProviderAmazon:
+		query = `UPDATE series SET amazon_stale_since = COALESCE(amazon_stale_since, ?) WHERE id = ?`
+	default:
+		return fmt.Errorf("unknown provider: %s", provider)
+	}
+
+	if _, err := s.db.Exec(query, time.Now().UTC(), seriesID); err != nil {
+		return fmt.Errorf("failed to mark %s data stale: %w", provider, err)
+	}
+	return nil
+}
+
+// ClaimScrapeJob atomically leases the oldest runnable job to owner, marking it
+// running and counting the attempt. Pending jobs that are due and running jobs
+// whose lease has expired are runnable; jobs for skipProviders (e.g. providers
+// paused after a CAPTCHA) are left alone. Returns nil when there is nothing to do.
+func (s *Service) ClaimScrapeJob(owner string, lease time.Duration, skipProviders ...string) (*ScrapeJob, error) {
+	skip := ""
+	if len(skipProviders) > 0 {
+		skip = "AND provider NOT IN (?" + strings.Repeat(", ?", len(skipProviders)-1) + ")"
+	}
+	query := `UPDATE scrape_jobs
+	          SET status = ?, started_at = ?, lease_owner = ?, lease_expires_at = ?,
+	              attempts = attempts + 1
+	          WHERE id = (
+	              SELECT id FROM scrape_jobs
+	              WHERE ((status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?))
+	                 OR (status = ? AND lease_expires_at < ?)) ` + skip + `
+	              ORDER BY created_at, id LIMIT 1)
+	          RETURNING id, series_id, provider, status, started_at, completed_at,
+	                    error_message, error_category, book_count, attempts, next_attempt_at, created_at`
+
+	now := time.Now().UTC()
+	args := []interface{}{JobStatusRunning, now, owner, now.Add(lease), JobStatusPending, now, JobStatusRunning, now}
+	for _, p := range skipProviders {
+		args = append(args, p)
+	}
+
+	var job ScrapeJob
+	err := s.db.QueryRow(query, args...).Scan(
+		&job.ID, &job.SeriesID, &job.Provider, &job.Status,
+		&job.StartedAt, &job.CompletedAt, &job.ErrorMessage, &job.ErrorCategory,
+		&job.BookCount, &job.Attempts, &job.NextAttemptAt, &job.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to claim scrape job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// CompleteScrapeJob stores a job's scraped data and marks the job completed in
+// one transaction, as long as owner still holds the job's lease. A job that was
+// cancelled or reclaimed in the meantime returns ErrLeaseLost and its result is
+// dropped, so a stale scrape can't overwrite books cleared after it started.
+func (s *Service) CompleteScrapeJob(job ScrapeJob, owner string, info models.SeriesInfo) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to clear all book data: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	bookCount := info.AudibleCount
+	if job.Provider == ProviderAmazon {
+		bookCount = info.AmazonCount
 	}
-	log.Printf("cleared all book data from database")
+
+	result, err := tx.Exec(`UPDATE scrape_jobs SET status = ?, completed_at = ?, book_count = ?,
+	                            lease_owner = NULL, lease_expires_at = NULL
+	                        WHERE id = ? AND status = ? AND lease_owner = ?`,
+		JobStatusCompleted, time.Now(), bookCount, job.ID, JobStatusRunning, owner)
+	if err != nil {
+		return fmt.Errorf("failed to complete scrape job: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrLeaseLost
+	}
+
+	if err := writeSeriesBooks(tx, job.SeriesID, job.Provider, info); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RescheduleScrapeJob returns a failed job owner holds to the queue to be
+// retried at the given time, recording the error that caused the retry. Like
+// FailScrapeJob, it returns ErrLeaseLost for a job owner no longer holds.
+func (s *Service) RescheduleScrapeJob(jobID int, owner string, jobErr error, at time.Time) error {
+	query := `UPDATE scrape_jobs 
+	          SET status = ?, next_attempt_at = ?, error_message = ?, error_category = ?,
+	              lease_owner = NULL, lease_expires_at = NULL
+	          WHERE id = ? AND status = ? AND lease_owner = ?`
+	category := string(models.CategoryOf(jobErr))
+
+	result, err := s.db.Exec(query, JobStatusPending, at.UTC(), jobErr.Error(), nilIfEmpty(category), jobID, JobStatusRunning, owner)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule scrape job: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrLeaseLost
+	}
+
 	return nil
 }
 
-// CleanupStaleRunningJobs marks all running and old pending jobs as failed (for startup cleanup)
-func (s *Service) CleanupStaleRunningJobs() error {
-	errorMsg := "job interrupted by application restart"
+// RenewScrapeJobLease extends the lease on a running job held by owner
+func (s *Service) RenewScrapeJobLease(jobID int, owner string, lease time.Duration) error {
+	query := `UPDATE scrape_jobs SET lease_expires_at = ? 
+	          WHERE id = ? AND lease_owner = ? AND status = ?`
 
-	// Clean up running jobs
-	query1 := `UPDATE scrape_jobs SET status = ?, completed_at = CURRENT_TIMESTAMP, error_message = ? WHERE status = ?`
-	result1, err := s.db.Exec(query1, JobStatusFailed, errorMsg, JobStatusRunning)
+	result, err := s.db.Exec(query, time.Now().UTC().Add(lease), jobID, owner, JobStatusRunning)
 	if err != nil {
-		return fmt.Errorf("failed to cleanup stale running jobs: %w", err)
+		return fmt.Errorf("failed to renew lease on job %d: %w", jobID, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("lease on job %d is no longer held by %s", jobID, owner)
 	}
 
-	runningCleaned, _ := result1.RowsAffected()
+	return nil
+}
 
-	// Clean up ALL pending jobs on startup (they were never processed)
-	query2 := `UPDATE scrape_jobs SET status = ?, completed_at = CURRENT_TIMESTAMP, error_message = ? 
+// RequeueInterruptedJobs returns jobs left running by a previous process to
+// the pending state so they are picked up again (for startup recovery)
+func (s *Service) RequeueInterruptedJobs() (int, error) {
+	query := `UPDATE scrape_jobs SET status = ?, lease_owner = NULL, lease_expires_at = NULL 
 	          WHERE status = ?`
-	result2, err := s.db.Exec(query2, JobStatusFailed, "job cleared on application restart", JobStatusPending)
+
+	result, err := s.db.Exec(query, JobStatusPending, JobStatusRunning)
 	if err != nil {
-		return fmt.Errorf("failed to cleanup stale pending jobs: %w", err)
+		return 0, fmt.Errorf("failed to requeue interrupted jobs: %w", err)
 	}
 
-	pendingCleaned, _ := result2.RowsAffected()
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// ReleaseScrapeJob returns a running job to the queue without counting the
+// attempt, for scrapes interrupted by shutdown
+func (s *Service) ReleaseScrapeJob(jobID int) error {
+	query := `UPDATE scrape_jobs
+	          SET status = ?, attempts = MAX(attempts - 1, 0), lease_owner = NULL, lease_expires_at = NULL
+	          WHERE id = ? AND status = ?`
 
-	if runningCleaned > 0 || pendingCleaned > 0 {
-		fmt.Printf("cleaned up %d stale running jobs and %d stale pending jobs\n", runningCleaned, pendingCleaned)
+	_, err := s.db.Exec(query, JobStatusPending, jobID, JobStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to release scrape job: %w", err)
 	}
 
 	return nil
 }
 
+// CancelScrapeJob marks a pending or running job as failed with a "cancelled"
+// message. It has no error category, so the series isn't shown as failing.
+// Returns false if the job doesn't exist or has already finished.
+func (s *Service) CancelScrapeJob(jobID int) (bool, error) {
+	query := `UPDATE scrape_jobs
+	          SET status = ?, completed_at = ?, error_message = 'cancelled', error_category = NULL,
+	              lease_owner = NULL, lease_expires_at = NULL
+	          WHERE id = ? AND status IN (?, ?)`
+
+	result, err := s.db.Exec(query, JobStatusFailed, time.Now(), jobID, JobStatusPending, JobStatusRunning)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel scrape job: %w", err)
+	}
+
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
 // GetLastScrapeTime returns the most recent scrape start time (when any scrape was initiated)
 func (s *Service) GetLastScrapeTime() (*time.Time, error) {
 	query := `SELECT MAX(started_at) FROM scrape_jobs WHERE started_at IS NOT NULL`
@@ -481,6 +911,330 @@ func (s *Service) GetAllSeries() ([]Series, error) {
 	return series, rows.Err()
 }
 
+// UpdateSeries changes a series' title and provider IDs, keeping its ID and
+// with it the release history, watchlists and reading progress. Books from a
+// provider whose ID changed came from another listing, so they're cleared and
+// the next scrape sets a new baseline; their queued and running scrapes are
+// cancelled in the same transaction. It returns those providers.
+func (s *Service) UpdateSeries(id int, title, audibleID, audibleURL, amazonASIN string) (*Series, []string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	changed, err := updateSeries(tx, id, title, audibleID, audibleURL, amazonASIN)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	series, err := s.GetSeriesByID(id)
+	return series, changed, err
+}
+
+// updateSeries applies UpdateSeries within a transaction
+func updateSeries(tx *sql.Tx, id int, title, audibleID, audibleURL, amazonASIN string) ([]string, error) {
+	var oldAudibleID, oldAmazonASIN sql.NullString
+	err := tx.QueryRow(`SELECT audible_id, amazon_asin FROM series WHERE id = ?`, id).Scan(&oldAudibleID, &oldAmazonASIN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query series: %w", err)
+	}
+
+	query := `UPDATE series SET title = ?, audible_id = ?, audible_url = ?, amazon_asin = ?, updated_at = CURRENT_TIMESTAMP
+	          WHERE id = ?`
+	_, err = tx.Exec(query, title, nilIfEmpty(audibleID), nilIfEmpty(audibleURL), nilIfEmpty(amazonASIN), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update series: %w", err)
+	}
+
+	var changed []string
+	if oldAudibleID.String != audibleID {
+		changed = append(changed, ProviderAudible)
+	}
+	if oldAmazonASIN.String != amazonASIN {
+		changed = append(changed, ProviderAmazon)
+	}
+	for _, provider := range changed {
+		if _, err := tx.Exec(`DELETE FROM books WHERE series_id = ? AND provider = ?`, id, provider); err != nil {
+			return nil, fmt.Errorf("failed to clear %s books: %w", provider, err)
+		}
+		query := `UPDATE series SET audible_scraped_count = 0, audible_stale_since = NULL WHERE id = ?`
+		if provider == ProviderAmazon {
+			query = `UPDATE series SET amazon_scraped_count = 0, amazon_stale_since = NULL WHERE id = ?`
+		}
+		if _, err := tx.Exec(query, id); err != nil {
+			return nil, fmt.Errorf("failed to reset %s count: %w", provider, err)
+		}
+		// Scrapes of the old listing lose their lease here, so a result that
+		// arrives later is dropped instead of refilling the cleared books
+		query = `UPDATE scrape_jobs
+		         SET status = ?, completed_at = ?, error_message = 'cancelled', error_category = NULL,
+		             lease_owner = NULL, lease_expires_at = NULL
+		         WHERE series_id = ? AND provider = ? AND status IN (?, ?)`
+		if _, err := tx.Exec(query, JobStatusFailed, time.Now(), id, provider, JobStatusPending, JobStatusRunning); err != nil {
+			return nil, fmt.Errorf("failed to cancel %s scrape jobs: %w", provider, err)
+		}
+	}
+	return changed, nil
+}
+
+// UpsertConfigSeries loads a series from a books.yaml entry. An entry that's
+// unchanged since it was last loaded leaves its series as it is, even if the
+// series has since been renamed, given other URLs or had its overrides
+// edited. Otherwise the series with the entry's title is created or updated,
+// taking the entry's overrides. created is true for a new series.
+func (s *Service) UpsertConfigSeries(e models.SeriesIDs) (series *Series, created bool, err error) {
+	entry := NewConfigEntry(e)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`SELECT id FROM series WHERE `+configEntryIs, configEntryArgs(entry)...).Scan(&id)
+	if err == sql.ErrNoRows {
+		err = tx.QueryRow(`SELECT id FROM series WHERE title = ?`, e.Title).Scan(&id)
+		switch {
+		case err == sql.ErrNoRows:
+			err = tx.QueryRow(`INSERT INTO series (title, audible_id, audible_url, amazon_asin) VALUES (?, ?, ?, ?) RETURNING id`,
+				e.Title, nilIfEmpty(e.AudibleID), nilIfEmpty(e.AudibleURL), nilIfEmpty(e.AmazonASIN)).Scan(&id)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to insert series: %w", err)
+			}
+			created = true
+		case err != nil:
+			return nil, false, fmt.Errorf("failed to query series: %w", err)
+		default:
+			if _, err := updateSeries(tx, id, e.Title, e.AudibleID, e.AudibleURL, e.AmazonASIN); err != nil {
+				return nil, false, err
+			}
+		}
+		if _, err := tx.Exec(setOverridesQuery, overrideArgs(id, e.AudibleOverride, e.AmazonOverride)...); err != nil {
+			return nil, false, fmt.Errorf("failed to set overrides: %w", err)
+		}
+		if _, err := tx.Exec(`UPDATE series SET config_entry = ? WHERE id = ?`, entry.encode(), id); err != nil {
+			return nil, false, fmt.Errorf("failed to record config entry: %w", err)
+		}
+	} else if err != nil {
+		return nil, false, fmt.Errorf("failed to query series: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	series, err = s.GetSeriesByID(id)
+	return series, created, err
+}
+
+// ConfigEntry is what a series was last loaded with from its books.yaml
+// entry, stored as JSON in series.config_entry. Overrides are formatted by
+// Override.String, or empty when the entry pinned none.
+type ConfigEntry struct {
+	Title           string `json:"title"`
+	AudibleID       string `json:"audible_id"`
+	AmazonASIN      string `json:"amazon_asin"`
+	AudibleOverride string `json:"audible_override"`
+	AmazonOverride  string `json:"amazon_override"`
+}
+
+// NewConfigEntry returns the config entry a books.yaml entry loads as
+func NewConfigEntry(e models.SeriesIDs) ConfigEntry {
+	return ConfigEntry{
+		Title:           e.Title,
+		AudibleID:       e.AudibleID,
+		AmazonASIN:      e.AmazonASIN,
+		AudibleOverride: overrideKey(e.AudibleOverride),
+		AmazonOverride:  overrideKey(e.AmazonOverride),
+	}
+}
+
+// overrideKey formats an override as a ConfigEntry records it
+func overrideKey(o models.Override) string {
+	if o.IsZero() {
+		return ""
+	}
+	return o.String()
+}
+
+// encode returns the entry as stored in series.config_entry
+func (e ConfigEntry) encode() string {
+	b, _ := json.Marshal(e) // Can't fail for a struct of strings
+	return string(b)
+}
+
+// decodeConfigEntry reads a config entry stored by encode
+func decodeConfigEntry(s string) (ConfigEntry, error) {
+	var e ConfigEntry
+	if err := json.Unmarshal([]byte(s), &e); err != nil {
+		return e, fmt.Errorf("failed to decode config entry: %w", err)
+	}
+	return e, nil
+}
+
+// configEntryIs matches the series loaded as a ConfigEntry, given
+// configEntryArgs. Fields are compared rather than the JSON, since entries
+// converted from before it was stored as JSON weren't encoded by encode.
+const configEntryIs = `json_extract(config_entry, '$.title') = ? AND json_extract(config_entry, '$.audible_id') = ?
+    AND json_extract(config_entry, '$.amazon_asin') = ? AND json_extract(config_entry, '$.audible_override') = ?
+    AND json_extract(config_entry, '$.amazon_override') = ?`
+
+func configEntryArgs(e ConfigEntry) []interface{} {
+	return []interface{}{e.Title, e.AudibleID, e.AmazonASIN, e.AudibleOverride, e.AmazonOverride}
+}
+
+// ApplyConfigEntry loads a changed books.yaml entry into the series with ID
+// id. Only what the file changed since the series was last loaded from it is
+// applied, so a rename or other listing picked in the UI survives an edit to
+// the rest of the entry; the same goes for overrides set in the UI. A series
+// never loaded from the file takes the whole entry.
+func (s *Service) ApplyConfigEntry(id int, e models.SeriesIDs) (*Series, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var title string
+	var audibleID, audibleURL, amazonASIN, entry sql.NullString
+	var audible, amazon models.Override
+	query := `SELECT title, audible_id, audible_url, amazon_asin, config_entry,
+	          audible_count_override, audible_latest_override, audible_next_override,
+	          amazon_count_override, amazon_latest_override, amazon_next_override
+	          FROM series WHERE id = ?`
+	err = tx.QueryRow(query, id).Scan(&title, &audibleID, &audibleURL, &amazonASIN, &entry,
+		&audible.Count, &audible.LatestDate, &audible.NextDate,
+		&amazon.Count, &amazon.LatestDate, &amazon.NextDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query series: %w", err)
+	}
+
+	if !entry.Valid {
+		title, audibleID.String, audibleURL.String, amazonASIN.String = e.Title, e.AudibleID, e.AudibleURL, e.AmazonASIN
+		audible, amazon = e.AudibleOverride, e.AmazonOverride
+	} else {
+		loaded, err := decodeConfigEntry(entry.String)
+		if err != nil {
+			return nil, err
+		}
+		// The URL goes with the audible ID, since the entry doesn't record it
+		if e.Title != loaded.Title {
+			title = e.Title
+		}
+		if e.AudibleID != loaded.AudibleID {
+			audibleID.String, audibleURL.String = e.AudibleID, e.AudibleURL
+		}
+		if e.AmazonASIN != loaded.AmazonASIN {
+			amazonASIN.String = e.AmazonASIN
+		}
+		if overrideKey(e.AudibleOverride) != loaded.AudibleOverride {
+			audible = e.AudibleOverride
+		}
+		if overrideKey(e.AmazonOverride) != loaded.AmazonOverride {
+			amazon = e.AmazonOverride
+		}
+	}
+
+	if _, err := updateSeries(tx, id, title, audibleID.String, audibleURL.String, amazonASIN.String); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(setOverridesQuery, overrideArgs(id, audible, amazon)...); err != nil {
+		return nil, fmt.Errorf("failed to set overrides: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE series SET config_entry = ? WHERE id = ?`, NewConfigEntry(e).encode(), id); err != nil {
+		return nil, fmt.Errorf("failed to record config entry: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetSeriesByID(id)
+}
+
+const setOverridesQuery = `UPDATE series SET
+    audible_count_override = ?, audible_latest_override = ?, audible_next_override = ?,
+    amazon_count_override = ?, amazon_latest_override = ?, amazon_next_override = ?
+    WHERE id = ?`
+
+// overrideArgs are setOverridesQuery's arguments, dates stored as UTC midnight
+func overrideArgs(id int, audible, amazon models.Override) []interface{} {
+	date := func(t *time.Time) interface{} {
+		if t == nil {
+			return nil
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	count := func(n *int) interface{} {
+		if n == nil {
+			return nil
+		}
+		return *n
+	}
+	return []interface{}{
+		count(audible.Count), date(audible.LatestDate), date(audible.NextDate),
+		count(amazon.Count), date(amazon.LatestDate), date(amazon.NextDate),
+		id,
+	}
+}
+
+// SetSeriesOverrides replaces the values pinned for a series on each
+// provider. Scraped values are kept, so clearing an override brings them back.
+func (s *Service) SetSeriesOverrides(seriesID int, audible, amazon models.Override) error {
+	if _, err := s.db.Exec(setOverridesQuery, overrideArgs(seriesID, audible, amazon)...); err != nil {
+		return fmt.Errorf("failed to set overrides: %w", err)
+	}
+	return nil
+}
+
+// GetSeriesOverrides returns the values pinned for a series on each provider
+func (s *Service) GetSeriesOverrides(seriesID int) (audible, amazon models.Override, err error) {
+	query := `SELECT audible_count_override, audible_latest_override, audible_next_override,
+	          amazon_count_override, amazon_latest_override, amazon_next_override
+	          FROM series WHERE id = ?`
+	err = s.db.QueryRow(query, seriesID).Scan(
+		&audible.Count, &audible.LatestDate, &audible.NextDate,
+		&amazon.Count, &amazon.LatestDate, &amazon.NextDate,
+	)
+	if err != nil {
+		return audible, amazon, fmt.Errorf("failed to query overrides: %w", err)
+	}
+	return audible, amazon, nil
+}
+
+// GetConfigEntries returns the books.yaml entry each series was last loaded
+// from, by series ID. Series only ever added in the UI aren't included.
+func (s *Service) GetConfigEntries() (map[int]ConfigEntry, error) {
+	rows, err := s.db.Query(`SELECT id, config_entry FROM series WHERE config_entry IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query config entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make(map[int]ConfigEntry)
+	for rows.Next() {
+		var id int
+		var entry string
+		if err := rows.Scan(&id, &entry); err != nil {
+			return nil, err
+		}
+		if entries[id], err = decodeConfigEntry(entry); err != nil {
+			return nil, err
+		}
+	}
+	return entries, rows.Err()
+}
+
+// SetConfigEntry records the books.yaml entry a series was written to
+func (s *Service) SetConfigEntry(seriesID int, entry ConfigEntry) error {
+	if _, err := s.db.Exec(`UPDATE series SET config_entry = ? WHERE id = ?`, entry.encode(), seriesID); err != nil {
+		return fmt.Errorf("failed to record config entry: %w", err)
+	}
+	return nil
+}
+
 // DeleteSeries deletes a series and all its associated data
 func (s *Service) DeleteSeries(seriesID int) error {
 	tx, err := s.db.Begin()
@@ -524,6 +1278,169 @@ func (s *Service) DeleteSeriesByTitle(title string) error {
 	return s.DeleteSeries(series.ID)
 }
 
+// WatchSeries adds a series to a user's watchlist. Watching a series twice is a no-op.
+func (s *Service) WatchSeries(userID string, seriesID int) error {
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO user_series (user_id, series_id) VALUES (?, ?)`, userID, seriesID)
+	if err != nil {
+		return fmt.Errorf("failed to watch series: %w", err)
+	}
+	return nil
+}
+
+// UnwatchSeries removes a series from a user's watchlist. The series and its
+// scraped data are kept for anyone else watching it.
+func (s *Service) UnwatchSeries(userID string, seriesID int) error {
+	_, err := s.db.Exec(`DELETE FROM user_series WHERE user_id = ? AND series_id = ?`, userID, seriesID)
+	if err != nil {
+		return fmt.Errorf("failed to unwatch series: %w", err)
+	}
+	return nil
+}
+
+// IsWatching reports whether a series is on a user's watchlist
+func (s *Service) IsWatching(userID string, seriesID int) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM user_series WHERE user_id = ? AND series_id = ?`, userID, seriesID).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to check watchlist: %w", err)
+	}
+	return n > 0, nil
+}
+
+// GetWatchedSeriesIDs returns the IDs of the series on a user's watchlist
+func (s *Service) GetWatchedSeriesIDs(userID string) (map[int]bool, error) {
+	return s.querySeriesIDs(`SELECT series_id FROM user_series WHERE user_id = ?`, userID)
+}
+
+// GetAllWatchedSeriesIDs returns the IDs of the series on at least one
+// watchlist, i.e. the series worth scraping
+func (s *Service) GetAllWatchedSeriesIDs() (map[int]bool, error) {
+	return s.querySeriesIDs(`SELECT DISTINCT series_id FROM user_series`)
+}
+
+// GetWatchedSeriesStats returns the stats of every series on at least one watchlist
+func (s *Service) GetWatchedSeriesStats() ([]SeriesStats, error) {
+	stats, err := s.GetAllSeriesStats()
+	if err != nil {
+		return nil, err
+	}
+	watched, err := s.GetAllWatchedSeriesIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []SeriesStats
+	for _, stat := range stats {
+		if watched[stat.ID] {
+			filtered = append(filtered, stat)
+		}
+	}
+	return filtered, nil
+}
+
+// CountSeriesWatchers returns how many users have a series on their watchlist
+func (s *Service) CountSeriesWatchers(seriesID int) (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM user_series WHERE series_id = ?`, seriesID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count series watchers: %w", err)
+	}
+	return count, nil
+}
+
+func (s *Service) querySeriesIDs(query string, args ...interface{}) (map[int]bool, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watchlist: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// GetReadingProgress returns a user's progress for every series they have
+// recorded any, keyed by series ID and then format
+func (s *Service) GetReadingProgress(userID string) (map[int]map[string]ReadingProgress, error) {
+	rows, err := s.db.Query(`SELECT series_id, format, owned_through, finished_through, updated_at
+	          FROM reading_progress WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reading progress: %w", err)
+	}
+	defer rows.Close()
+
+	progress := make(map[int]map[string]ReadingProgress)
+	for rows.Next() {
+		var p ReadingProgress
+		if err := rows.Scan(&p.SeriesID, &p.Format, &p.OwnedThrough, &p.FinishedThrough, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reading progress: %w", err)
+		}
+		if progress[p.SeriesID] == nil {
+			progress[p.SeriesID] = make(map[string]ReadingProgress)
+		}
+		progress[p.SeriesID][p.Format] = p
+	}
+	return progress, rows.Err()
+}
+
+// SetReadingProgress records how far through a series a user owns and has
+// finished one format, replacing any earlier progress for that format
+func (s *Service) SetReadingProgress(userID string, p ReadingProgress) error {
+	if p.Format != FormatAudio && p.Format != FormatEbook {
+		return fmt.Errorf("unknown format: %s", p.Format)
+	}
+	if p.OwnedThrough < 0 || p.FinishedThrough < 0 {
+		return fmt.Errorf("book numbers must not be negative")
+	}
+
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO reading_progress
+	          (user_id, series_id, format, owned_through, finished_through, updated_at)
+	          VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+		userID, p.SeriesID, p.Format, p.OwnedThrough, p.FinishedThrough)
+	if err != nil {
+		return fmt.Errorf("failed to set reading progress: %w", err)
+	}
+	return nil
+}
+
+// SeedWatchlists puts every existing series on every given user's watchlist.
+// It runs once, so databases from before watchlists keep showing everyone
+// the series they saw before; later calls do nothing.
+func (s *Service) SeedWatchlists(userIDs []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var seeded int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM runtime_settings WHERE key = 'watchlists_seeded'`).Scan(&seeded); err != nil {
+		return fmt.Errorf("failed to check watchlist seeding: %w", err)
+	}
+	if seeded > 0 {
+		return nil
+	}
+
+	for _, userID := range userIDs {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO user_series (user_id, series_id) SELECT ?, id FROM series`, userID)
+		if err != nil {
+			return fmt.Errorf("failed to seed watchlist: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO runtime_settings (key, value) VALUES ('watchlists_seeded', ?)`,
+		time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to record watchlist seeding: %w", err)
+	}
+	return tx.Commit()
+}
+
 // nilIfEmpty returns nil for empty strings, otherwise returns pointer to string
 func nilIfEmpty(s string) *string {
 	if s == "" {
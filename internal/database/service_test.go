@@ -0,0 +1,805 @@
+package database
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+// newTestService returns a service backed by a fully migrated temporary database
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	db, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewService(db)
+}
+
+// addUsers creates bare user accounts for watchlists and reading progress to
+// refer to
+func addUsers(t *testing.T, svc *Service, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		_, err := svc.db.Exec(`INSERT INTO users (id, username, password_hash, ical_token) VALUES (?, ?, '', ?)`,
+			id, id, "ical-"+id)
+		if err != nil {
+			t.Fatalf("add user %s: %v", id, err)
+		}
+	}
+}
+
+func date(s string) *time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return &d
+}
+
+func TestUpdateSeriesBooks(t *testing.T) {
+	svc := newTestService(t)
+	series, err := svc.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "B0AMZSERIE")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	// stored returns one provider's books as title:number:flags
+	stored := func(provider string) []string {
+		t.Helper()
+		books, err := svc.GetAllBooks()
+		if err != nil {
+			t.Fatalf("GetAllBooks: %v", err)
+		}
+		var out []string
+		for _, b := range books {
+			if b.SeriesID != series.ID || b.Provider != provider {
+				continue
+			}
+			row := b.Title + ":"
+			if b.BookNumber != nil {
+				row += strconv.Itoa(*b.BookNumber)
+			}
+			if b.IsLatest {
+				row += ":latest"
+			}
+			if b.IsPreorder {
+				row += ":preorder"
+			}
+			out = append(out, row)
+		}
+		return out
+	}
+	expect := func(got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("books = %v, want %v", got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("books = %v, want %v", got, want)
+			}
+		}
+	}
+
+	// A catalog is stored book by book; novellas and repeated positions keep
+	// no number, and the latest release is flagged
+	catalog := models.SeriesInfo{AudibleCount: 5, AudibleLatestTitle: "Book Two", AudibleBooks: []models.Book{
+		{Title: "Book One", Position: 1, ASIN: "B0BOOK0001", ReleaseDate: date("2024-01-10")},
+		{Title: "A Novella", Position: 0, ReleaseDate: date("2024-03-01")},
+		{Title: "Book Two", Position: 2, ASIN: "B0BOOK0002", ReleaseDate: date("2024-06-01")},
+		{Title: "Books One and Two", Position: 2, ReleaseDate: date("2024-06-01")},
+		{Title: "Book Three", Position: 3, ReleaseDate: date("2099-03-01"), IsPreorder: true},
+	}}
+	if err := svc.UpdateSeriesBooks(series.ID, ProviderAudible, catalog); err != nil {
+		t.Fatalf("UpdateSeriesBooks: %v", err)
+	}
+	expect(stored(ProviderAudible), "Book One:1", "Book Two:2:latest", "Book Three:3:preorder", "A Novella:", "Books One and Two:")
+
+	// A rescrape replaces the catalog rather than adding to it
+	catalog.AudibleLatestTitle = ""
+	catalog.AudibleBooks = catalog.AudibleBooks[:2]
+	if err := svc.UpdateSeriesBooks(series.ID, ProviderAudible, catalog); err != nil {
+		t.Fatalf("UpdateSeriesBooks: %v", err)
+	}
+	expect(stored(ProviderAudible), "Book One:1", "A Novella::latest")
+
+	// Without a catalog only the latest and next books are stored, numbered
+	// from the count; the other provider's books are left alone
+	summary := models.SeriesInfo{AmazonCount: 4, AmazonLatestDate: date("2024-06-01"), AmazonNextDate: date("2099-03-01")}
+	if err := svc.UpdateSeriesBooks(series.ID, ProviderAmazon, summary); err != nil {
+		t.Fatalf("UpdateSeriesBooks: %v", err)
+	}
+	expect(stored(ProviderAmazon), "Book 4:4:latest", "Book 5:5:preorder")
+	expect(stored(ProviderAudible), "Book One:1", "A Novella::latest")
+}
+
+func TestUpdateSeriesBooksRecordsReleaseEvents(t *testing.T) {
+	svc := newTestService(t)
+	series, err := svc.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+
+	scrape := func(books ...models.Book) {
+		t.Helper()
+		info := models.SeriesInfo{AudibleCount: len(books), AudibleBooks: books}
+		if err := svc.UpdateSeriesBooks(series.ID, ProviderAudible, info); err != nil {
+			t.Fatalf("UpdateSeriesBooks: %v", err)
+		}
+	}
+
+	// The first scrape is the baseline and records nothing
+	scrape(
+		models.Book{Title: "Book One", Position: 1, ASIN: "B0BOOK0001", ReleaseDate: date("2024-01-10")},
+		models.Book{Title: "Book Two", Position: 2, ASIN: "B0BOOK0002", ReleaseDate: date("2099-03-01"), IsPreorder: true},
+		models.Book{Title: "Book Three", Position: 3, ASIN: "B0BOOK0003", ReleaseDate: date("2099-09-01"), IsPreorder: true},
+	)
+	events, err := svc.GetReleaseEvents(series.ID)
+	if err != nil {
+		t.Fatalf("GetReleaseEvents: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("baseline scrape recorded %d events, want 0", len(events))
+	}
+
+	// Book two goes on sale, book three slips and book four is announced;
+	// an unchanged rescrape must not add anything
+	second := []models.Book{
+		{Title: "Book One", Position: 1, ASIN: "B0BOOK0001", ReleaseDate: date("2024-01-10")},
+		{Title: "Book Two", Position: 2, ASIN: "B0BOOK0002", ReleaseDate: date("2099-03-01")},
+		{Title: "Book Three", Position: 3, ASIN: "B0BOOK0003", ReleaseDate: date("2099-11-15"), IsPreorder: true},
+		{Title: "Book Four", Position: 4, ASIN: "B0BOOK0004", IsPreorder: true},
+	}
+	scrape(second...)
+	scrape(second...)
+
+	events, err = svc.GetReleaseEvents(series.ID)
+	if err != nil {
+		t.Fatalf("GetReleaseEvents: %v", err)
+	}
+	got := make(map[string]ReleaseEvent)
+	for _, e := range events {
+		if _, dup := got[e.BookTitle]; dup {
+			t.Errorf("more than one event for %q", e.BookTitle)
+		}
+		got[e.BookTitle] = e
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+
+	if e := got["Book Two"]; e.EventType != EventReleased {
+		t.Errorf("Book Two event = %q, want %q", e.EventType, EventReleased)
+	}
+	e := got["Book Three"]
+	if e.EventType != EventDateChanged {
+		t.Errorf("Book Three event = %q, want %q", e.EventType, EventDateChanged)
+	}
+	if e.OldReleaseDate == nil || e.NewReleaseDate == nil ||
+		!sameDay(*e.OldReleaseDate, *date("2099-09-01")) || !sameDay(*e.NewReleaseDate, *date("2099-11-15")) {
+		t.Errorf("Book Three dates = %v -> %v, want 2099-09-01 -> 2099-11-15", e.OldReleaseDate, e.NewReleaseDate)
+	}
+	if e := got["Book Four"]; e.EventType != EventAnnounced || e.BookNumber == nil || *e.BookNumber != 4 {
+		t.Errorf("Book Four event = %+v, want announced as #4", e)
+	}
+}
+
+func TestWatchlists(t *testing.T) {
+	svc := newTestService(t)
+	saga, err := svc.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	chronicle, err := svc.UpsertSeries("The Example Chronicle", "", "", "B0AMZSERIE")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	addUsers(t, svc, "alice", "bob")
+
+	// Existing users start out watching every existing series, once
+	if err := svc.SeedWatchlists([]string{"alice"}); err != nil {
+		t.Fatalf("SeedWatchlists: %v", err)
+	}
+	if err := svc.SeedWatchlists([]string{"bob"}); err != nil {
+		t.Fatalf("second SeedWatchlists: %v", err)
+	}
+	if ids, _ := svc.GetWatchedSeriesIDs("alice"); len(ids) != 2 {
+		t.Errorf("alice watches %v after seeding, want both series", ids)
+	}
+	if ids, _ := svc.GetWatchedSeriesIDs("bob"); len(ids) != 0 {
+		t.Errorf("bob watches %v, want nothing since seeding already ran", ids)
+	}
+
+	if err := svc.WatchSeries("bob", saga.ID); err != nil {
+		t.Fatalf("WatchSeries: %v", err)
+	}
+	if err := svc.WatchSeries("bob", saga.ID); err != nil {
+		t.Fatalf("WatchSeries twice: %v", err)
+	}
+	if n, err := svc.CountSeriesWatchers(saga.ID); err != nil || n != 2 {
+		t.Errorf("CountSeriesWatchers = %d, %v; want 2", n, err)
+	}
+	if ok, err := svc.IsWatching("bob", chronicle.ID); err != nil || ok {
+		t.Errorf("IsWatching(bob, chronicle) = %v, %v; want false", ok, err)
+	}
+
+	// Unwatching leaves the series in place for everyone else
+	if err := svc.UnwatchSeries("alice", chronicle.ID); err != nil {
+		t.Fatalf("UnwatchSeries: %v", err)
+	}
+	stats, err := svc.GetWatchedSeriesStats()
+	if err != nil {
+		t.Fatalf("GetWatchedSeriesStats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].ID != saga.ID {
+		t.Errorf("watched series = %+v, want only %q", stats, saga.Title)
+	}
+	if all, _ := svc.GetAllSeries(); len(all) != 2 {
+		t.Errorf("got %d series after unwatching, want 2", len(all))
+	}
+
+	// Deleting a user drops their watchlist and reading progress
+	if err := svc.SetReadingProgress("bob", ReadingProgress{SeriesID: saga.ID, Format: "audio", OwnedThrough: 1}); err != nil {
+		t.Fatalf("SetReadingProgress: %v", err)
+	}
+	if _, err := svc.db.Exec(`DELETE FROM users WHERE id = 'bob'`); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if ids, _ := svc.GetWatchedSeriesIDs("bob"); len(ids) != 0 {
+		t.Errorf("bob watches %v after being deleted, want nothing", ids)
+	}
+	var progress int
+	if err := svc.db.QueryRow(`SELECT COUNT(*) FROM reading_progress WHERE user_id = 'bob'`).Scan(&progress); err != nil || progress != 0 {
+		t.Errorf("bob has %d reading progress rows after being deleted, want none (%v)", progress, err)
+	}
+
+	// Deleting a series drops it from every watchlist
+	if err := svc.DeleteSeries(saga.ID); err != nil {
+		t.Fatalf("DeleteSeries: %v", err)
+	}
+	if ids, _ := svc.GetAllWatchedSeriesIDs(); len(ids) != 0 {
+		t.Errorf("watched series %v remain after delete, want none", ids)
+	}
+}
+
+func TestReadingProgress(t *testing.T) {
+	svc := newTestService(t)
+	series, err := svc.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "B0AMZSERIE")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	addUsers(t, svc, "alice", "bob")
+
+	set := func(userID, format string, owned, finished int) error {
+		return svc.SetReadingProgress(userID, ReadingProgress{
+			SeriesID: series.ID, Format: format, OwnedThrough: owned, FinishedThrough: finished,
+		})
+	}
+	if err := set("alice", FormatAudio, 3, 1); err != nil {
+		t.Fatalf("SetReadingProgress: %v", err)
+	}
+	if err := set("alice", FormatAudio, 4, 2); err != nil {
+		t.Fatalf("SetReadingProgress update: %v", err)
+	}
+	if err := set("alice", FormatEbook, 1, 1); err != nil {
+		t.Fatalf("SetReadingProgress ebook: %v", err)
+	}
+	if err := set("alice", "paperback", 1, 1); err == nil {
+		t.Error("SetReadingProgress accepted an unknown format")
+	}
+	if err := set("alice", FormatAudio, -1, 0); err == nil {
+		t.Error("SetReadingProgress accepted a negative book number")
+	}
+
+	progress, err := svc.GetReadingProgress("alice")
+	if err != nil {
+		t.Fatalf("GetReadingProgress: %v", err)
+	}
+	audio := progress[series.ID][FormatAudio]
+	if audio.OwnedThrough != 4 || audio.FinishedThrough != 2 {
+		t.Errorf("audio progress = %+v, want owned 4 finished 2", audio)
+	}
+	if ebook := progress[series.ID][FormatEbook]; ebook.OwnedThrough != 1 {
+		t.Errorf("ebook progress = %+v, want owned 1", ebook)
+	}
+
+	// Progress is per user
+	if other, _ := svc.GetReadingProgress("bob"); len(other) != 0 {
+		t.Errorf("bob has progress %v, want none", other)
+	}
+}
+
+func TestUpdateSeries(t *testing.T) {
+	svc := newTestService(t)
+	series, err := svc.UpsertSeries("The Example Saga", "B0AUDSERIE", "https://www.audible.com/series/B0AUDSERIE", "B0AMZSERIE")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	for _, provider := range []string{ProviderAudible, ProviderAmazon} {
+		info := models.SeriesInfo{
+			AudibleCount: 2, AudibleBooks: []models.Book{{Title: "Book One", Position: 1}, {Title: "Book Two", Position: 2}},
+			AmazonCount: 2, AmazonBooks: []models.Book{{Title: "Book One", Position: 1}, {Title: "Book Two", Position: 2}},
+		}
+		if err := svc.UpdateSeriesBooks(series.ID, provider, info); err != nil {
+			t.Fatalf("UpdateSeriesBooks: %v", err)
+		}
+	}
+	// A second scrape records an event that has to survive the edit
+	info := models.SeriesInfo{AudibleCount: 3, AudibleBooks: []models.Book{
+		{Title: "Book One", Position: 1}, {Title: "Book Two", Position: 2}, {Title: "Book Three", Position: 3},
+	}}
+	if err := svc.UpdateSeriesBooks(series.ID, ProviderAudible, info); err != nil {
+		t.Fatalf("UpdateSeriesBooks: %v", err)
+	}
+
+	// A rename alone keeps everything
+	updated, changed, err := svc.UpdateSeries(series.ID, "The Example Saga (Unabridged)", "B0AUDSERIE", "https://www.audible.com/series/B0AUDSERIE", "B0AMZSERIE")
+	if err != nil {
+		t.Fatalf("UpdateSeries: %v", err)
+	}
+	if updated.ID != series.ID || updated.Title != "The Example Saga (Unabridged)" || len(changed) != 0 {
+		t.Errorf("rename = %+v, changed %v", updated, changed)
+	}
+
+	// A new Audible listing clears the old listing's books but not Amazon's
+	_, changed, err = svc.UpdateSeries(series.ID, "The Example Saga (Unabridged)", "B0AUDOTHER", "https://www.audible.com/series/B0AUDOTHER", "B0AMZSERIE")
+	if err != nil {
+		t.Fatalf("UpdateSeries: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != ProviderAudible {
+		t.Errorf("changed = %v, want [audible]", changed)
+	}
+	books, err := svc.GetSeriesBooks(series.ID)
+	if err != nil {
+		t.Fatalf("GetSeriesBooks: %v", err)
+	}
+	for _, b := range books {
+		if b.Provider == ProviderAudible {
+			t.Errorf("audible book %q from the old listing is still stored", b.Title)
+		}
+	}
+	if len(books) != 2 {
+		t.Errorf("got %d books, want amazon's 2", len(books))
+	}
+	stats, err := svc.GetAllSeriesStats()
+	if err != nil {
+		t.Fatalf("GetAllSeriesStats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].AudibleCount != 0 || stats[0].AmazonCount != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// The history is kept, and the new listing's first scrape is a baseline
+	if err := svc.UpdateSeriesBooks(series.ID, ProviderAudible, models.SeriesInfo{
+		AudibleCount: 1, AudibleBooks: []models.Book{{Title: "Another Book", Position: 1}},
+	}); err != nil {
+		t.Fatalf("UpdateSeriesBooks: %v", err)
+	}
+	events, err := svc.GetReleaseEvents(series.ID)
+	if err != nil {
+		t.Fatalf("GetReleaseEvents: %v", err)
+	}
+	if len(events) != 1 || events[0].BookTitle != "Book Three" {
+		t.Errorf("events = %+v, want only Book Three's announcement", events)
+	}
+}
+
+func TestUpsertConfigSeries(t *testing.T) {
+	svc := newTestService(t)
+	load := func(title, audibleID, amazonASIN string) (*Series, bool) {
+		t.Helper()
+		series, created, err := svc.UpsertConfigSeries(models.SeriesIDs{Title: title, AudibleID: audibleID, AmazonASIN: amazonASIN})
+		if err != nil {
+			t.Fatalf("UpsertConfigSeries(%q): %v", title, err)
+		}
+		return series, created
+	}
+
+	series, created := load("The Example Saga", "B0AUDSERIE", "")
+	if !created {
+		t.Fatalf("first load didn't create the series")
+	}
+
+	// Reloading an unchanged entry leaves edits made in the UI alone
+	if _, _, err := svc.UpdateSeries(series.ID, "The Example Saga (Unabridged)", "B0AUDOTHER", "", "B0AMZSERIE"); err != nil {
+		t.Fatalf("UpdateSeries: %v", err)
+	}
+	reloaded, created := load("The Example Saga", "B0AUDSERIE", "")
+	if created || reloaded.ID != series.ID || reloaded.Title != "The Example Saga (Unabridged)" || *reloaded.AudibleID != "B0AUDOTHER" {
+		t.Errorf("reload = %+v (created %v), want the edited series", reloaded, created)
+	}
+	all, err := svc.GetAllSeries()
+	if err != nil {
+		t.Fatalf("GetAllSeries: %v", err)
+	}
+	if len(all) != 1 {
+		t.Errorf("got %d series after reload, want 1", len(all))
+	}
+
+	// Changing the entry in the file updates the series with its title
+	other, _ := load("The Example Chronicle", "B0CHRONICL", "")
+	if _, _, err := svc.UpdateSeries(other.ID, "The Example Chronicle", "B0CHRONICL", "", "B0AMZCHRON"); err != nil {
+		t.Fatalf("UpdateSeries: %v", err)
+	}
+	changed, created := load("The Example Chronicle", "B0CHRONIC2", "")
+	if created || changed.ID != other.ID || *changed.AudibleID != "B0CHRONIC2" || changed.AmazonASIN != nil {
+		t.Errorf("changed entry = %+v (created %v)", changed, created)
+	}
+}
+
+func TestSeriesOverrides(t *testing.T) {
+	svc := newTestService(t)
+	series, err := svc.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	scrape := func() {
+		t.Helper()
+		info := models.SeriesInfo{AudibleCount: 2, AudibleBooks: []models.Book{
+			{Title: "Book One", Position: 1, ReleaseDate: date("2024-01-10")},
+			{Title: "Book Two", Position: 2, ReleaseDate: date("2099-03-01"), IsPreorder: true},
+		}}
+		if err := svc.UpdateSeriesBooks(series.ID, ProviderAudible, info); err != nil {
+			t.Fatalf("UpdateSeriesBooks: %v", err)
+		}
+	}
+	stats := func() SeriesStats {
+		t.Helper()
+		all, err := svc.GetAllSeriesStats()
+		if err != nil || len(all) != 1 {
+			t.Fatalf("GetAllSeriesStats = %d series, %v", len(all), err)
+		}
+		return all[0]
+	}
+	sameDay := func(got, want *time.Time) bool {
+		return got != nil && want != nil && got.Format("2006-01-02") == want.Format("2006-01-02")
+	}
+	scrape()
+
+	// Pinned values win over scraped ones, and a rescrape doesn't undo them
+	count := 5
+	pinned := models.Override{Count: &count, NextDate: date("2099-06-15")}
+	if err := svc.SetSeriesOverrides(series.ID, pinned, models.Override{}); err != nil {
+		t.Fatalf("SetSeriesOverrides: %v", err)
+	}
+	scrape()
+	s := stats()
+	if s.AudibleCount != 5 || !sameDay(s.AudibleNextDate, date("2099-06-15")) || !sameDay(s.AudibleLatestDate, date("2024-01-10")) {
+		t.Errorf("stats = count %d, latest %v, next %v; want the pinned count and next date", s.AudibleCount, s.AudibleLatestDate, s.AudibleNextDate)
+	}
+	if s.AudibleOverride.String() != "5,,2099-06-15" || !s.AmazonOverride.IsZero() {
+		t.Errorf("overrides = %v / %v", s.AudibleOverride, s.AmazonOverride)
+	}
+	audible, amazon, err := svc.GetSeriesOverrides(series.ID)
+	if err != nil || audible.String() != "5,,2099-06-15" || !amazon.IsZero() {
+		t.Errorf("GetSeriesOverrides = %v / %v (%v)", audible, amazon, err)
+	}
+
+	// Clearing them brings the scraped values back
+	if err := svc.SetSeriesOverrides(series.ID, models.Override{}, models.Override{}); err != nil {
+		t.Fatalf("SetSeriesOverrides: %v", err)
+	}
+	s = stats()
+	if s.AudibleCount != 2 || !sameDay(s.AudibleNextDate, date("2099-03-01")) || !s.AudibleOverride.IsZero() {
+		t.Errorf("stats = count %d, next %v, overrides %v; want the scraped values", s.AudibleCount, s.AudibleNextDate, s.AudibleOverride)
+	}
+
+	// Loading a books.yaml entry sets the overrides it pins
+	count = 7
+	e := models.SeriesIDs{Title: "The Example Saga", AudibleID: "B0AUDSERIE", AmazonOverride: models.Override{Count: &count}}
+	if _, _, err := svc.UpsertConfigSeries(e); err != nil {
+		t.Fatalf("UpsertConfigSeries: %v", err)
+	}
+	if s = stats(); s.AmazonCount != 7 || s.AmazonOverride.String() != "7,," {
+		t.Errorf("amazon count = %d, overrides %v; want 7 from the entry", s.AmazonCount, s.AmazonOverride)
+	}
+}
+
+// newTestJob creates a series with a pending audible scrape job
+func newTestJob(t *testing.T, svc *Service) *ScrapeJob {
+	t.Helper()
+	series, err := svc.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	job, err := svc.CreateScrapeJob(series.ID, ProviderAudible)
+	if err != nil {
+		t.Fatalf("CreateScrapeJob: %v", err)
+	}
+	return job
+}
+
+// claim leases the next runnable job to owner, failing the test if there is none
+func claim(t *testing.T, svc *Service, owner string, lease time.Duration) *ScrapeJob {
+	t.Helper()
+	job, err := svc.ClaimScrapeJob(owner, lease)
+	if err != nil {
+		t.Fatalf("ClaimScrapeJob: %v", err)
+	}
+	if job == nil {
+		t.Fatalf("ClaimScrapeJob by %s found nothing to do", owner)
+	}
+	return job
+}
+
+func TestClaimScrapeJob(t *testing.T) {
+	scrapeErr := &models.ScrapeError{Provider: "audible", Category: models.ErrorNetwork, Err: errors.New("timeout")}
+
+	tests := []struct {
+		name         string
+		setup        func(t *testing.T, svc *Service, job *ScrapeJob)
+		skip         []string
+		wantClaim    bool
+		wantAttempts int
+	}{
+		{"pending job", func(t *testing.T, svc *Service, job *ScrapeJob) {}, nil, true, 1},
+		{"retry not yet due", func(t *testing.T, svc *Service, job *ScrapeJob) {
+			claim(t, svc, "worker-1", time.Minute)
+			svc.RescheduleScrapeJob(job.ID, "worker-1", scrapeErr, time.Now().Add(time.Hour))
+		}, nil, false, 0},
+		{"retry due", func(t *testing.T, svc *Service, job *ScrapeJob) {
+			claim(t, svc, "worker-1", time.Minute)
+			svc.RescheduleScrapeJob(job.ID, "worker-1", scrapeErr, time.Now().Add(-time.Second))
+		}, nil, true, 2},
+		{"leased to another worker", func(t *testing.T, svc *Service, job *ScrapeJob) {
+			claim(t, svc, "worker-1", time.Minute)
+		}, nil, false, 0},
+		{"lease expired", func(t *testing.T, svc *Service, job *ScrapeJob) {
+			claim(t, svc, "worker-1", -time.Second)
+		}, nil, true, 2},
+		{"provider paused", func(t *testing.T, svc *Service, job *ScrapeJob) {}, []string{ProviderAudible}, false, 0},
+		{"other provider paused", func(t *testing.T, svc *Service, job *ScrapeJob) {}, []string{ProviderAmazon}, true, 1},
+		{"cancelled", func(t *testing.T, svc *Service, job *ScrapeJob) {
+			svc.CancelScrapeJob(job.ID)
+		}, nil, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			job := newTestJob(t, svc)
+			tt.setup(t, svc, job)
+
+			got, err := svc.ClaimScrapeJob("worker-2", time.Minute, tt.skip...)
+			if err != nil {
+				t.Fatalf("ClaimScrapeJob: %v", err)
+			}
+			if (got != nil) != tt.wantClaim {
+				t.Fatalf("claimed = %v, want %v", got != nil, tt.wantClaim)
+			}
+			if got == nil {
+				return
+			}
+			if got.ID != job.ID || got.Status != JobStatusRunning || got.Attempts != tt.wantAttempts {
+				t.Errorf("claimed job = %+v, want job %d running on attempt %d", got, job.ID, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRenewScrapeJobLease(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(svc *Service, job *ScrapeJob)
+		owner   string
+		wantErr bool
+	}{
+		{"held by owner", func(svc *Service, job *ScrapeJob) {}, "worker-1", false},
+		{"held by another worker", func(svc *Service, job *ScrapeJob) {}, "worker-2", true},
+		{"cancelled", func(svc *Service, job *ScrapeJob) { svc.CancelScrapeJob(job.ID) }, "worker-1", true},
+		{"requeued", func(svc *Service, job *ScrapeJob) { svc.RequeueInterruptedJobs() }, "worker-1", true},
+		{"reclaimed after expiry", func(svc *Service, job *ScrapeJob) {
+			svc.db.Exec(`UPDATE scrape_jobs SET lease_expires_at = ? WHERE id = ?`, time.Now().UTC().Add(-time.Second), job.ID)
+			svc.ClaimScrapeJob("worker-2", time.Minute)
+		}, "worker-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			newTestJob(t, svc)
+			job := claim(t, svc, "worker-1", time.Minute)
+			tt.setup(svc, job)
+
+			err := svc.RenewScrapeJobLease(job.ID, tt.owner, time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RenewScrapeJobLease by %s error = %v, want error %v", tt.owner, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequeueInterruptedJobs(t *testing.T) {
+	svc := newTestService(t)
+	series, err := svc.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "B0AMZSERIE")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+
+	// One job finishes, one is left running and one never starts
+	for _, provider := range []string{ProviderAudible, ProviderAmazon, ProviderAudible} {
+		if _, err := svc.CreateScrapeJob(series.ID, provider); err != nil {
+			t.Fatalf("CreateScrapeJob: %v", err)
+		}
+	}
+	done := claim(t, svc, "worker-1", time.Minute)
+	if err := svc.CompleteScrapeJob(*done, "worker-1", models.SeriesInfo{AudibleCount: 1}); err != nil {
+		t.Fatalf("CompleteScrapeJob: %v", err)
+	}
+	interrupted := claim(t, svc, "worker-1", time.Minute)
+
+	n, err := svc.RequeueInterruptedJobs()
+	if err != nil {
+		t.Fatalf("RequeueInterruptedJobs: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("requeued %d jobs, want 1", n)
+	}
+
+	// The interrupted job is runnable again straight away, ahead of the newer one
+	again := claim(t, svc, "worker-2", time.Minute)
+	if again.ID != interrupted.ID || again.Attempts != 2 {
+		t.Errorf("reclaimed job = %+v, want job %d on attempt 2", again, interrupted.ID)
+	}
+	jobs, err := svc.GetSeriesScrapeJobs(series.ID, 10)
+	if err != nil {
+		t.Fatalf("GetSeriesScrapeJobs: %v", err)
+	}
+	for _, job := range jobs {
+		if job.ID == done.ID && job.Status != JobStatusCompleted {
+			t.Errorf("finished job %d is now %s", job.ID, job.Status)
+		}
+	}
+}
+
+func TestFailScrapeJobLease(t *testing.T) {
+	scrapeErr := &models.ScrapeError{Provider: "audible", Category: models.ErrorNetwork, Err: errors.New("timeout")}
+	ops := map[string]struct {
+		finish     func(svc *Service, job *ScrapeJob, owner string) error
+		wantStatus string
+	}{
+		"fail": {func(svc *Service, job *ScrapeJob, owner string) error {
+			return svc.FailScrapeJob(job.ID, owner, scrapeErr)
+		}, JobStatusFailed},
+		"reschedule": {func(svc *Service, job *ScrapeJob, owner string) error {
+			return svc.RescheduleScrapeJob(job.ID, owner, scrapeErr, time.Now().Add(time.Minute))
+		}, JobStatusPending},
+	}
+
+	tests := []struct {
+		name       string
+		setup      func(svc *Service, job *ScrapeJob)
+		owner      string
+		wantErr    error
+		wantStatus string // "" for the operation's own status
+	}{
+		{"held by owner", func(svc *Service, job *ScrapeJob) {}, "worker-1", nil, ""},
+		{"held by another worker", func(svc *Service, job *ScrapeJob) {}, "worker-2", ErrLeaseLost, JobStatusRunning},
+		{"cancelled", func(svc *Service, job *ScrapeJob) { svc.CancelScrapeJob(job.ID) }, "worker-1", ErrLeaseLost, JobStatusFailed},
+		{"reclaimed", func(svc *Service, job *ScrapeJob) { svc.RequeueInterruptedJobs() }, "worker-1", ErrLeaseLost, JobStatusPending},
+	}
+
+	for name, op := range ops {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				svc := newTestService(t)
+				newTestJob(t, svc)
+				job := claim(t, svc, "worker-1", time.Minute)
+				tt.setup(svc, job)
+
+				if err := op.finish(svc, job, tt.owner); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				jobs, err := svc.GetSeriesScrapeJobs(job.SeriesID, 1)
+				if err != nil || len(jobs) != 1 {
+					t.Fatalf("GetSeriesScrapeJobs = %+v, %v", jobs, err)
+				}
+				want := tt.wantStatus
+				if want == "" {
+					want = op.wantStatus
+				}
+				if jobs[0].Status != want {
+					t.Errorf("job status = %s, want %s", jobs[0].Status, want)
+				}
+				// Someone else's outcome isn't overwritten with this worker's error
+				if tt.wantErr != nil && jobs[0].ErrorMessage != nil && *jobs[0].ErrorMessage == scrapeErr.Error() {
+					t.Errorf("job error = %q, want it left as it was", *jobs[0].ErrorMessage)
+				}
+			})
+		}
+	}
+}
+
+func TestCompleteScrapeJob(t *testing.T) {
+	info := models.SeriesInfo{AudibleCount: 1, AudibleBooks: []models.Book{{Title: "Book One", Position: 1}}}
+
+	tests := []struct {
+		name       string
+		setup      func(svc *Service, job *ScrapeJob)
+		owner      string
+		wantErr    error
+		wantStatus string
+	}{
+		{"held by owner", func(svc *Service, job *ScrapeJob) {}, "worker-1", nil, JobStatusCompleted},
+		{"held by another worker", func(svc *Service, job *ScrapeJob) {}, "worker-2", ErrLeaseLost, JobStatusRunning},
+		{"cancelled", func(svc *Service, job *ScrapeJob) { svc.CancelScrapeJob(job.ID) }, "worker-1", ErrLeaseLost, JobStatusFailed},
+		{"requeued", func(svc *Service, job *ScrapeJob) { svc.RequeueInterruptedJobs() }, "worker-1", ErrLeaseLost, JobStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t)
+			newTestJob(t, svc)
+			job := claim(t, svc, "worker-1", time.Minute)
+			tt.setup(svc, job)
+
+			if err := svc.CompleteScrapeJob(*job, tt.owner, info); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteScrapeJob error = %v, want %v", err, tt.wantErr)
+			}
+
+			jobs, err := svc.GetSeriesScrapeJobs(job.SeriesID, 1)
+			if err != nil {
+				t.Fatalf("GetSeriesScrapeJobs: %v", err)
+			}
+			if len(jobs) != 1 || jobs[0].Status != tt.wantStatus {
+				t.Errorf("jobs = %+v, want status %s", jobs, tt.wantStatus)
+			}
+
+			// A dropped result leaves the stored books alone
+			books, err := svc.GetSeriesBooks(job.SeriesID)
+			if err != nil {
+				t.Fatalf("GetSeriesBooks: %v", err)
+			}
+			wantBooks := 0
+			if tt.wantErr == nil {
+				wantBooks = 1
+			}
+			if len(books) != wantBooks {
+				t.Errorf("stored %d books, want %d", len(books), wantBooks)
+			}
+		})
+	}
+}
+
+func TestUpdateSeriesCancelsOldListingScrapes(t *testing.T) {
+	svc := newTestService(t)
+	series, err := svc.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "B0AMZSERIE")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	for _, provider := range []string{ProviderAudible, ProviderAmazon} {
+		if _, err := svc.CreateScrapeJob(series.ID, provider); err != nil {
+			t.Fatalf("CreateScrapeJob: %v", err)
+		}
+	}
+	audible := claim(t, svc, "worker-1", time.Minute)
+	amazon := claim(t, svc, "worker-2", time.Minute)
+
+	// Nothing else gets a chance to cancel the job: the scrape finishes right
+	// after the listing changes
+	if _, _, err := svc.UpdateSeries(series.ID, series.Title, "B0AUDOTHER", "", "B0AMZSERIE"); err != nil {
+		t.Fatalf("UpdateSeries: %v", err)
+	}
+	old := models.SeriesInfo{AudibleCount: 1, AudibleBooks: []models.Book{{Title: "Old Listing Book", Position: 1}}}
+	if err := svc.CompleteScrapeJob(*audible, "worker-1", old); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("CompleteScrapeJob for the old listing = %v, want ErrLeaseLost", err)
+	}
+
+	// The unchanged provider's scrape is unaffected
+	info := models.SeriesInfo{AmazonCount: 1, AmazonBooks: []models.Book{{Title: "Book One", Position: 1}}}
+	if err := svc.CompleteScrapeJob(*amazon, "worker-2", info); err != nil {
+		t.Errorf("CompleteScrapeJob for amazon: %v", err)
+	}
+
+	books, err := svc.GetSeriesBooks(series.ID)
+	if err != nil {
+		t.Fatalf("GetSeriesBooks: %v", err)
+	}
+	if len(books) != 1 || books[0].Provider != ProviderAmazon {
+		t.Errorf("books = %+v, want only amazon's", books)
+	}
+}
@@ -0,0 +1,242 @@
+// Package events broadcasts server-sent events to connected clients. Events
+// about one series only go to the clients allowed to see it.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types sent to clients as the SSE "event:" field
+const (
+	TypeRefresh = "refresh" // Series data changed; clients should reload it
+	TypeScrape  = "scrape"  // A scrape job made progress; data is a scraper.SeriesUpdate
+)
+
+const (
+	DefaultHistory   = 256              // Events kept for clients resuming with Last-Event-ID
+	DefaultKeepAlive = 30 * time.Second // Idle time before a keep-alive comment is sent
+
+	// subscriberBuffer is how many events a client can fall behind before it's
+	// disconnected. It reconnects with Last-Event-ID and catches up from history.
+	subscriberBuffer = 64
+)
+
+// Event is one message in the stream. IDs increase by one per event and are
+// sent to clients prefixed with the hub's epoch, so an ID from before a
+// restart is never mistaken for one of the new run's.
+type Event struct {
+	ID       uint64
+	Type     string
+	Data     []byte // JSON
+	SeriesID int    // Series the event is about; 0 for events every client gets
+}
+
+// Hub fans events out to every subscriber, each with its own buffered channel,
+// and keeps recent events so a reconnecting client misses nothing
+type Hub struct {
+	keepAlive time.Duration
+	epoch     string // Boot time, prefixed to every event ID sent to clients
+
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event // Ring buffer of the most recent events
+	next        int     // Index in history the next event is written to
+	subscribers map[chan Event]struct{}
+}
+
+// NewHub creates a hub that remembers the last history events and sends a
+// keep-alive comment to idle clients every keepAlive
+func NewHub(history int, keepAlive time.Duration) *Hub {
+	if history < 1 {
+		history = DefaultHistory
+	}
+	if keepAlive <= 0 {
+		keepAlive = DefaultKeepAlive
+	}
+	return &Hub{
+		keepAlive:   keepAlive,
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		history:     make([]Event, 0, history),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish sends an event to every subscriber. data is encoded as JSON; nil
+// sends an empty object, since browsers ignore events without data.
+func (h *Hub) Publish(eventType string, data interface{}) {
+	h.publish(eventType, 0, data)
+}
+
+// PublishSeries sends an event about one series, which Serve only passes on
+// to clients allowed to see that series
+func (h *Hub) PublishSeries(eventType string, seriesID int, data interface{}) {
+	h.publish(eventType, seriesID, data)
+}
+
+func (h *Hub) publish(eventType string, seriesID int, data interface{}) {
+	payload := []byte("{}")
+	if data != nil {
+		var err error
+		if payload, err = json.Marshal(data); err != nil {
+			log.Printf("error encoding %s event: %v", eventType, err)
+			return
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event := Event{ID: h.lastID, Type: eventType, Data: payload, SeriesID: seriesID}
+	if len(h.history) < cap(h.history) {
+		h.history = append(h.history, event)
+	} else {
+		h.history[h.next] = event
+	}
+	h.next = (h.next + 1) % cap(h.history)
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Too far behind; drop it and let it resume from history
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe registers a subscriber and returns the events it missed after
+// lastEventID, the Last-Event-ID it reconnected with; "" is a new client with
+// nothing to catch up on. If some were already dropped from history, or the
+// ID is from another epoch (the server restarted), the backlog is a single
+// refresh event instead.
+func (h *Hub) Subscribe(lastEventID string) (<-chan Event, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	h.subscribers[ch] = struct{}{}
+
+	if lastEventID == "" {
+		return ch, nil
+	}
+	refresh := []Event{{ID: h.lastID, Type: TypeRefresh, Data: []byte("{}")}}
+	epoch, id, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != h.epoch {
+		return ch, refresh
+	}
+	lastID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || lastID > h.lastID {
+		return ch, refresh
+	}
+	if lastID == h.lastID {
+		return ch, nil
+	}
+	backlog := h.since(lastID)
+	if len(backlog) == 0 || backlog[0].ID != lastID+1 {
+		return ch, refresh
+	}
+	return ch, backlog
+}
+
+// eventID returns the ID sent to clients for event, which they send back as
+// Last-Event-ID when they reconnect
+func (h *Hub) eventID(event Event) string {
+	return h.epoch + "-" + strconv.FormatUint(event.ID, 10)
+}
+
+// Unsubscribe removes a subscriber. It's safe to call after the hub dropped it.
+func (h *Hub) Unsubscribe(ch <-chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		if sub == ch {
+			delete(h.subscribers, sub)
+			close(sub)
+		}
+	}
+}
+
+// since returns the remembered events with IDs after lastID, oldest first
+func (h *Hub) since(lastID uint64) []Event {
+	var events []Event
+	start := 0
+	if len(h.history) == cap(h.history) {
+		start = h.next
+	}
+	for i := 0; i < len(h.history); i++ {
+		if event := h.history[(start+i)%len(h.history)]; event.ID > lastID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// ServeHTTP streams every event to a client, first replaying any it missed
+// since the Last-Event-ID it sent when reconnecting
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Serve(w, r, nil)
+}
+
+// Serve streams events to a client like ServeHTTP, leaving out events about
+// series that allow rejects. allow is called as events are sent, so it sees
+// changes made while the client is connected; nil allows every series.
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, allow func(seriesID int) bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch, backlog := h.Subscribe(strings.TrimSpace(r.Header.Get("Last-Event-ID")))
+	defer h.Unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	fmt.Fprintf(w, ": connected\n\n")
+	for _, event := range backlog {
+		if visible(event, allow) {
+			h.writeEvent(w, event)
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			if !visible(event, allow) {
+				continue
+			}
+			h.writeEvent(w, event)
+			keepAlive.Reset(h.keepAlive)
+		case <-keepAlive.C:
+			fmt.Fprintf(w, ": keep-alive\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// visible reports whether allow lets a client see event
+func visible(event Event, allow func(seriesID int) bool) bool {
+	return event.SeriesID == 0 || allow == nil || allow(event.SeriesID)
+}
+
+func (h *Hub) writeEvent(w http.ResponseWriter, event Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", h.eventID(event), event.Type, event.Data)
+}
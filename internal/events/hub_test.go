@@ -0,0 +1,195 @@
+package events
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFanOut(t *testing.T) {
+	hub := NewHub(8, time.Minute)
+	first, _ := hub.Subscribe("")
+	second, _ := hub.Subscribe("")
+
+	hub.Publish(TypeRefresh, nil)
+	for i, ch := range []<-chan Event{first, second} {
+		select {
+		case event := <-ch:
+			if event.ID != 1 || event.Type != TypeRefresh || string(event.Data) != "{}" {
+				t.Errorf("subscriber %d got %+v", i, event)
+			}
+		default:
+			t.Errorf("subscriber %d got nothing", i)
+		}
+	}
+
+	// A subscriber that stops reading is dropped rather than blocking the rest
+	for i := 0; i < subscriberBuffer; i++ {
+		hub.Publish(TypeScrape, i)
+	}
+	for len(second) > 0 {
+		<-second
+	}
+	hub.Publish(TypeScrape, subscriberBuffer)
+	received := 0
+	for range first {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("dropped subscriber received %d events before closing, want %d", received, subscriberBuffer)
+	}
+	if event := <-second; event.ID != subscriberBuffer+2 {
+		t.Errorf("reading subscriber got event %d, want %d", event.ID, subscriberBuffer+2)
+	}
+	hub.Unsubscribe(first)
+	hub.Unsubscribe(second)
+}
+
+func TestReplay(t *testing.T) {
+	hub := NewHub(4, time.Minute)
+	for i := 0; i < 6; i++ {
+		hub.Publish(TypeScrape, i)
+	}
+	// The previous run's hub got as far as event 40
+	previous := NewHub(4, time.Minute)
+	previous.epoch = "previous"
+	id := func(h *Hub, n uint64) string { return h.eventID(Event{ID: n}) }
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		want        []string // id:type
+	}{
+		{"new client", "", nil},
+		{"up to date", id(hub, 6), nil},
+		{"missed two", id(hub, 4), []string{"5:scrape", "6:scrape"}},
+		{"oldest remembered", id(hub, 2), []string{"3:scrape", "4:scrape", "5:scrape", "6:scrape"}},
+		{"missed more than history", id(hub, 1), []string{"6:refresh"}},
+		{"ahead of this run", id(hub, 40), []string{"6:refresh"}},
+		{"from before a restart", id(previous, 40), []string{"6:refresh"}},
+		{"restart that got less far", id(previous, 4), []string{"6:refresh"}},
+		{"restart at the same event", id(previous, 6), []string{"6:refresh"}},
+		{"unprefixed id", "4", []string{"6:refresh"}},
+		{"garbage", hub.epoch + "-x", []string{"6:refresh"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch, backlog := hub.Subscribe(tt.lastEventID)
+			defer hub.Unsubscribe(ch)
+			var got []string
+			for _, event := range backlog {
+				got = append(got, fmt.Sprintf("%d:%s", event.ID, event.Type))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("backlog = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	hub := NewHub(8, 50*time.Millisecond)
+	hub.Publish(TypeScrape, map[string]string{"title": "Dungeon Crawler Carl"})
+	hub.Publish(TypeRefresh, nil)
+	server := httptest.NewServer(hub)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Last-Event-ID", hub.eventID(Event{ID: 1}))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	expect := func(want string) {
+		t.Helper()
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("stream ended waiting for %q", want)
+				}
+				if line == want {
+					return
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("timed out waiting for %q", want)
+			}
+		}
+	}
+
+	// The missed refresh is replayed, then live events follow
+	expect("id: " + hub.epoch + "-2")
+	expect("event: refresh")
+	expect("data: {}")
+	hub.Publish(TypeScrape, map[string]string{"status": "completed"})
+	expect("id: " + hub.epoch + "-3")
+	expect("event: scrape")
+	expect(`data: {"status":"completed"}`)
+	expect(": keep-alive")
+}
+
+func TestServeFiltersSeries(t *testing.T) {
+	hub := NewHub(8, time.Minute)
+	hub.PublishSeries(TypeScrape, 2, map[string]string{"title": "Someone Else's Series"})
+	hub.PublishSeries(TypeScrape, 1, map[string]string{"title": "Watched Series"})
+	hub.Publish(TypeRefresh, nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.Serve(w, r, func(seriesID int) bool { return seriesID == 1 })
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Last-Event-ID", hub.eventID(Event{ID: 0}))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	var data []string
+	readUntilRefresh := func() {
+		t.Helper()
+		scrape := false
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "event: refresh" {
+				return
+			}
+			if scrape && strings.HasPrefix(line, "data: ") {
+				data = append(data, line)
+			}
+			scrape = line == "event: scrape"
+		}
+		t.Fatalf("stream ended before a refresh: %v", scanner.Err())
+	}
+
+	// Both from the backlog and live, only allowed series get through
+	readUntilRefresh()
+	hub.PublishSeries(TypeScrape, 2, map[string]string{"status": "hidden"})
+	hub.PublishSeries(TypeScrape, 1, map[string]string{"status": "shown"})
+	hub.Publish(TypeRefresh, nil)
+	readUntilRefresh()
+
+	want := []string{`data: {"title":"Watched Series"}`, `data: {"status":"shown"}`}
+	if strings.Join(data, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", data, want)
+	}
+}
@@ -0,0 +1,551 @@
+package handlers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/auth"
+	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/events"
+	"github.com/michaeldvinci/syllabus/internal/models"
+	"github.com/michaeldvinci/syllabus/internal/utils"
+)
+
+// OpenAPISpec describes the v1 API. Tests check it against apiV1Routes and
+// the handlers' responses.
+//
+//go:embed openapi.json
+var OpenAPISpec []byte
+
+// maxJobsListed is how many of a series' scrape jobs the API returns
+const maxJobsListed = 50
+
+// apiRoute is one operation of the v1 API and the permission it needs
+type apiRoute struct {
+	Method  string
+	Pattern string
+	Perm    auth.Permission
+	Handler http.HandlerFunc
+}
+
+func (a *App) apiV1Routes() []apiRoute {
+	return []apiRoute{
+		{http.MethodGet, "/api/v1/series", auth.PermView, a.apiListSeries},
+		{http.MethodPost, "/api/v1/series", auth.PermManageSeries, a.apiCreateSeries},
+		{http.MethodGet, "/api/v1/series/{id}", auth.PermView, a.apiGetSeries},
+		{http.MethodPatch, "/api/v1/series/{id}", auth.PermManageSeries, a.apiUpdateSeries},
+		{http.MethodDelete, "/api/v1/series/{id}", auth.PermManageSeries, a.apiDeleteSeries},
+		{http.MethodPut, "/api/v1/series/{id}/overrides", auth.PermManageSeries, a.apiSetOverrides},
+		{http.MethodGet, "/api/v1/series/{id}/books", auth.PermView, a.apiListBooks},
+		{http.MethodGet, "/api/v1/series/{id}/jobs", auth.PermView, a.apiListJobs},
+		{http.MethodPost, "/api/v1/series/{id}/jobs", auth.PermManageSeries, a.apiQueueJobs},
+	}
+}
+
+// RegisterAPIv1 adds the v1 API to mux, wrapping each operation with
+// require for its permission. Every error, including unknown paths and
+// methods, is a JSON object with an "error" message.
+func (a *App) RegisterAPIv1(mux *http.ServeMux, require func(auth.Permission, http.HandlerFunc) http.HandlerFunc) {
+	methods := make(map[string]map[string]http.HandlerFunc)
+	var patterns []string
+	for _, route := range a.apiV1Routes() {
+		if methods[route.Pattern] == nil {
+			methods[route.Pattern] = make(map[string]http.HandlerFunc)
+			patterns = append(patterns, route.Pattern)
+		}
+		methods[route.Pattern][route.Method] = require(route.Perm, route.Handler)
+	}
+
+	for _, pattern := range patterns {
+		handlers := methods[pattern]
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			method := r.Method
+			if method == http.MethodHead {
+				method = http.MethodGet
+			}
+			if handler, ok := handlers[method]; ok {
+				handler(w, r)
+				return
+			}
+			var allowed []string
+			for m := range handlers {
+				allowed = append(allowed, m)
+			}
+			sort.Strings(allowed)
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		})
+	}
+
+	mux.HandleFunc("GET /api/v1/openapi.json", HandleOpenAPI)
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusNotFound, "not found")
+	})
+}
+
+// HandleOpenAPI serves the OpenAPI document for the v1 API
+func HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(OpenAPISpec)
+}
+
+// apiSeries is a series as the v1 API returns it
+type apiSeries struct {
+	ID      int         `json:"id"`
+	Title   string      `json:"title"`
+	Audible apiProvider `json:"audible"`
+	Amazon  apiProvider `json:"amazon"`
+}
+
+// apiProvider is what one storefront lists for a series. ID and URL are
+// empty when the series isn't tracked there.
+type apiProvider struct {
+	ID          string          `json:"id,omitempty"`
+	URL         string          `json:"url,omitempty"`
+	Count       int             `json:"count"`
+	LatestTitle string          `json:"latest_title,omitempty"`
+	LatestDate  *time.Time      `json:"latest_date,omitempty"`
+	NextTitle   string          `json:"next_title,omitempty"`
+	NextDate    *time.Time      `json:"next_date,omitempty"`
+	StaleSince  *time.Time      `json:"stale_since,omitempty"` // Set while the data is from before a failed refresh
+	LastError   *apiScrapeError `json:"last_error,omitempty"`  // Last failed scrape, if the last scrape failed
+	Overrides   *apiOverride    `json:"overrides,omitempty"`   // Values pinned by hand, already applied above
+}
+
+// apiOverride is the values pinned for one storefront, dates as YYYY-MM-DD.
+// Omitted fields aren't pinned.
+type apiOverride struct {
+	Count      *int   `json:"count,omitempty"`
+	LatestDate string `json:"latest_date,omitempty"`
+	NextDate   string `json:"next_date,omitempty"`
+}
+
+type apiScrapeError struct {
+	Category string     `json:"category"`
+	Message  string     `json:"message"`
+	FailedAt *time.Time `json:"failed_at,omitempty"`
+}
+
+// apiSeriesInput is the body of POST and PATCH requests. For PATCH, omitted
+// fields are left alone and an empty URL stops tracking that storefront.
+type apiSeriesInput struct {
+	Title      *string `json:"title"`
+	AudibleURL *string `json:"audible_url"`
+	AmazonURL  *string `json:"amazon_url"`
+}
+
+func toAPISeries(info models.SeriesInfo) apiSeries {
+	row := toRow(info)
+	return apiSeries{
+		ID:    info.ID,
+		Title: info.Title,
+		Audible: apiProvider{
+			ID:          info.AudibleID,
+			URL:         row.AudibleURL,
+			Count:       info.AudibleCount,
+			LatestTitle: info.AudibleLatestTitle,
+			LatestDate:  info.AudibleLatestDate,
+			NextTitle:   info.AudibleNextTitle,
+			NextDate:    info.AudibleNextDate,
+			StaleSince:  info.AudibleStaleSince,
+			LastError:   toAPIScrapeError(info.AudibleError),
+			Overrides:   toAPIOverride(info.AudibleOverride),
+		},
+		Amazon: apiProvider{
+			ID:          info.AmazonASIN,
+			URL:         row.AmazonURL,
+			Count:       info.AmazonCount,
+			LatestTitle: info.AmazonLatestTitle,
+			LatestDate:  info.AmazonLatestDate,
+			NextTitle:   info.AmazonNextTitle,
+			NextDate:    info.AmazonNextDate,
+			StaleSince:  info.AmazonStaleSince,
+			LastError:   toAPIScrapeError(info.AmazonError),
+			Overrides:   toAPIOverride(info.AmazonOverride),
+		},
+	}
+}
+
+func toAPIScrapeError(f *models.ScrapeFailure) *apiScrapeError {
+	if f == nil {
+		return nil
+	}
+	return &apiScrapeError{Category: string(f.Category), Message: f.Message, FailedAt: f.FailedAt}
+}
+
+func toAPIOverride(o models.Override) *apiOverride {
+	if o.IsZero() {
+		return nil
+	}
+	out := &apiOverride{Count: o.Count}
+	if o.LatestDate != nil {
+		out.LatestDate = o.LatestDate.Format("2006-01-02")
+	}
+	if o.NextDate != nil {
+		out.NextDate = o.NextDate.Format("2006-01-02")
+	}
+	return out
+}
+
+// toOverride parses the values to pin for one storefront, writing a 400 if
+// any is invalid
+func (o *apiOverride) toOverride(w http.ResponseWriter, provider string) (models.Override, bool) {
+	if o == nil {
+		return models.Override{}, true
+	}
+	override, err := utils.ParseOverride(nil, o.LatestDate, o.NextDate)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, provider+": "+err.Error())
+		return override, false
+	}
+	if o.Count != nil && *o.Count < 0 {
+		writeAPIError(w, http.StatusBadRequest, provider+": count can't be negative")
+		return override, false
+	}
+	override.Count = o.Count
+	return override, true
+}
+
+// writeAPIJSON writes v as the JSON response body with the given status
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeAPIError writes the JSON error body every v1 error uses
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeAPIJSON(w, status, map[string]string{"error": message})
+}
+
+// decodeAPIInput reads a request body, rejecting unknown fields so typos
+// aren't silently ignored
+func decodeAPIInput(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// apiWatchedSeries returns the series in the {id} path segment if it's on
+// the requesting user's watchlist, writing a 404 if not
+func (a *App) apiWatchedSeries(w http.ResponseWriter, r *http.Request) (*models.SeriesInfo, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err == nil && id > 0 {
+		for _, info := range a.collectWatched(r) {
+			if info.ID == id {
+				return &info, true
+			}
+		}
+	}
+	writeAPIError(w, http.StatusNotFound, "series not found")
+	return nil, false
+}
+
+func (a *App) apiListSeries(w http.ResponseWriter, r *http.Request) {
+	series := []apiSeries{}
+	for _, info := range a.collectWatched(r) {
+		series = append(series, toAPISeries(info))
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]interface{}{"series": series})
+}
+
+func (a *App) apiGetSeries(w http.ResponseWriter, r *http.Request) {
+	info, ok := a.apiWatchedSeries(w, r)
+	if !ok {
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, toAPISeries(*info))
+}
+
+// apiCreateSeries adds a series to the user's watchlist, creating it unless
+// another user already tracks the title. It answers 201 for a new series and
+// 200 for an existing one.
+func (a *App) apiCreateSeries(w http.ResponseWriter, r *http.Request) {
+	var input apiSeriesInput
+	if !decodeAPIInput(w, r, &input) {
+		return
+	}
+	var fields seriesFields
+	if input.Title == nil || strings.TrimSpace(*input.Title) == "" {
+		writeAPIError(w, http.StatusBadRequest, "title is required")
+		return
+	}
+	if !fields.apply(w, input) {
+		return
+	}
+
+	user, ok := auth.GetUserFromContext(r)
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	series, created, err := a.addSeries(user, fields.title, fields.audibleID, fields.audibleURL, fields.amazonASIN, fields.amazonURL)
+	if err != nil {
+		log.Printf("error adding series %s: %v", fields.title, err)
+		writeAPIError(w, http.StatusInternalServerError, "failed to add series")
+		return
+	}
+	a.Events.Publish(events.TypeRefresh, nil)
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/series/%d", series.ID))
+	a.writeAPISeries(w, r, status, series.ID)
+}
+
+// apiUpdateSeries renames a series or changes its storefront URLs. The series
+// keeps its ID, so its history and everyone's watchlists follow it, and a
+// storefront whose URL changed is scraped again.
+func (a *App) apiUpdateSeries(w http.ResponseWriter, r *http.Request) {
+	info, ok := a.apiWatchedSeries(w, r)
+	if !ok {
+		return
+	}
+	var input apiSeriesInput
+	if !decodeAPIInput(w, r, &input) {
+		return
+	}
+
+	series, err := a.DB.GetSeriesByID(info.ID)
+	if err != nil || series == nil {
+		log.Printf("error fetching series %d: %v", info.ID, err)
+		writeAPIError(w, http.StatusInternalServerError, "failed to load series")
+		return
+	}
+
+	fields := seriesFields{title: series.Title}
+	if series.AudibleID != nil {
+		fields.audibleID = *series.AudibleID
+	}
+	if series.AudibleURL != nil {
+		fields.audibleURL = *series.AudibleURL
+	}
+	if series.AmazonASIN != nil {
+		fields.amazonASIN = *series.AmazonASIN
+	}
+	if input.Title != nil && strings.TrimSpace(*input.Title) == "" {
+		writeAPIError(w, http.StatusBadRequest, "title can't be empty")
+		return
+	}
+	if !fields.apply(w, input) {
+		return
+	}
+	if err := a.updateSeries(series.ID, fields.title, fields.audibleID, fields.audibleURL, fields.amazonASIN, fields.amazonURL); err != nil {
+		if errors.Is(err, errTitleTaken) {
+			writeAPIError(w, http.StatusConflict, "another series already has that title")
+			return
+		}
+		log.Printf("error updating series %d: %v", series.ID, err)
+		writeAPIError(w, http.StatusInternalServerError, "failed to update series")
+		return
+	}
+	a.writeAPISeries(w, r, http.StatusOK, series.ID)
+}
+
+// seriesFields are the stored fields of a series being created or updated,
+// plus the Amazon link when one was given
+type seriesFields struct {
+	title      string
+	audibleID  string
+	audibleURL string
+	amazonASIN string
+	amazonURL  string
+}
+
+// apply sets the fields given in input, extracting the storefront IDs from
+// any URLs. It writes a 400 if a URL isn't recognized or no storefront is left.
+func (f *seriesFields) apply(w http.ResponseWriter, input apiSeriesInput) bool {
+	if input.Title != nil {
+		f.title = strings.TrimSpace(*input.Title)
+	}
+	if input.AudibleURL != nil {
+		f.audibleURL = strings.TrimSpace(*input.AudibleURL)
+		if f.audibleID = utils.ExtractAudibleSeriesID(f.audibleURL); f.audibleURL != "" && f.audibleID == "" {
+			writeAPIError(w, http.StatusBadRequest, "audible_url isn't an Audible series URL")
+			return false
+		}
+	}
+	if input.AmazonURL != nil {
+		f.amazonURL = strings.TrimSpace(*input.AmazonURL)
+		if f.amazonASIN = utils.ExtractAmazonASIN(f.amazonURL); f.amazonURL != "" && f.amazonASIN == "" {
+			writeAPIError(w, http.StatusBadRequest, "amazon_url isn't an Amazon product URL")
+			return false
+		}
+	}
+	if f.audibleID == "" && f.amazonASIN == "" {
+		writeAPIError(w, http.StatusBadRequest, "at least one of audible_url and amazon_url is required")
+		return false
+	}
+	return true
+}
+
+// writeAPISeries responds with a series as it's now stored
+func (a *App) writeAPISeries(w http.ResponseWriter, r *http.Request, status, id int) {
+	for _, info := range a.collectAll() {
+		if info.ID == id {
+			writeAPIJSON(w, status, toAPISeries(info))
+			return
+		}
+	}
+	writeAPIError(w, http.StatusInternalServerError, "failed to load series")
+}
+
+// apiSetOverrides replaces the counts and release dates pinned for a series
+// on each storefront
+func (a *App) apiSetOverrides(w http.ResponseWriter, r *http.Request) {
+	info, ok := a.apiWatchedSeries(w, r)
+	if !ok {
+		return
+	}
+	var input struct {
+		Audible *apiOverride `json:"audible"`
+		Amazon  *apiOverride `json:"amazon"`
+	}
+	if !decodeAPIInput(w, r, &input) {
+		return
+	}
+	audible, ok := input.Audible.toOverride(w, database.ProviderAudible)
+	if !ok {
+		return
+	}
+	amazon, ok := input.Amazon.toOverride(w, database.ProviderAmazon)
+	if !ok {
+		return
+	}
+
+	if err := a.DB.SetSeriesOverrides(info.ID, audible, amazon); err != nil {
+		log.Printf("error setting overrides for series %d: %v", info.ID, err)
+		writeAPIError(w, http.StatusInternalServerError, "failed to set overrides")
+		return
+	}
+	log.Printf("set overrides for %s: audible [%s], amazon [%s]", info.Title, audible, amazon)
+	a.writeBack(info.ID, "")
+	a.Events.Publish(events.TypeRefresh, nil)
+	a.writeAPISeries(w, r, http.StatusOK, info.ID)
+}
+
+// apiDeleteSeries removes a series from the user's watchlist. The series
+// and its data are kept for anyone else watching it.
+func (a *App) apiDeleteSeries(w http.ResponseWriter, r *http.Request) {
+	info, ok := a.apiWatchedSeries(w, r)
+	if !ok {
+		return
+	}
+	user, ok := auth.GetUserFromContext(r)
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if err := a.DB.UnwatchSeries(user.ID, info.ID); err != nil {
+		log.Printf("error removing series %d from %s's watchlist: %v", info.ID, user.Username, err)
+		writeAPIError(w, http.StatusInternalServerError, "failed to remove series")
+		return
+	}
+	log.Printf("removed series %s from %s's watchlist", info.Title, user.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiListBooks returns a series' stored books, optionally for one provider
+func (a *App) apiListBooks(w http.ResponseWriter, r *http.Request) {
+	info, ok := a.apiWatchedSeries(w, r)
+	if !ok {
+		return
+	}
+	provider := r.URL.Query().Get("provider")
+	if provider != "" && provider != database.ProviderAudible && provider != database.ProviderAmazon {
+		writeAPIError(w, http.StatusBadRequest, "provider must be audible or amazon")
+		return
+	}
+
+	all, err := a.DB.GetSeriesBooks(info.ID)
+	if err != nil {
+		log.Printf("error fetching books for series %d: %v", info.ID, err)
+		writeAPIError(w, http.StatusInternalServerError, "failed to load books")
+		return
+	}
+	books := []database.Book{}
+	for _, book := range all {
+		if provider == "" || book.Provider == provider {
+			books = append(books, book)
+		}
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]interface{}{"books": books})
+}
+
+// apiListJobs returns a series' most recent scrape jobs, newest first
+func (a *App) apiListJobs(w http.ResponseWriter, r *http.Request) {
+	info, ok := a.apiWatchedSeries(w, r)
+	if !ok {
+		return
+	}
+	jobs, err := a.DB.GetSeriesScrapeJobs(info.ID, maxJobsListed)
+	if err != nil {
+		log.Printf("error fetching scrape jobs for series %d: %v", info.ID, err)
+		writeAPIError(w, http.StatusInternalServerError, "failed to load scrape jobs")
+		return
+	}
+	if jobs == nil {
+		jobs = []database.ScrapeJob{}
+	}
+	writeAPIJSON(w, http.StatusOK, map[string]interface{}{"jobs": jobs})
+}
+
+// apiQueueJobs queues a scrape of a series from one provider, or from every
+// provider it's tracked on
+func (a *App) apiQueueJobs(w http.ResponseWriter, r *http.Request) {
+	info, ok := a.apiWatchedSeries(w, r)
+	if !ok {
+		return
+	}
+	var input struct {
+		Provider string `json:"provider"`
+	}
+	if !decodeAPIInput(w, r, &input) {
+		return
+	}
+
+	var tracked []string
+	if info.AudibleID != "" {
+		tracked = append(tracked, database.ProviderAudible)
+	}
+	if info.AmazonASIN != "" {
+		tracked = append(tracked, database.ProviderAmazon)
+	}
+	providers := tracked
+	if input.Provider != "" {
+		if !slices.Contains(tracked, input.Provider) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("series isn't tracked on %q", input.Provider))
+			return
+		}
+		providers = []string{input.Provider}
+	}
+	if a.BackgroundScraper == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "background scraper not available")
+		return
+	}
+
+	for _, provider := range providers {
+		if err := a.BackgroundScraper.QueueSeriesUpdate(info.ID, provider); err != nil {
+			log.Printf("error queuing %s scrape for series %d: %v", provider, info.ID, err)
+			writeAPIError(w, http.StatusInternalServerError, "failed to queue scrape")
+			return
+		}
+	}
+	writeAPIJSON(w, http.StatusAccepted, map[string]interface{}{"queued": providers})
+}
@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/auth"
+	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/events"
+	"github.com/michaeldvinci/syllabus/internal/models"
+	"github.com/michaeldvinci/syllabus/internal/scraper"
+)
+
+// openAPI is the parsed spec, with helpers to check responses against it
+type openAPI struct {
+	doc map[string]interface{}
+}
+
+func loadOpenAPI(t *testing.T) *openAPI {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := json.Unmarshal(OpenAPISpec, &doc); err != nil {
+		t.Fatalf("parse openapi.json: %v", err)
+	}
+	return &openAPI{doc: doc}
+}
+
+// resolve follows a local $ref such as "#/components/schemas/Series"
+func (s *openAPI) resolve(node map[string]interface{}) map[string]interface{} {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node
+		}
+		var target interface{} = s.doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			target = target.(map[string]interface{})[part]
+		}
+		node = target.(map[string]interface{})
+	}
+}
+
+// operations returns "METHOD /path" for every operation in the spec
+func (s *openAPI) operations() []string {
+	var ops []string
+	for path, item := range s.doc["paths"].(map[string]interface{}) {
+		for method := range item.(map[string]interface{}) {
+			if method != "parameters" {
+				ops = append(ops, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// response returns the documented response for an operation and status
+func (s *openAPI) response(method, path string, status int) (map[string]interface{}, bool) {
+	item, ok := s.doc["paths"].(map[string]interface{})[path].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	op, ok := item[strings.ToLower(method)].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	resp, ok := op["responses"].(map[string]interface{})[fmt.Sprint(status)].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return s.resolve(resp), true
+}
+
+// validate checks a decoded JSON value against a schema, returning what
+// doesn't match. It covers the parts of JSON Schema the spec uses.
+func (s *openAPI) validate(schema map[string]interface{}, value interface{}, at string) []string {
+	schema = s.resolve(schema)
+	var problems []string
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{at + ": not an object"}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing %q", at, name))
+			}
+		}
+		for name, v := range obj {
+			prop, ok := props[name].(map[string]interface{})
+			if !ok {
+				if schema["additionalProperties"] == false {
+					problems = append(problems, fmt.Sprintf("%s: undocumented property %q", at, name))
+				}
+				continue
+			}
+			problems = append(problems, s.validate(prop, v, at+"."+name)...)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{at + ": not an array"}
+		}
+		for i, item := range items {
+			problems = append(problems, s.validate(schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []string{at + ": not a string"}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q isn't a date-time", at, str))
+			}
+		}
+		if schema["format"] == "date" {
+			if _, err := time.Parse(time.DateOnly, str); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q isn't a date", at, str))
+			}
+		}
+		if enum, ok := schema["enum"].([]interface{}); ok && !slices.Contains(enum, interface{}(str)) {
+			problems = append(problems, fmt.Sprintf("%s: %q isn't one of %v", at, str, enum))
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return []string{at + ": not an integer"}
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			problems = append(problems, fmt.Sprintf("%s: %v is below the minimum %v", at, n, min))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{at + ": not a boolean"}
+		}
+	}
+	return problems
+}
+
+// newTestAPI serves the v1 API backed by a temporary database. Permission
+// checks use the role of *user, which tests can swap.
+func newTestAPI(t *testing.T, user **auth.User) (*App, http.Handler) {
+	t.Helper()
+	db, err := database.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if u := *user; u != nil {
+		_, err := db.Exec(`INSERT INTO users (id, username, password_hash, ical_token) VALUES (?, ?, '', ?)`,
+			u.ID, u.Username, "ical-"+u.ID)
+		if err != nil {
+			t.Fatalf("add user: %v", err)
+		}
+	}
+	svc := database.NewService(db)
+
+	app := &App{
+		DB:                svc,
+		Events:            events.NewHub(0, 0),
+		BackgroundScraper: scraper.NewBackgroundScraper(map[string]models.Provider{}, svc, 1, time.Minute),
+	}
+	mux := http.NewServeMux()
+	app.RegisterAPIv1(mux, func(perm auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !(*user).Can(perm) {
+				http.Error(w, `{"error":"permission denied"}`, http.StatusForbidden)
+				return
+			}
+			next(w, r.WithContext(context.WithValue(r.Context(), auth.UserContextKey, *user)))
+		}
+	})
+	return app, mux
+}
+
+func TestAPIv1MatchesOpenAPI(t *testing.T) {
+	spec := loadOpenAPI(t)
+	editor := &auth.User{ID: "editor-id", Username: "erin", Role: auth.RoleEditor}
+	viewer := &auth.User{ID: "editor-id", Username: "erin", Role: auth.RoleViewer}
+	user := editor
+	app, handler := newTestAPI(t, &user)
+
+	// Every route is documented and every documented operation is served
+	var routes []string
+	for _, route := range app.apiV1Routes() {
+		routes = append(routes, route.Method+" "+route.Pattern)
+	}
+	sort.Strings(routes)
+	if ops := spec.operations(); !slices.Equal(routes, ops) {
+		t.Fatalf("routes don't match the spec\nroutes: %v\n  spec: %v", routes, ops)
+	}
+
+	tests := []struct {
+		name   string
+		as     *auth.User
+		method string
+		op     string // Path as written in the spec
+		path   string
+		body   string
+		want   int
+	}{
+		{"create", editor, "POST", "/api/v1/series", "/api/v1/series",
+			`{"title": "The Example Saga", "audible_url": "https://www.audible.com/series/B0AUDSERIE"}`, http.StatusCreated},
+		{"create another", editor, "POST", "/api/v1/series", "/api/v1/series",
+			`{"title": "The Example Chronicle", "amazon_url": "https://www.amazon.com/dp/B0AMZSERIE"}`, http.StatusCreated},
+		{"create again", editor, "POST", "/api/v1/series", "/api/v1/series",
+			`{"title": "The Example Saga", "audible_url": "https://www.audible.com/series/B0AUDSERIE"}`, http.StatusOK},
+		{"create without title", editor, "POST", "/api/v1/series", "/api/v1/series",
+			`{"audible_url": "https://www.audible.com/series/B0AUDSERIE"}`, http.StatusBadRequest},
+		{"create with unknown field", editor, "POST", "/api/v1/series", "/api/v1/series",
+			`{"title": "x", "audible": "https://www.audible.com/series/B0AUDSERIE"}`, http.StatusBadRequest},
+		{"create as viewer", viewer, "POST", "/api/v1/series", "/api/v1/series",
+			`{"title": "x", "amazon_url": "https://www.amazon.com/dp/B0AMZSERIE"}`, http.StatusForbidden},
+		{"list", viewer, "GET", "/api/v1/series", "/api/v1/series", "", http.StatusOK},
+		{"get", viewer, "GET", "/api/v1/series/{id}", "/api/v1/series/1", "", http.StatusOK},
+		{"get unknown", viewer, "GET", "/api/v1/series/{id}", "/api/v1/series/99", "", http.StatusNotFound},
+		{"rename", editor, "PATCH", "/api/v1/series/{id}", "/api/v1/series/1", `{"title": "The Example Saga (Unabridged)"}`, http.StatusOK},
+		{"rename onto another", editor, "PATCH", "/api/v1/series/{id}", "/api/v1/series/1", `{"title": "The Example Chronicle"}`, http.StatusConflict},
+		{"bad url", editor, "PATCH", "/api/v1/series/{id}", "/api/v1/series/1", `{"amazon_url": "https://example.com"}`, http.StatusBadRequest},
+		{"drop the only storefront", editor, "PATCH", "/api/v1/series/{id}", "/api/v1/series/2", `{"amazon_url": ""}`, http.StatusBadRequest},
+		{"pin", editor, "PUT", "/api/v1/series/{id}/overrides", "/api/v1/series/1/overrides",
+			`{"audible": {"count": 5, "next_date": "2099-06-15"}, "amazon": {"latest_date": "2024-01-10"}}`, http.StatusOK},
+		{"pin a bad date", editor, "PUT", "/api/v1/series/{id}/overrides", "/api/v1/series/1/overrides",
+			`{"audible": {"next_date": "June 15"}}`, http.StatusBadRequest},
+		{"pin a negative count", editor, "PUT", "/api/v1/series/{id}/overrides", "/api/v1/series/1/overrides",
+			`{"amazon": {"count": -1}}`, http.StatusBadRequest},
+		{"pin as viewer", viewer, "PUT", "/api/v1/series/{id}/overrides", "/api/v1/series/1/overrides", `{}`, http.StatusForbidden},
+		{"get pinned", viewer, "GET", "/api/v1/series/{id}", "/api/v1/series/1", "", http.StatusOK},
+		{"books", viewer, "GET", "/api/v1/series/{id}/books", "/api/v1/series/1/books", "", http.StatusOK},
+		{"books from one provider", viewer, "GET", "/api/v1/series/{id}/books", "/api/v1/series/1/books?provider=amazon", "", http.StatusOK},
+		{"books from an unknown provider", viewer, "GET", "/api/v1/series/{id}/books", "/api/v1/series/1/books?provider=kobo", "", http.StatusBadRequest},
+		{"queue", editor, "POST", "/api/v1/series/{id}/jobs", "/api/v1/series/1/jobs", "", http.StatusAccepted},
+		{"queue untracked provider", editor, "POST", "/api/v1/series/{id}/jobs", "/api/v1/series/1/jobs", `{"provider": "amazon"}`, http.StatusBadRequest},
+		{"jobs", viewer, "GET", "/api/v1/series/{id}/jobs", "/api/v1/series/1/jobs", "", http.StatusOK},
+		{"delete", editor, "DELETE", "/api/v1/series/{id}", "/api/v1/series/2", "", http.StatusNoContent},
+		{"get deleted", viewer, "GET", "/api/v1/series/{id}", "/api/v1/series/2", "", http.StatusNotFound},
+	}
+
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user = tt.as
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			covered[tt.method+" "+tt.op] = true
+
+			resp, ok := spec.response(tt.method, tt.op, rec.Code)
+			if !ok {
+				t.Fatalf("%s %s doesn't document status %d", tt.method, tt.op, rec.Code)
+			}
+			content, ok := resp["content"].(map[string]interface{})
+			if !ok {
+				if rec.Body.Len() != 0 {
+					t.Errorf("undocumented body: %s", rec.Body.String())
+				}
+				return
+			}
+			var body interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response isn't JSON: %v: %s", err, rec.Body.String())
+			}
+			schema := content["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+			for _, problem := range spec.validate(schema, body, "body") {
+				t.Error(problem)
+			}
+		})
+
+		// Give the first series some books once it exists
+		if tt.name == "create" {
+			if err := app.DB.UpdateSeriesBooks(1, database.ProviderAudible, models.SeriesInfo{
+				AudibleCount: 2,
+				AudibleBooks: []models.Book{
+					{Title: "Book One", Position: 1, ASIN: "B0BOOK0001", ReleaseDate: &time.Time{}},
+					{Title: "Book Two", Position: 2, ASIN: "B0BOOK0002"},
+				},
+			}); err != nil {
+				t.Fatalf("UpdateSeriesBooks: %v", err)
+			}
+		}
+	}
+	for _, op := range spec.operations() {
+		if !covered[op] {
+			t.Errorf("%s isn't exercised", op)
+		}
+	}
+}
+
+func TestAPIv1Errors(t *testing.T) {
+	user := &auth.User{ID: "editor-id", Username: "erin", Role: auth.RoleEditor}
+	_, handler := newTestAPI(t, &user)
+
+	tests := []struct {
+		method, path string
+		want         int
+		allow        string
+	}{
+		{"PUT", "/api/v1/series", http.StatusMethodNotAllowed, "GET, POST"},
+		{"POST", "/api/v1/series/1", http.StatusMethodNotAllowed, "DELETE, GET, PATCH"},
+		{"GET", "/api/v1/authors", http.StatusNotFound, ""},
+		{"GET", "/api/v1/series/abc", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		var body map[string]string
+		if rec.Code != tt.want || json.Unmarshal(rec.Body.Bytes(), &body) != nil || body["error"] == "" {
+			t.Errorf("%s %s = %d %q, want %d with a JSON error", tt.method, tt.path, rec.Code, rec.Body.String(), tt.want)
+		}
+		if rec.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s Allow = %q, want %q", tt.method, tt.path, rec.Header().Get("Allow"), tt.allow)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK || !json.Valid(rec.Body.Bytes()) {
+		t.Errorf("GET /api/v1/openapi.json = %d", rec.Code)
+	}
+}
+
+func TestUpdateSeriesRescrapesChangedListing(t *testing.T) {
+	user := &auth.User{ID: "editor-id", Username: "erin", Role: auth.RoleEditor}
+	app, handler := newTestAPI(t, &user)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	if rec := do("POST", "/api/v1/series", `{"title": "The Example Saga", "audible_url": "https://www.audible.com/series/B0AUDSERIE", "amazon_url": "https://www.amazon.com/dp/B0AMZSERIE"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// A rename doesn't scrape anything again
+	if rec := do("PATCH", "/api/v1/series/1", `{"title": "The Example Saga (Unabridged)"}`); rec.Code != http.StatusOK {
+		t.Fatalf("rename = %d: %s", rec.Code, rec.Body.String())
+	}
+	jobs, err := app.DB.GetSeriesScrapeJobs(1, maxJobsListed)
+	if err != nil || len(jobs) != 2 {
+		t.Fatalf("after rename got %d jobs (%v), want the 2 from creating", len(jobs), err)
+	}
+
+	// A worker is partway through scraping the old Audible listing
+	running, err := app.DB.ClaimScrapeJob("worker-1", time.Minute, database.ProviderAmazon)
+	if err != nil || running == nil {
+		t.Fatalf("ClaimScrapeJob = %v, %v", running, err)
+	}
+
+	// A new Audible listing replaces the running Audible scrape
+	rec := do("PATCH", "/api/v1/series/1", `{"audible_url": "https://www.audible.com/series/The-Example-Saga-Audiobooks/B0AUDOTHER?ref=a"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("change url = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The old listing's result arrives after the books were cleared and is dropped
+	stale := models.SeriesInfo{AudibleCount: 1, AudibleBooks: []models.Book{{Title: "Old Listing Book", Position: 1}}}
+	if err := app.DB.CompleteScrapeJob(*running, "worker-1", stale); !errors.Is(err, database.ErrLeaseLost) {
+		t.Errorf("CompleteScrapeJob after the listing changed = %v, want ErrLeaseLost", err)
+	}
+	books, err := app.DB.GetSeriesBooks(1)
+	if err != nil {
+		t.Fatalf("GetSeriesBooks: %v", err)
+	}
+	if len(books) != 0 {
+		t.Errorf("books = %+v, want none until the new listing is scraped", books)
+	}
+	var series apiSeries
+	if err := json.Unmarshal(rec.Body.Bytes(), &series); err != nil || series.Audible.ID != "B0AUDOTHER" || series.Amazon.ID != "B0AMZSERIE" {
+		t.Errorf("updated series = %+v (%v)", series, err)
+	}
+
+	jobs, err = app.DB.GetSeriesScrapeJobs(1, maxJobsListed)
+	if err != nil {
+		t.Fatalf("GetSeriesScrapeJobs: %v", err)
+	}
+	active := map[string]int{}
+	cancelled := 0
+	for _, job := range jobs {
+		switch job.Status {
+		case database.JobStatusPending, database.JobStatusRunning:
+			active[job.Provider]++
+		case database.JobStatusFailed:
+			cancelled++
+		}
+	}
+	if active[database.ProviderAudible] != 1 || active[database.ProviderAmazon] != 1 || cancelled != 1 {
+		t.Errorf("jobs = %+v, want the old audible scrape cancelled and one queued per provider", jobs)
+	}
+}
+
+func TestSetOverrides(t *testing.T) {
+	user := &auth.User{ID: "editor-id", Username: "erin", Role: auth.RoleEditor}
+	app, handler := newTestAPI(t, &user)
+
+	do := func(method, path, body string) apiSeries {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		if rec.Code >= 300 {
+			t.Fatalf("%s %s = %d: %s", method, path, rec.Code, rec.Body.String())
+		}
+		var series apiSeries
+		if err := json.Unmarshal(rec.Body.Bytes(), &series); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		return series
+	}
+	do("POST", "/api/v1/series", `{"title": "The Example Saga", "audible_url": "https://www.audible.com/series/B0AUDSERIE"}`)
+	next := time.Date(2099, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := app.DB.UpdateSeriesBooks(1, database.ProviderAudible, models.SeriesInfo{
+		AudibleCount: 2,
+		AudibleBooks: []models.Book{
+			{Title: "Book One", Position: 1},
+			{Title: "Book Two", Position: 2, ReleaseDate: &next, IsPreorder: true},
+		},
+	}); err != nil {
+		t.Fatalf("UpdateSeriesBooks: %v", err)
+	}
+
+	// Pinned values replace what was scraped and are reported as overrides
+	do("PUT", "/api/v1/series/1/overrides", `{"audible": {"count": 3, "next_date": "2099-06-15"}}`)
+	series := do("GET", "/api/v1/series/1", "")
+	if series.Audible.Count != 3 || series.Audible.NextDate == nil || series.Audible.NextDate.Format(time.DateOnly) != "2099-06-15" {
+		t.Errorf("audible = count %d, next %v; want the pinned values", series.Audible.Count, series.Audible.NextDate)
+	}
+	if o := series.Audible.Overrides; o == nil || o.Count == nil || *o.Count != 3 || o.NextDate != "2099-06-15" || o.LatestDate != "" {
+		t.Errorf("audible overrides = %+v", o)
+	}
+	if series.Amazon.Overrides != nil {
+		t.Errorf("amazon overrides = %+v, want none", series.Amazon.Overrides)
+	}
+
+	// Clearing them brings back the scraped values
+	series = do("PUT", "/api/v1/series/1/overrides", `{}`)
+	if series.Audible.Count != 2 || series.Audible.NextDate == nil || !series.Audible.NextDate.Equal(next) || series.Audible.Overrides != nil {
+		t.Errorf("audible = %+v, want the scraped values", series.Audible)
+	}
+}
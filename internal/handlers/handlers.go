@@ -2,18 +2,23 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
-	"regexp"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/michaeldvinci/syllabus/internal/auth"
 	"github.com/michaeldvinci/syllabus/internal/cache"
+	"github.com/michaeldvinci/syllabus/internal/configsync"
 	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/events"
 	"github.com/michaeldvinci/syllabus/internal/models"
 	"github.com/michaeldvinci/syllabus/internal/scraper"
 	"github.com/michaeldvinci/syllabus/internal/utils"
@@ -25,9 +30,9 @@ type App struct {
 	DB                *database.Service
 	Cache             *cache.Cache
 	Data              []models.SeriesIDs
-	RefreshChan       chan bool
-	ScraperUpdateCh   <-chan scraper.SeriesUpdate // Channel for scraper updates
+	Events            *events.Hub                 // Server-sent events for connected clients
 	BackgroundScraper *scraper.BackgroundScraper  // Reference to background scraper
+	ConfigWriter      *configsync.Writer          // Writes UI changes back to books.yaml; nil unless write_back is set
 	Settings          models.Settings             // Application settings from config
 	mu                sync.RWMutex                // Protect Data updates
 
@@ -39,6 +44,7 @@ type App struct {
 
 // Row represents a table row in the HTML template
 type Row struct {
+	ID            int
 	Title         string
 	AudibleCount  int
 	AudibleLatest string
@@ -48,6 +54,55 @@ type Row struct {
 	AmazonNext    string
 	AudibleURL    string
 	AmazonURL     string
+	AudibleBooks  []BookRow
+	AmazonBooks   []BookRow
+	AudibleError  *ErrorRow
+	AmazonError   *ErrorRow
+	AudibleStale  string // Date of the first failed refresh, empty while data is fresh
+	AmazonStale   string
+	AudiblePinned string // Values overridden by hand, e.g. "count, next date"; empty when none
+	AmazonPinned  string
+	Audio         ProgressRow // The requesting user's progress, measured against Audible
+	Ebook         ProgressRow // The requesting user's progress, measured against Amazon
+}
+
+// ProgressRow is a user's progress through one format of a row's series
+type ProgressRow struct {
+	Verb       string // "listened" or "read"
+	Owned      int    // Owned through book N
+	Finished   int    // Listened to or read through book N
+	Unfinished int    // Released books owned but not yet finished
+	NotOwned   int    // Released books past the last one owned
+}
+
+// ErrorRow explains why a provider has no fresh data for a row
+type ErrorRow struct {
+	Label   string
+	Message string
+}
+
+// BookRow represents a single book in a row's expandable series listing
+type BookRow struct {
+	Position int
+	Title    string
+	Date     string
+	Preorder bool
+}
+
+// EventRow represents one entry in a series' release timeline
+type EventRow struct {
+	When     string
+	Provider string
+	Type     string // database.Event* constant, used as a CSS class
+	Position int
+	Book     string
+	Detail   string
+}
+
+// SeriesPage represents the data for the series detail template
+type SeriesPage struct {
+	Series Row
+	Events []EventRow
 }
 
 // Page represents the complete page data for the HTML template
@@ -65,29 +120,10 @@ type Page struct {
 func (a *App) HandleIndex(w http.ResponseWriter, r *http.Request) {
 	var rows []Row
 
-	infos := a.collectAll()
+	infos := a.collectWatched(r)
+	progress := a.readingProgress(r)
 	for _, info := range infos {
-		audibleLatest := formatDateOnly(info.AudibleLatestDate)
-		audibleNext := formatDateOnly(info.AudibleNextDate)
-		audURL := ""
-		if info.AudibleID != "" {
-			audURL = fmt.Sprintf("https://www.audible.com/series/%s", info.AudibleID)
-		}
-		amzURL := ""
-		if info.AmazonASIN != "" {
-			amzURL = fmt.Sprintf("https://www.amazon.com/dp/%s", info.AmazonASIN)
-		}
-		rows = append(rows, Row{
-			Title:         info.Title,
-			AudibleCount:  info.AudibleCount,
-			AudibleLatest: audibleLatest,
-			AudibleNext:   audibleNext,
-			AmazonCount:   info.AmazonCount,
-			AmazonLatest:  formatDateOnly(info.AmazonLatestDate),
-			AmazonNext:    formatDateOnly(info.AmazonNextDate),
-			AudibleURL:    audURL,
-			AmazonURL:     amzURL,
-		})
+		rows = append(rows, withProgress(toRow(info), info, progress[info.ID]))
 	}
 
 	// Get current user from context if available
@@ -135,13 +171,265 @@ func (a *App) HandleIndex(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// toRow converts a series for display in the templates
+func toRow(info models.SeriesInfo) Row {
+	audURL := ""
+	if info.AudibleID != "" {
+		audURL = fmt.Sprintf("https://www.audible.com/series/%s", info.AudibleID)
+	}
+	amzURL := ""
+	if info.AmazonASIN != "" {
+		amzURL = fmt.Sprintf("https://www.amazon.com/dp/%s", info.AmazonASIN)
+	}
+	return Row{
+		ID:            info.ID,
+		Title:         info.Title,
+		AudibleCount:  info.AudibleCount,
+		AudibleLatest: formatDateOnly(info.AudibleLatestDate),
+		AudibleNext:   formatDateOnly(info.AudibleNextDate),
+		AmazonCount:   info.AmazonCount,
+		AmazonLatest:  formatDateOnly(info.AmazonLatestDate),
+		AmazonNext:    formatDateOnly(info.AmazonNextDate),
+		AudibleURL:    audURL,
+		AmazonURL:     amzURL,
+		AudibleBooks:  toBookRows(info.AudibleBooks),
+		AmazonBooks:   toBookRows(info.AmazonBooks),
+		AudibleError:  toErrorRow(info.AudibleError),
+		AmazonError:   toErrorRow(info.AmazonError),
+		AudibleStale:  formatStaleSince(info.AudibleStaleSince),
+		AmazonStale:   formatStaleSince(info.AmazonStaleSince),
+		AudiblePinned: describeOverride(info.AudibleOverride),
+		AmazonPinned:  describeOverride(info.AmazonOverride),
+	}
+}
+
+// describeOverride lists which values are pinned for a provider
+func describeOverride(o models.Override) string {
+	var pinned []string
+	if o.Count != nil {
+		pinned = append(pinned, "count")
+	}
+	if o.LatestDate != nil {
+		pinned = append(pinned, "latest date")
+	}
+	if o.NextDate != nil {
+		pinned = append(pinned, "next date")
+	}
+	return strings.Join(pinned, ", ")
+}
+
+// withProgress fills in a row's reading progress from the user's recorded
+// progress for its series
+func withProgress(row Row, info models.SeriesInfo, progress map[string]database.ReadingProgress) Row {
+	audible := releasedThrough(info.AudibleCount, info.AudibleBooks, info.AudibleOverride.Count != nil)
+	amazon := releasedThrough(info.AmazonCount, info.AmazonBooks, info.AmazonOverride.Count != nil)
+	row.Audio = toProgressRow(progress[database.FormatAudio], "listened", audible)
+	row.Ebook = toProgressRow(progress[database.FormatEbook], "read", amazon)
+	return row
+}
+
+func toProgressRow(p database.ReadingProgress, verb string, released int) ProgressRow {
+	row := ProgressRow{Verb: verb, Owned: p.OwnedThrough, Finished: p.FinishedThrough}
+	if row.Owned == 0 && row.Finished == 0 {
+		return row // Nothing recorded for this format
+	}
+	if owned := min(row.Owned, released); owned > row.Finished {
+		row.Unfinished = owned - row.Finished
+	}
+	if released > row.Owned && row.Owned > 0 {
+		row.NotOwned = released - row.Owned
+	}
+	return row
+}
+
+// releasedThrough is the series position of the last book already out, the
+// basis owned_through and finished_through are recorded on. Preorders and
+// unnumbered books such as novellas don't move it. A pinned count, or a
+// listing without positions, falls back to the count less any preorders.
+func releasedThrough(count int, books []models.Book, pinned bool) int {
+	through, preorders := 0, 0
+	for _, b := range books {
+		if b.IsPreorder {
+			preorders++
+		} else if b.Position > through {
+			through = b.Position
+		}
+	}
+	if pinned || through == 0 {
+		return max(count-preorders, 0)
+	}
+	return through
+}
+
+// readingProgress returns the requesting user's reading progress by series ID
+func (a *App) readingProgress(r *http.Request) map[int]map[string]database.ReadingProgress {
+	user, ok := auth.GetUserFromContext(r)
+	if !ok {
+		return nil
+	}
+	progress, err := a.DB.GetReadingProgress(user.ID)
+	if err != nil {
+		log.Printf("error fetching reading progress for %s: %v", user.Username, err)
+		return nil
+	}
+	return progress
+}
+
+// HandleGetProgress returns the requesting user's reading progress as JSON
+func (a *App) HandleGetProgress(w http.ResponseWriter, r *http.Request) {
+	list := []database.ReadingProgress{}
+	for _, formats := range a.readingProgress(r) {
+		for _, p := range formats {
+			list = append(list, p)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].SeriesID != list[j].SeriesID {
+			return list[i].SeriesID < list[j].SeriesID
+		}
+		return list[i].Format < list[j].Format
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleSetProgress records how far through one format of a series the
+// requesting user owns and has finished
+func (a *App) HandleSetProgress(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req database.ReadingProgress
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Format != database.FormatAudio && req.Format != database.FormatEbook {
+		http.Error(w, "Format must be audio or ebook", http.StatusBadRequest)
+		return
+	}
+	if req.OwnedThrough < 0 || req.FinishedThrough < 0 {
+		http.Error(w, "Book numbers must not be negative", http.StatusBadRequest)
+		return
+	}
+	if !a.isWatched(r, req.SeriesID) {
+		http.Error(w, "Series not found on your watchlist", http.StatusNotFound)
+		return
+	}
+
+	if err := a.DB.SetReadingProgress(user.ID, req); err != nil {
+		log.Printf("error saving reading progress for %s: %v", user.Username, err)
+		http.Error(w, "Failed to save reading progress", http.StatusInternalServerError)
+		return
+	}
+	req.UpdatedAt = time.Now().UTC()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"progress": req,
+	})
+}
+
 // HandleAPI serves the JSON API endpoint
 func (a *App) HandleAPI(w http.ResponseWriter, r *http.Request) {
-	infos := a.collectAll()
+	infos := a.collectWatched(r)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(infos)
 }
 
+// seriesIDFromPath parses the {id} path segment, writing a 404 when it isn't a series ID
+func seriesIDFromPath(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.NotFound(w, r)
+		return 0, false
+	}
+	return id, true
+}
+
+// HandleSeriesPage serves the detail page for one series with its release history
+func (a *App) HandleSeriesPage(w http.ResponseWriter, r *http.Request) {
+	id, ok := seriesIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var series *models.SeriesInfo
+	infos := a.collectWatched(r)
+	for i := range infos {
+		if infos[i].ID == id {
+			series = &infos[i]
+			break
+		}
+	}
+	if series == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	events, err := a.DB.GetReleaseEvents(id)
+	if err != nil {
+		log.Printf("error fetching release events for series %d: %v", id, err)
+		http.Error(w, "Failed to load release history", http.StatusInternalServerError)
+		return
+	}
+
+	tpl, err := template.New("series").Parse(SeriesHTML)
+	if err != nil {
+		log.Printf("parse template: %v", err)
+		http.Error(w, "template error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tpl.Execute(w, SeriesPage{
+		Series: withProgress(toRow(*series), *series, a.readingProgress(r)[id]),
+		Events: toEventRows(events),
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// HandleSeriesHistory returns a series' release events as JSON, newest first
+func (a *App) HandleSeriesHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := seriesIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	series, err := a.DB.GetSeriesByID(id)
+	if err != nil {
+		log.Printf("error fetching series %d: %v", id, err)
+		http.Error(w, "Failed to load series", http.StatusInternalServerError)
+		return
+	}
+	if series == nil || !a.isWatched(r, series.ID) {
+		http.NotFound(w, r)
+		return
+	}
+
+	events, err := a.DB.GetReleaseEvents(id)
+	if err != nil {
+		log.Printf("error fetching release events for series %d: %v", id, err)
+		http.Error(w, "Failed to load release history", http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []database.ReleaseEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"series_id": series.ID,
+		"title":     series.Title,
+		"events":    events,
+	})
+}
+
 // HandleScrapeStatus returns the current scrape job status
 func (a *App) HandleScrapeStatus(w http.ResponseWriter, r *http.Request) {
 	// Get count of active scrape jobs (running or pending)
@@ -152,21 +440,69 @@ func (a *App) HandleScrapeStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	activeJobs := 0
+	active := []database.ScrapeJob{}
 	for _, job := range jobs {
 		if job.Status == "running" || job.Status == "pending" {
 			activeJobs++
+			active = append(active, job)
 		}
 	}
 
+	// Providers paused by the CAPTCHA circuit breaker, with when they resume
+	pausedUntil := map[string]time.Time{}
+	if a.BackgroundScraper != nil {
+		pausedUntil = a.BackgroundScraper.PausedUntil()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]int{
-		"activeJobs": activeJobs,
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"activeJobs":  activeJobs,
+		"jobs":        active,
+		"pausedUntil": pausedUntil,
 	})
 }
 
+// CancelScrapeJobRequest represents the request to cancel a scrape job
+type CancelScrapeJobRequest struct {
+	JobID int `json:"jobId"`
+}
+
+// HandleCancelScrapeJob cancels a pending or running scrape job, aborting any
+// requests it has in flight
+func (a *App) HandleCancelScrapeJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CancelScrapeJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.JobID <= 0 {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if a.BackgroundScraper == nil {
+		http.Error(w, "Background scraper not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	cancelled, err := a.BackgroundScraper.CancelJob(req.JobID)
+	if err != nil {
+		log.Printf("error cancelling scrape job %d: %v", req.JobID, err)
+		http.Error(w, "Failed to cancel scrape job", http.StatusInternalServerError)
+		return
+	}
+	if !cancelled {
+		http.Error(w, "Scrape job not found or already finished", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "Cancelled scrape job %d", req.JobID)
+}
+
 // HandleICal serves the iCal export endpoint
 func (a *App) HandleICal(w http.ResponseWriter, r *http.Request) {
-	infos := a.collectAll()
+	infos := a.collectWatched(r)
 	icalContent := utils.GenerateICal(infos)
 
 	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
@@ -180,8 +516,8 @@ func (a *App) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get all series for re-scraping
-	stats, err := a.DB.GetAllSeriesStats()
+	// Get every watched series for re-scraping
+	stats, err := a.DB.GetWatchedSeriesStats()
 	if err != nil {
 		log.Printf("error fetching series for refresh: %v", err)
 		http.Error(w, "Failed to fetch series data", http.StatusInternalServerError)
@@ -198,11 +534,6 @@ func (a *App) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	log.Printf("clearing all book data before refresh to prevent corruption")
-	if err := a.DB.ClearAllBookData(); err != nil {
-		log.Printf("warning: failed to clear book data: %v", err)
-	}
-
 	if a.BackgroundScraper != nil {
 		if err := a.BackgroundScraper.QueueAllSeriesUpdate(); err != nil {
 			log.Printf("error queuing refresh jobs: %v", err)
@@ -210,10 +541,7 @@ func (a *App) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		log.Printf("queued refresh jobs for all series")
-		select {
-		case a.RefreshChan <- true:
-		default:
-		}
+		a.Events.Publish(events.TypeRefresh, nil)
 	} else {
 		log.Printf("background scraper not available")
 		http.Error(w, "Background scraper not available", http.StatusInternalServerError)
@@ -259,152 +587,250 @@ func (a *App) HandleAddSeries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract IDs from URLs
-	audibleID := ""
-	amazonASIN := ""
-
-	if req.Audible != "" {
-		// Extract Audible ID from URL
-		if matches := regexp.MustCompile(`/series/[^/]*?([A-Z0-9]{10})`).FindStringSubmatch(req.Audible); len(matches) > 1 {
-			audibleID = matches[1]
-		}
-	}
-
-	if req.Amazon != "" {
-		// Extract Amazon ASIN from URL
-		if matches := regexp.MustCompile(`/dp/([A-Z0-9]{10})`).FindStringSubmatch(req.Amazon); len(matches) > 1 {
-			amazonASIN = matches[1]
-		}
+	user, ok := auth.GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
 	}
 
-	// Add series to database
-	series, err := a.DB.UpsertSeries(req.Title, audibleID, req.Audible, amazonASIN)
+	audibleID, amazonASIN := utils.ExtractAudibleSeriesID(req.Audible), utils.ExtractAmazonASIN(req.Amazon)
+	series, _, err := a.addSeries(user, req.Title, audibleID, req.Audible, amazonASIN, req.Amazon)
 	if err != nil {
 		log.Printf("error adding series %s: %v", req.Title, err)
 		http.Error(w, "Failed to add series", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("added new series: %s (ID: %d)", req.Title, series.ID)
+	// Trigger data refresh
+	a.Events.Publish(events.TypeRefresh, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Series added successfully",
+		"series":  series,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// addSeries puts a series on the user's watchlist, creating it if nobody
+// tracks it yet. created is false when the title already existed.
+func (a *App) addSeries(user *auth.User, title, audibleID, audibleURL, amazonASIN, amazonURL string) (series *database.Series, created bool, err error) {
+	// Series are shared between users: adding a title someone else already
+	// tracks just puts it on this user's watchlist and keeps its URLs
+	series, err = a.DB.GetSeriesByTitle(title)
+	if err != nil {
+		return nil, false, err
+	}
+	if series == nil {
+		series, err = a.DB.UpsertSeries(title, audibleID, audibleURL, amazonASIN)
+		if err != nil {
+			return nil, false, err
+		}
+		created = true
+		log.Printf("added new series: %s (ID: %d)", title, series.ID)
+		a.writeBack(series.ID, amazonURL)
+	}
+
+	// Series nobody watches aren't refreshed, so their data may be out of date
+	watchers, err := a.DB.CountSeriesWatchers(series.ID)
+	if err != nil {
+		log.Printf("error counting watchers for %s: %v", title, err)
+	}
+	if err := a.DB.WatchSeries(user.ID, series.ID); err != nil {
+		return nil, false, err
+	}
 
 	// Queue scraping jobs for the new series
-	if a.BackgroundScraper != nil {
-		if audibleID != "" {
+	if a.BackgroundScraper != nil && watchers == 0 {
+		if series.AudibleID != nil {
 			if err := a.BackgroundScraper.QueueSeriesUpdate(series.ID, "audible"); err != nil {
-				log.Printf("error queuing audible scrape for %s: %v", req.Title, err)
+				log.Printf("error queuing audible scrape for %s: %v", title, err)
 			}
 		}
-		if amazonASIN != "" {
+		if series.AmazonASIN != nil {
 			if err := a.BackgroundScraper.QueueSeriesUpdate(series.ID, "amazon"); err != nil {
-				log.Printf("error queuing amazon scrape for %s: %v", req.Title, err)
+				log.Printf("error queuing amazon scrape for %s: %v", title, err)
 			}
 		}
 	}
+	return series, created, nil
+}
 
-	// Trigger data refresh
-	select {
-	case a.RefreshChan <- true:
-	default:
+// errTitleTaken is returned by updateSeries when another series has the title
+var errTitleTaken = errors.New("another series already has that title")
+
+// updateSeries renames a series or changes its provider IDs. Providers whose
+// ID changed are scraped again from the new listing; any scrape of the old
+// one still queued or running is cancelled so it can't overwrite the result.
+// amazonURL is the link amazonASIN came from, if it was given.
+func (a *App) updateSeries(id int, title, audibleID, audibleURL, amazonASIN, amazonURL string) error {
+	existing, err := a.DB.GetSeriesByTitle(title)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != id {
+		return errTitleTaken
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]interface{}{
-		"success": true,
-		"message": "Series added successfully",
-		"series":  series,
+	// UpdateSeries cancels the old listing's jobs in the database; note them
+	// first so their requests in flight can be aborted too
+	var jobs []database.ScrapeJob
+	if a.BackgroundScraper != nil {
+		if jobs, err = a.DB.GetSeriesScrapeJobs(id, maxJobsListed); err != nil {
+			log.Printf("error fetching scrape jobs for series %d: %v", id, err)
+		}
 	}
-	json.NewEncoder(w).Encode(response)
+
+	_, changed, err := a.DB.UpdateSeries(id, title, audibleID, audibleURL, amazonASIN)
+	if err != nil {
+		return err
+	}
+	log.Printf("updated series %d: %s", id, title)
+	a.writeBack(id, amazonURL)
+
+	if a.BackgroundScraper != nil && len(changed) > 0 {
+		for _, job := range jobs {
+			active := job.Status == database.JobStatusPending || job.Status == database.JobStatusRunning
+			if active && slices.Contains(changed, job.Provider) {
+				if _, err := a.BackgroundScraper.CancelJob(job.ID); err != nil {
+					log.Printf("error cancelling scrape job %d: %v", job.ID, err)
+				}
+			}
+		}
+		for _, provider := range changed {
+			if (provider == database.ProviderAudible && audibleID == "") || (provider == database.ProviderAmazon && amazonASIN == "") {
+				continue
+			}
+			if err := a.BackgroundScraper.QueueSeriesUpdate(id, provider); err != nil {
+				log.Printf("error queuing %s scrape for %s: %v", provider, title, err)
+			}
+		}
+	}
+
+	a.Events.Publish(events.TypeRefresh, nil)
+	return nil
 }
 
-func (a *App) collectAll() []models.SeriesInfo {
-	stats, err := a.DB.GetAllSeriesStats()
+// writeBack saves a series as it's now stored to books.yaml when write-back
+// is enabled. Only the ASIN of an Amazon link is stored, so amazonURL is the
+// link it was set from, written if the ASIN changed. Failures are only
+// logged, since the change is already in the database.
+func (a *App) writeBack(id int, amazonURL string) {
+	if a.ConfigWriter == nil {
+		return
+	}
+	series, err := a.DB.GetSeriesByID(id)
+	if err != nil || series == nil {
+		log.Printf("error writing series %d back to config: %v", id, err)
+		return
+	}
+	entries, err := a.DB.GetConfigEntries()
 	if err != nil {
-		log.Printf("error fetching series stats from database: %v", err)
-		return []models.SeriesInfo{}
+		log.Printf("error writing %s back to config: %v", series.Title, err)
+		return
+	}
+	audible, amazon, err := a.DB.GetSeriesOverrides(id)
+	if err != nil {
+		log.Printf("error writing %s back to config: %v", series.Title, err)
+		return
+	}
+	// Recording the entry as it's written means the file watcher sees it as
+	// unchanged and leaves the series alone
+	_, err = a.ConfigWriter.SaveSeries(entries[id], models.SeriesIDs{
+		Title:           series.Title,
+		AudibleID:       stringValue(series.AudibleID),
+		AudibleURL:      stringValue(series.AudibleURL),
+		AmazonASIN:      stringValue(series.AmazonASIN),
+		AmazonURL:       amazonURL,
+		AudibleOverride: audible,
+		AmazonOverride:  amazon,
+	}, func(entry database.ConfigEntry) error {
+		return a.DB.SetConfigEntry(id, entry)
+	})
+	if err != nil {
+		log.Printf("error writing %s back to config: %v", series.Title, err)
 	}
-	return database.ToSeriesInfoSlice(stats)
 }
 
-// WarmupCache is now a no-op since data comes from database
-func (a *App) WarmupCache() {
-	log.Printf("using database - no warmup needed")
+// stringValue returns the string s points to, or "" for nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
 }
 
-// HandleEvents serves Server-Sent Events for live refresh
-func (a *App) HandleEvents(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	fmt.Fprintf(w, "data: connected\n\n")
-	w.(http.Flusher).Flush()
-
-	for {
-		select {
-		case <-r.Context().Done():
-			return
-		case <-a.RefreshChan:
-			fmt.Fprintf(w, "data: refresh\n\n")
-			w.(http.Flusher).Flush()
-		case update := <-a.ScraperUpdateCh:
-			updateJSON, _ := json.Marshal(update)
-			fmt.Fprintf(w, "data: %s\n\n", updateJSON)
-			w.(http.Flusher).Flush()
-		}
+// watchedSeriesIDs returns the series on the requesting user's watchlist
+func (a *App) watchedSeriesIDs(r *http.Request) (map[int]bool, error) {
+	user, ok := auth.GetUserFromContext(r)
+	if !ok {
+		return map[int]bool{}, nil
 	}
+	return a.DB.GetWatchedSeriesIDs(user.ID)
 }
 
-func (a *App) findNewEntries(newData []models.SeriesIDs) []models.SeriesIDs {
-	a.mu.RLock()
-	oldData := a.Data
-	a.mu.RUnlock()
+// isWatched reports whether a series is on the requesting user's watchlist
+func (a *App) isWatched(r *http.Request, seriesID int) bool {
+	watched, err := a.watchedSeriesIDs(r)
+	if err != nil {
+		log.Printf("error fetching watchlist: %v", err)
+		return false
+	}
+	return watched[seriesID]
+}
 
-	existing := make(map[string]bool)
-	for _, series := range oldData {
-		key := series.Title + "|" + series.AudibleID + "|" + series.AmazonASIN
-		existing[key] = true
+// collectWatched returns collectAll filtered to the requesting user's watchlist
+func (a *App) collectWatched(r *http.Request) []models.SeriesInfo {
+	watched, err := a.watchedSeriesIDs(r)
+	if err != nil {
+		log.Printf("error fetching watchlist: %v", err)
+		return []models.SeriesInfo{}
 	}
 
-	var newEntries []models.SeriesIDs
-	for _, series := range newData {
-		key := series.Title + "|" + series.AudibleID + "|" + series.AmazonASIN
-		if !existing[key] {
-			newEntries = append(newEntries, series)
+	infos := []models.SeriesInfo{}
+	for _, info := range a.collectAll() {
+		if watched[info.ID] {
+			infos = append(infos, info)
 		}
 	}
-	return newEntries
+	return infos
 }
 
-func (a *App) UpdateDataIncremental(newData []models.SeriesIDs) {
-	newEntries := a.findNewEntries(newData)
-	if len(newEntries) == 0 {
-		log.Printf("no new entries found in config update")
-		return
+func (a *App) collectAll() []models.SeriesInfo {
+	stats, err := a.DB.GetAllSeriesStats()
+	if err != nil {
+		log.Printf("error fetching series stats from database: %v", err)
+		return []models.SeriesInfo{}
+	}
+	books, err := a.DB.GetAllBooks()
+	if err != nil {
+		log.Printf("error fetching books from database: %v", err)
+		return database.ToSeriesInfoSlice(stats)
 	}
+	return database.ToSeriesInfoSliceWithBooks(stats, books)
+}
 
-	a.mu.Lock()
-	a.Data = newData
-	a.mu.Unlock()
+// WarmupCache is now a no-op since data comes from database
+func (a *App) WarmupCache() {
+	log.Printf("using database - no warmup needed")
+}
 
-	go func() {
-		log.Printf("scraping %d new entries...", len(newEntries))
-		for _, entry := range newEntries {
-			key := entry.Title + "|" + entry.AudibleID + "|" + entry.AmazonASIN
-			if _, ok := a.Cache.Get(key); !ok {
-				info, err := a.Provider.Fetch(entry)
-				if err != nil {
-					info.Err = err
-				}
-				a.Cache.Set(key, info)
-				log.Printf("scraped new entry: %s", entry.Title)
-			}
+// HandleEvents serves Server-Sent Events for live refresh. Scrape progress
+// is only sent for series on the requesting user's watchlist.
+func (a *App) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.GetUserFromContext(r)
+	a.Events.Serve(w, r, func(seriesID int) bool {
+		if !ok {
+			return false
 		}
-		select {
-		case a.RefreshChan <- true:
-		default:
+		watching, err := a.DB.IsWatching(user.ID, seriesID)
+		if err != nil {
+			log.Printf("error checking %s's watchlist for series %d: %v", user.Username, seriesID, err)
+			return false
 		}
-		log.Printf("incremental update complete - added %d new entries", len(newEntries))
-	}()
+		return watching
+	})
 }
 
 func (a *App) ReloadData(newData []models.SeriesIDs) {
@@ -412,10 +838,7 @@ func (a *App) ReloadData(newData []models.SeriesIDs) {
 	a.mu.Lock()
 	a.Data = newData
 	a.mu.Unlock()
-	select {
-	case a.RefreshChan <- true:
-	default:
-	}
+	a.Events.Publish(events.TypeRefresh, nil)
 }
 
 func joinTitleDate(title string, d *time.Time) string {
@@ -431,6 +854,85 @@ func joinTitleDate(title string, d *time.Time) string {
 	return d.Format("2006-01-02")
 }
 
+// toBookRows converts a provider's books for display in the template
+func toBookRows(books []models.Book) []BookRow {
+	rows := make([]BookRow, 0, len(books))
+	for _, b := range books {
+		rows = append(rows, BookRow{
+			Position: b.Position,
+			Title:    b.Title,
+			Date:     formatDateOnly(b.ReleaseDate),
+			Preorder: b.IsPreorder,
+		})
+	}
+	return rows
+}
+
+// toErrorRow converts a scrape failure for display, returning nil when there is none
+func toErrorRow(f *models.ScrapeFailure) *ErrorRow {
+	if f == nil {
+		return nil
+	}
+	labels := map[models.ErrorCategory]string{
+		models.ErrorBlocked:    "blocked",
+		models.ErrorNotFound:   "not found",
+		models.ErrorHTTPStatus: "HTTP error",
+		models.ErrorTimeout:    "timed out",
+		models.ErrorNetwork:    "network error",
+		models.ErrorParse:      "unreadable page",
+	}
+	label, ok := labels[f.Category]
+	if !ok {
+		label = "failed"
+	}
+	msg := f.Message
+	if f.FailedAt != nil {
+		msg = fmt.Sprintf("%s (%s)", msg, f.FailedAt.Format("Jan 2, 2006 at 3:04 PM"))
+	}
+	return &ErrorRow{Label: label, Message: msg}
+}
+
+// toEventRows describes release events for the series timeline
+func toEventRows(events []database.ReleaseEvent) []EventRow {
+	rows := make([]EventRow, 0, len(events))
+	for _, e := range events {
+		row := EventRow{
+			When:     e.ObservedAt.Local().Format("Jan 2, 2006"),
+			Provider: e.Provider,
+			Type:     e.EventType,
+			Book:     e.BookTitle,
+		}
+		if e.BookNumber != nil {
+			row.Position = *e.BookNumber
+		}
+
+		switch e.EventType {
+		case database.EventAnnounced:
+			row.Detail = "announced"
+			if e.NewReleaseDate != nil {
+				row.Detail += ", releasing " + formatDateOnly(e.NewReleaseDate)
+			}
+		case database.EventDateChanged:
+			row.Detail = fmt.Sprintf("moved from %s to %s", formatDateOnly(e.OldReleaseDate), formatDateOnly(e.NewReleaseDate))
+		case database.EventReleased:
+			row.Detail = "released"
+			if e.NewReleaseDate != nil {
+				row.Detail += " " + formatDateOnly(e.NewReleaseDate)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// formatStaleSince formats when a provider's data went stale
+func formatStaleSince(d *time.Time) string {
+	if d == nil {
+		return ""
+	}
+	return d.Local().Format("Jan 2, 3:04 PM")
+}
+
 func formatDateOnly(d *time.Time) string {
 	if d == nil {
 		return ""
@@ -524,9 +1026,6 @@ func (a *App) StartAutoRefresh() {
 		log.Printf("starting auto-refresh loop with %d hour interval", interval)
 		for range a.autoRefreshTicker.C {
 			log.Printf("triggering scheduled data refresh...")
-			if err := a.DB.ClearAllBookData(); err != nil {
-				log.Printf("warning: failed to clear book data during auto-refresh: %v", err)
-			}
 			if a.BackgroundScraper != nil {
 				if err := a.BackgroundScraper.QueueAllSeriesUpdate(); err != nil {
 					log.Printf("error queuing auto-refresh jobs: %v", err)
@@ -553,7 +1052,8 @@ type DeleteSeriesRequest struct {
 	SeriesTitles []string `json:"seriesTitles"`
 }
 
-// HandleDeleteSeries handles the deletion of multiple series
+// HandleDeleteSeries removes series from the requesting user's watchlist. The
+// series themselves stay in the database for other users.
 func (a *App) HandleDeleteSeries(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -571,20 +1071,27 @@ func (a *App) HandleDeleteSeries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Delete series from database by title
+	user, ok := auth.GetUserFromContext(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Remove series from the user's watchlist by title
 	for _, title := range req.SeriesTitles {
-		if err := a.DB.DeleteSeriesByTitle(title); err != nil {
-			log.Printf("error deleting series %s: %v", title, err)
+		series, err := a.DB.GetSeriesByTitle(title)
+		if err == nil && series == nil {
+			continue
+		}
+		if err == nil {
+			err = a.DB.UnwatchSeries(user.ID, series.ID)
+		}
+		if err != nil {
+			log.Printf("error removing series %s from %s's watchlist: %v", title, user.Username, err)
 			http.Error(w, fmt.Sprintf("Failed to delete series %s", title), http.StatusInternalServerError)
 			return
 		}
-		log.Printf("deleted series %s", title)
-	}
-
-	// Update the in-memory data by reloading from database
-	if err := a.reloadDataFromDB(); err != nil {
-		log.Printf("error reloading data after deletion: %v", err)
-		// Don't return error to user since deletion succeeded
+		log.Printf("removed series %s from %s's watchlist", title, user.Username)
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/michaeldvinci/syllabus/internal/auth"
+	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+func TestHandleCancelScrapeJob(t *testing.T) {
+	tests := []struct {
+		name     string
+		scraper  bool
+		body     string
+		wantCode int
+	}{
+		{"cancels a queued job", true, `{"jobId": 1}`, http.StatusOK},
+		{"unknown job", true, `{"jobId": 99}`, http.StatusNotFound},
+		{"invalid body", true, `{"jobId": "one"}`, http.StatusBadRequest},
+		{"no background scraper", false, `{"jobId": 1}`, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &auth.User{ID: "editor-id", Username: "erin", Role: auth.RoleEditor}
+			app, _ := newTestAPI(t, &user)
+			series, err := app.DB.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "")
+			if err != nil {
+				t.Fatalf("upsert series: %v", err)
+			}
+			if _, err := app.DB.CreateScrapeJob(series.ID, database.ProviderAudible); err != nil {
+				t.Fatalf("CreateScrapeJob: %v", err)
+			}
+			if !tt.scraper {
+				app.BackgroundScraper = nil
+			}
+
+			rec := httptest.NewRecorder()
+			app.HandleCancelScrapeJob(rec, httptest.NewRequest("POST", "/api/scrape-jobs/cancel", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestReleasedThrough(t *testing.T) {
+	book := func(position int, preorder bool) models.Book {
+		return models.Book{Title: fmt.Sprintf("Book %d", position), Position: position, IsPreorder: preorder}
+	}
+
+	tests := []struct {
+		name   string
+		count  int
+		books  []models.Book
+		pinned bool
+		want   int
+	}{
+		{"numbered books", 3, []models.Book{book(1, false), book(2, false), book(3, false)}, false, 3},
+		{"preorder isn't out yet", 4, []models.Book{book(1, false), book(2, false), book(3, false), book(4, true)}, false, 3},
+		{"novella doesn't shift positions", 4, []models.Book{book(1, false), book(2, false), book(0, false), book(3, false)}, false, 3},
+		{"gap in the listing", 2, []models.Book{book(1, false), book(5, false)}, false, 5},
+		{"no positions uses the count", 5, []models.Book{book(0, false), book(0, true)}, false, 4},
+		{"count only", 6, nil, false, 6},
+		{"pinned count wins", 8, []models.Book{book(1, false), book(2, false), book(3, true)}, true, 7},
+		{"nothing scraped", 0, nil, false, 0},
+	}
+
+	for _, tt := range tests {
+		if got := releasedThrough(tt.count, tt.books, tt.pinned); got != tt.want {
+			t.Errorf("%s: releasedThrough = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	// Owned and finished are positions, so a novella doesn't leave a phantom unread book
+	info := models.SeriesInfo{AudibleCount: 4, AudibleBooks: []models.Book{book(1, false), book(2, false), book(0, false), book(3, false)}}
+	row := withProgress(Row{}, info, map[string]database.ReadingProgress{
+		database.FormatAudio: {Format: database.FormatAudio, OwnedThrough: 3, FinishedThrough: 3},
+	})
+	if row.Audio.Unfinished != 0 || row.Audio.NotOwned != 0 {
+		t.Errorf("progress = %+v, want everything owned and finished", row.Audio)
+	}
+}
@@ -1,6 +1,21 @@
 package handlers
 
-const IndexHTML = `
+const IndexHTML = `{{ define "bookItems" }}{{ range . }}<li class="{{ if .Preorder }}book-preorder{{ end }}"><span class="book-pos">{{ if .Position }}#{{ .Position }}{{ else }}–{{ end }}</span><span class="book-title">{{ .Title }}</span><span>{{ if .Date }}{{ .Date }}{{ end }}{{ if .Preorder }} (preorder){{ end }}</span></li>{{ end }}{{ end }}
+{{ define "bookList" }}{{ if or .AudibleBooks .AmazonBooks }}<details class="book-list"><summary>Show books</summary>
+  {{ if .AudibleBooks }}<ol class="book-aud">{{ template "bookItems" .AudibleBooks }}</ol>{{ end }}
+  {{ if .AmazonBooks }}<ol class="book-amz">{{ template "bookItems" .AmazonBooks }}</ol>{{ end }}
+</details>{{ end }}{{ end }}
+{{ define "audibleBookList" }}{{ if .AudibleBooks }}<details class="book-list"><summary>Show books</summary><ol class="book-aud">{{ template "bookItems" .AudibleBooks }}</ol></details>{{ end }}{{ end }}
+{{ define "seriesTitle" }}{{ if .ID }}<a class="series-link" href="/series/{{ .ID }}" title="Series details and release history">{{ .Title }}</a>{{ else }}{{ .Title }}{{ end }}{{ end }}
+{{ define "scrapeError" }}{{ with . }}<span class="scrape-error" title="{{ .Message }}">⚠ {{ .Label }}</span>{{ end }}{{ end }}
+{{ define "staleNote" }}{{ if . }}<span class="stale-note" title="The last refresh failed; showing data from the last successful scrape">stale since {{ . }}</span>{{ end }}{{ end }}
+{{ define "overrideNote" }}{{ if . }}<span class="override-note" title="Set by hand ({{ . }}); scraped values are ignored">overridden</span>{{ end }}{{ end }}
+{{ define "progressSummary" }}{{ if or .Owned .Finished }}own #{{ .Owned }} · {{ .Verb }} #{{ .Finished }}{{ else }}—{{ end }}{{ end }}
+{{ define "progressNotes" }}{{ if .Unfinished }}<span class="progress-note" title="Owned but not yet {{ .Verb }}">{{ .Unfinished }} un{{ .Verb }}</span>{{ end }}{{ if .NotOwned }}<span class="progress-note progress-new" title="Released books you don't own yet">{{ .NotOwned }} not owned</span>{{ end }}{{ end }}
+{{ define "progressEdit" }}<button class="progress-edit" type="button" title="Update reading progress" data-series-id="{{ .ID }}" data-title="{{ .Title }}" data-aud-owned="{{ .Audio.Owned }}" data-aud-finished="{{ .Audio.Finished }}" data-ebook-owned="{{ .Ebook.Owned }}" data-ebook-finished="{{ .Ebook.Finished }}" onclick="openProgressModal(this)">✎</button>{{ end }}
+{{ define "seriesEdit" }}<button class="series-edit" type="button" title="Edit series" data-series-id="{{ .ID }}" data-title="{{ .Title }}" data-audible="{{ .AudibleURL }}" data-amazon="{{ .AmazonURL }}" onclick="openEditSeriesModal(this)">✎</button>{{ end }}
+{{ define "progressCell" }}<div class="progress-cell"><div class="progress-line"><span class="icon-headphones" style="color:var(--aud)"></span>{{ template "progressSummary" .Audio }}{{ template "progressNotes" .Audio }}</div><div class="progress-line"><span class="icon-book" style="color:var(--amz)"></span>{{ template "progressSummary" .Ebook }}{{ template "progressNotes" .Ebook }}</div>{{ template "progressEdit" . }}</div>{{ end }}
+{{ define "amazonBookList" }}{{ if .AmazonBooks }}<details class="book-list"><summary>Show books</summary><ol class="book-amz">{{ template "bookItems" .AmazonBooks }}</ol></details>{{ end }}{{ end }}
 <!doctype html>
 <html>
 <head>
@@ -66,6 +81,7 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
 .user-actions{display:flex;gap:4px}
 .delete-user-btn{padding:4px 8px;background:#dc2626;color:white;border:none;border-radius:4px;cursor:pointer;font-size:12px}
 .delete-user-btn:hover{background:#b91c1c}
+.user-role-select{padding:2px 4px;border:1px solid var(--line);border-radius:4px;background:var(--bg);color:var(--text);font-size:.85rem}
 .reset-password-btn{padding:4px 8px;background:#f59e0b;color:white;border:none;border-radius:4px;cursor:pointer;font-size:12px}
 .reset-password-btn:hover{background:#d97706}
 [data-theme="dark"] .user-list-item{background:#2a2a2a;border-color:#374151}
@@ -139,6 +155,32 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
 [data-theme="dark"] .link-aud{background:rgba(74,159,255,.12);color:var(--aud)}
 [data-theme="dark"] .link-amz{background:rgba(255,149,0,.12);color:var(--amz)}
 
+/* per-series book listing */
+.book-list{margin-top:4px;font-size:.8rem;color:var(--muted)}
+.book-list summary{cursor:pointer;user-select:none}
+.book-list ol{margin:6px 0 0;padding-left:0;list-style:none}
+.book-list li{display:flex;gap:8px;padding:2px 0}
+.book-list .book-pos{min-width:2.2rem;color:var(--muted)}
+.book-list .book-title{flex:1;color:var(--text)}
+.book-list .book-aud .book-pos{color:var(--aud)}
+.book-list .book-amz .book-pos{color:var(--amz)}
+.book-list .book-preorder .book-title{font-style:italic}
+.scrape-error{font-size:.75rem;font-weight:600;color:#b45309;background:#fef3c7;border-radius:999px;padding:1px 8px;white-space:nowrap;cursor:help}
+[data-theme="dark"] .scrape-error{color:#fcd34d;background:#78350f}
+.stale-note{font-size:.75rem;color:var(--muted);font-style:italic;white-space:nowrap}
+.override-note{font-size:.75rem;font-weight:600;color:#6d28d9;background:#ede9fe;border-radius:999px;padding:1px 8px;white-space:nowrap;cursor:help}
+[data-theme="dark"] .override-note{color:#c4b5fd;background:#4c1d95}
+.progress-cell{display:flex;flex-direction:column;align-items:flex-start;gap:4px;font-size:.85rem;color:var(--muted)}
+.progress-line{display:inline-flex;align-items:center;gap:6px;white-space:nowrap}
+.progress-note{font-size:.75rem;font-weight:600;color:#1d4ed8;background:#dbeafe;border-radius:999px;padding:1px 8px;white-space:nowrap}
+.progress-note.progress-new{color:#047857;background:#d1fae5}
+[data-theme="dark"] .progress-note{color:#bfdbfe;background:#1e3a8a}
+[data-theme="dark"] .progress-note.progress-new{color:#a7f3d0;background:#065f46}
+.progress-edit,.series-edit{background:none;border:1px solid var(--line);border-radius:6px;color:var(--muted);cursor:pointer;font-size:12px;padding:2px 8px}
+.progress-edit:hover,.series-edit:hover{background:var(--row-hover);color:var(--text)}
+.series-link{color:inherit;text-decoration:none}
+.series-link:hover{text-decoration:underline}
+
 /* Icon styles */
 .icon-headphones{
   display:inline-block;
@@ -211,17 +253,19 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
 .unified-table th:nth-child(3){width:120px;text-align:center} /* Count (80px * 1.5) */
 .unified-table th:nth-child(4){width:300px;text-align:center} /* Latest (200px * 1.5) */
 .unified-table th:nth-child(5){width:300px;text-align:center} /* Next (200px * 1.5) */
+.unified-table th:nth-child(6){width:220px} /* Progress */
 .unified-table td:nth-child(3), .unified-table td:nth-child(4), .unified-table td:nth-child(5){text-align:center}
 
 /* Main table (unified/separated view) - proportional column widths */
 .table th:nth-child(1){width:40px} /* Checkbox */
-.table th:nth-child(2){width:30%} /* Series name */
+.table th:nth-child(2){width:24%} /* Series name */
 .table th:nth-child(3){width:8%;text-align:center} /* Audible count */
-.table th:nth-child(4){width:15%;text-align:center} /* Audible latest */
-.table th:nth-child(5){width:15%;text-align:center} /* Audible next */
+.table th:nth-child(4){width:12%;text-align:center} /* Audible latest */
+.table th:nth-child(5){width:12%;text-align:center} /* Audible next */
 .table th:nth-child(6){width:8%;text-align:center} /* Amazon count */
-.table th:nth-child(7){width:15%;text-align:center} /* Amazon latest */
-.table th:nth-child(8){width:15%;text-align:center} /* Amazon next */
+.table th:nth-child(7){width:12%;text-align:center} /* Amazon latest */
+.table th:nth-child(8){width:12%;text-align:center} /* Amazon next */
+.table th:nth-child(9){width:12%} /* Reading progress */
 .table td:nth-child(3), .table td:nth-child(4), .table td:nth-child(5),
 .table td:nth-child(6), .table td:nth-child(7), .table td:nth-child(8){text-align:center}
 
@@ -247,6 +291,7 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
             </svg>
           </button>
         </div>
+        {{ if .User }}{{ if .User.Can "manage_series" }}
         <button class="add-series-btn" id="addSeriesBtn" onclick="openAddSeriesModal()" title="Add new series">
           <svg width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2">
             <line x1="12" y1="5" x2="12" y2="19"></line>
@@ -259,7 +304,7 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
             <path d="m18.5 2.5 3 3L12 15l-4 1 1-4Z"></path>
           </svg>
         </button>
-        <button class="add-series-btn" id="deleteBtn" onclick="deleteSelected()" title="Delete selected" style="display:none;background:#dc2626;color:white;border-color:#dc2626">
+        <button class="add-series-btn" id="deleteBtn" onclick="deleteSelected()" title="Remove selected from my list" style="display:none;background:#dc2626;color:white;border-color:#dc2626">
           <svg width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2">
             <polyline points="3,6 5,6 21,6"></polyline>
             <path d="m19,6v14a2,2 0 0,1-2,2H7a2,2 0 0,1-2-2V6m3,0V4a2,2 0 0,1,2-2h4a2,2 0 0,1,2,2v2"></path>
@@ -267,6 +312,7 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
             <line x1="14" y1="11" x2="14" y2="17"></line>
           </svg>
         </button>
+        {{ end }}{{ end }}
       </div>
     </div>
     <div class="top-bar-right">
@@ -294,9 +340,9 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
         {{ end }}
         <div class="user-dropdown" id="userDropdown">
           {{ if .Authenticated }}
-            {{ if .User.IsAdmin }}
+            {{ if .User }}{{ if .User.Can "manage_users" }}
               <button class="user-dropdown-item" onclick="openUsersModal()">Users</button>
-            {{ end }}
+            {{ end }}{{ end }}
             <button class="user-dropdown-item" onclick="logout()">Logout</button>
           {{ else }}
             <a class="user-dropdown-item" href="/login">Login</a>
@@ -348,6 +394,9 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
                 <div class="checkrow"><input type="checkbox" id="fAnyUpcoming"><label for="fAnyUpcoming">Any upcoming date</label></div>
                 <div class="checkrow"><input type="checkbox" id="fNoNext"><label for="fNoNext">No upcoming date</label></div>
                 <hr style="border:0;border-top:1px solid var(--line);margin:12px 0">
+                <div class="checkrow"><input type="checkbox" id="fUnfinished"><label for="fUnfinished">Owned but <b>unfinished</b></label></div>
+                <div class="checkrow"><input type="checkbox" id="fNotOwned"><label for="fNotOwned">New book I <b>don't own</b></label></div>
+                <hr style="border:0;border-top:1px solid var(--line);margin:12px 0">
                 <button id="clearFilters" style="width:100%;padding:.6rem .8rem;border:1px solid var(--line);border-radius:8px;background:#fff;cursor:pointer">Clear filters</button>
               </div>
             </div>
@@ -390,6 +439,7 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
                       <th class="sortable" data-sort="amazon" style="color:var(--amz)" onclick="sortTable('amazon')">Amazon</th>
                       <th class="sortable" data-sort="amz-latest" style="color:var(--amz)" onclick="sortTable('amz-latest')">Latest</th>
                       <th class="sortable" data-sort="amz-next" style="color:var(--amz)" onclick="sortTable('amz-next')">Next</th>
+                      <th>Progress</th>
                     </tr>
                   </thead>
                 <tbody id="seriesTbody">
@@ -401,17 +451,23 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
                       data-aud-latest="{{ .AudibleLatest }}"
                       data-amz-latest="{{ .AmazonLatest }}"
                       data-aud-next="{{ .AudibleNext }}"
-                      data-amz-next="{{ .AmazonNext }}">
-                    <td class="selectTd" style="width:40px;text-align:center;display:none">
+                      data-amz-next="{{ .AmazonNext }}"
+                      data-aud-unfinished="{{ .Audio.Unfinished }}"
+                      data-aud-not-owned="{{ .Audio.NotOwned }}"
+                      data-ebook-unfinished="{{ .Ebook.Unfinished }}"
+                      data-ebook-not-owned="{{ .Ebook.NotOwned }}">
+                    <td class="selectTd" style="width:64px;text-align:center;white-space:nowrap;display:none">
                       <input type="checkbox" class="rowCheckbox" value="{{ .Title }}" onchange="updateDeleteButton()">
+                      {{ template "seriesEdit" . }}
                     </td>
                     <td class="text">
-                      <div style="font-weight:700;color:var(--text)">{{ .Title }}</div>
+                      <div style="font-weight:700;color:var(--text)">{{ template "seriesTitle" . }}</div>
+                      {{ template "bookList" . }}
                     </td>
                     <td style="text-align:left;padding:12px 8px">
                       <div style="display:inline-flex;align-items:center;gap:6px">
                         {{ if .AudibleURL }}<a class="linkpill link-aud" href="{{ .AudibleURL }}" target="_blank" rel="noopener" title="View on Audible"><span class="icon-headphones"></span></a>{{ end }}
-                        <span style="color:var(--aud);font-weight:600">{{ .AudibleCount }}</span>
+                        <span style="color:var(--aud);font-weight:600">{{ .AudibleCount }}</span>{{ template "scrapeError" .AudibleError }}{{ template "staleNote" .AudibleStale }}{{ template "overrideNote" .AudiblePinned }}
                       </div>
                     </td>
                     <td><span class="latest" data-latest-pill-aud>{{ if .AudibleLatest }}{{ .AudibleLatest }}{{ else }}—{{ end }}</span></td>
@@ -419,11 +475,12 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
                     <td style="text-align:left;padding:12px 8px">
                       <div style="display:inline-flex;align-items:center;gap:6px">
                         {{ if .AmazonURL }}<a class="linkpill link-amz" href="{{ .AmazonURL }}" target="_blank" rel="noopener" title="View on Amazon"><span class="icon-book"></span></a>{{ end }}
-                        <span style="color:var(--amz);font-weight:600">{{ .AmazonCount }}</span>
+                        <span style="color:var(--amz);font-weight:600">{{ .AmazonCount }}</span>{{ template "scrapeError" .AmazonError }}{{ template "staleNote" .AmazonStale }}{{ template "overrideNote" .AmazonPinned }}
                       </div>
                     </td>
                     <td><span class="latest" data-latest-pill-amz>{{ if .AmazonLatest }}{{ .AmazonLatest }}{{ else }}—{{ end }}</span></td>
                     <td><span class="next" data-next-pill-amz><center>-</center></span></td>
+                    <td>{{ template "progressCell" . }}</td>
                   </tr>
                   {{ end }}
                 </tbody>
@@ -442,6 +499,7 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
                       <th class="sortable" data-sort="audible" style="color:var(--aud)" onclick="sortTable('audible')">Count</th>
                       <th class="sortable" data-sort="aud-latest" style="color:var(--aud)" onclick="sortTable('aud-latest')">Latest</th>
                       <th class="sortable" data-sort="aud-next" style="color:var(--aud)" onclick="sortTable('aud-next')">Next</th>
+                      <th>Progress</th>
                     </tr>
                   </thead>
                   <tbody id="audibleTbody">
@@ -450,21 +508,26 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
                         data-title="{{ .Title }}"
                         data-aud-count="{{ .AudibleCount }}"
                         data-aud-latest="{{ .AudibleLatest }}"
-                        data-aud-next="{{ .AudibleNext }}">
-                      <td class="selectTd" style="width:40px;text-align:center;display:none">
+                        data-aud-next="{{ .AudibleNext }}"
+                        data-aud-unfinished="{{ .Audio.Unfinished }}"
+                        data-aud-not-owned="{{ .Audio.NotOwned }}">
+                      <td class="selectTd" style="width:64px;text-align:center;white-space:nowrap;display:none">
                         <input type="checkbox" class="rowCheckbox" value="{{ .Title }}" onchange="updateDeleteButton()">
+                        {{ template "seriesEdit" . }}
                       </td>
                       <td class="text">
-                        <div style="font-weight:700;color:var(--text)">{{ .Title }}</div>
+                        <div style="font-weight:700;color:var(--text)">{{ template "seriesTitle" . }}</div>
+                        {{ template "audibleBookList" . }}
                       </td>
                       <td style="text-align:left;padding:12px 8px">
                         <div style="display:inline-flex;align-items:center;gap:6px">
                           {{ if .AudibleURL }}<a class="linkpill link-aud" href="{{ .AudibleURL }}" target="_blank" rel="noopener" title="View on Audible"><span class="icon-headphones"></span></a>{{ end }}
-                          <span style="color:var(--aud);font-weight:600">{{ .AudibleCount }}</span>
+                          <span style="color:var(--aud);font-weight:600">{{ .AudibleCount }}</span>{{ template "scrapeError" .AudibleError }}{{ template "staleNote" .AudibleStale }}{{ template "overrideNote" .AudiblePinned }}
                         </div>
                       </td>
                       <td><span class="latest" data-latest-pill-aud>{{ if .AudibleLatest }}{{ .AudibleLatest }}{{ else }}—{{ end }}</span></td>
                       <td><span class="next" data-next-pill-aud><center>-</center></span></td>
+                      <td><div class="progress-cell"><div class="progress-line">{{ template "progressSummary" .Audio }}{{ template "progressNotes" .Audio }}</div>{{ template "progressEdit" . }}</div></td>
                     </tr>
                     {{ end }}
                   </tbody>
@@ -482,6 +545,7 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
                       <th class="sortable" data-sort="amazon" style="color:var(--amz)" onclick="sortTable('amazon')">Count</th>
                       <th class="sortable" data-sort="amz-latest" style="color:var(--amz)" onclick="sortTable('amz-latest')">Latest</th>
                       <th class="sortable" data-sort="amz-next" style="color:var(--amz)" onclick="sortTable('amz-next')">Next</th>
+                      <th>Progress</th>
                     </tr>
                   </thead>
                   <tbody id="amazonTbody">
@@ -490,21 +554,26 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
                         data-title="{{ .Title }}"
                         data-amz-count="{{ .AmazonCount }}"
                         data-amz-latest="{{ .AmazonLatest }}"
-                        data-amz-next="{{ .AmazonNext }}">
-                      <td class="selectTd" style="width:40px;text-align:center;display:none">
+                        data-amz-next="{{ .AmazonNext }}"
+                        data-ebook-unfinished="{{ .Ebook.Unfinished }}"
+                        data-ebook-not-owned="{{ .Ebook.NotOwned }}">
+                      <td class="selectTd" style="width:64px;text-align:center;white-space:nowrap;display:none">
                         <input type="checkbox" class="rowCheckbox" value="{{ .Title }}" onchange="updateDeleteButton()">
+                        {{ template "seriesEdit" . }}
                       </td>
                       <td class="text">
-                        <div style="font-weight:700;color:var(--text)">{{ .Title }}</div>
+                        <div style="font-weight:700;color:var(--text)">{{ template "seriesTitle" . }}</div>
+                        {{ template "amazonBookList" . }}
                       </td>
                       <td style="text-align:left;padding:12px 8px">
                         <div style="display:inline-flex;align-items:center;gap:6px">
                           {{ if .AmazonURL }}<a class="linkpill link-amz" href="{{ .AmazonURL }}" target="_blank" rel="noopener" title="View on Amazon"><span class="icon-book"></span></a>{{ end }}
-                          <span style="color:var(--amz);font-weight:600">{{ .AmazonCount }}</span>
+                          <span style="color:var(--amz);font-weight:600">{{ .AmazonCount }}</span>{{ template "scrapeError" .AmazonError }}{{ template "staleNote" .AmazonStale }}{{ template "overrideNote" .AmazonPinned }}
                         </div>
                       </td>
                       <td><span class="latest" data-latest-pill-amz>{{ if .AmazonLatest }}{{ .AmazonLatest }}{{ else }}—{{ end }}</span></td>
                       <td><span class="next" data-next-pill-amz><center>-</center></span></td>
+                      <td><div class="progress-cell"><div class="progress-line">{{ template "progressSummary" .Ebook }}{{ template "progressNotes" .Ebook }}</div>{{ template "progressEdit" . }}</div></td>
                     </tr>
                     {{ end }}
                   </tbody>
@@ -532,12 +601,18 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
              data-aud-latest="{{ .AudibleLatest }}"
              data-amz-latest="{{ .AmazonLatest }}"
              data-aud-next="{{ .AudibleNext }}"
-             data-amz-next="{{ .AmazonNext }}">
-          <div class="m-title">{{ .Title }}</div>
-          <div class="m-row"><span class="icon-headphones" style="color:var(--aud)"></span>{{ .AudibleCount }} Latest <span class="latest" data-latest-pill-aud>{{ if .AudibleLatest }}{{ .AudibleLatest }}{{ else }}—{{ end }}</span></div>
-          <div class="m-row"><span class="icon-book" style="color:var(--amz)"></span>{{ .AmazonCount }} Latest <span class="latest" data-latest-pill-amz>{{ if .AmazonLatest }}{{ .AmazonLatest }}{{ else }}—{{ end }}</span></div>
+             data-amz-next="{{ .AmazonNext }}"
+             data-aud-unfinished="{{ .Audio.Unfinished }}"
+             data-aud-not-owned="{{ .Audio.NotOwned }}"
+             data-ebook-unfinished="{{ .Ebook.Unfinished }}"
+             data-ebook-not-owned="{{ .Ebook.NotOwned }}">
+          <div class="m-title">{{ template "seriesTitle" . }}</div>
+          {{ template "bookList" . }}
+          <div class="m-row"><span class="icon-headphones" style="color:var(--aud)"></span>{{ .AudibleCount }}{{ template "scrapeError" .AudibleError }}{{ template "staleNote" .AudibleStale }}{{ template "overrideNote" .AudiblePinned }} Latest <span class="latest" data-latest-pill-aud>{{ if .AudibleLatest }}{{ .AudibleLatest }}{{ else }}—{{ end }}</span></div>
+          <div class="m-row"><span class="icon-book" style="color:var(--amz)"></span>{{ .AmazonCount }}{{ template "scrapeError" .AmazonError }}{{ template "staleNote" .AmazonStale }}{{ template "overrideNote" .AmazonPinned }} Latest <span class="latest" data-latest-pill-amz>{{ if .AmazonLatest }}{{ .AmazonLatest }}{{ else }}—{{ end }}</span></div>
           <div class="m-row">Next (Au): <span class="next" data-next-pill-aud><center>-</center></span></div>
           <div class="m-row">Next (Am): <span class="next" data-next-pill-amz><center>-</center></span></div>
+          <div class="m-row">{{ template "progressCell" . }}</div>
           <div class="m-row" style="gap:6px">
             {{ if .AudibleURL }}<a class="linkpill link-aud" href="{{ .AudibleURL }}" target="_blank" rel="noopener" title="View on Audible"><span class="icon-headphones"></span>{{ .AudibleCount }}</a>{{ end }}
             {{ if .AmazonURL }}<a class="linkpill link-amz" href="{{ .AmazonURL }}" target="_blank" rel="noopener" title="View on Amazon"><span class="icon-book"></span>{{ .AmazonCount }}</a>{{ end }}
@@ -606,6 +681,7 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
             </select>
           </div>
         </div>
+        {{ if .User }}{{ if .User.Can "manage_series" }}
         <div class="modal-row">
           <div>
             <div style="font-weight:600">Force Scrape</div>
@@ -615,6 +691,7 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
             <button id="forceScrapeBtn" style="padding:6px 12px;background:#16a34a;color:white;border:none;border-radius:6px;cursor:pointer;font-size:13px;font-weight:500">Force Scrape</button>
           </div>
         </div>
+        {{ end }}{{ end }}
         <div class="modal-row" style="flex-direction:column;align-items:stretch;gap:10px">
           <div style="display:flex;justify-content:space-between;align-items:center">
             <div>
@@ -631,6 +708,75 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
             <button id="icalRegenBtn" style="padding:8px 10px;background:none;border:1px solid var(--line);border-radius:6px;cursor:pointer;color:var(--muted);font-size:12px" title="Regenerate token">&#x21bb;</button>
           </div>
         </div>
+        <div class="modal-row" style="flex-direction:column;align-items:stretch;gap:10px">
+          <div>
+            <div style="font-weight:600">API Tokens</div>
+            <div style="color:var(--muted);font-size:.9rem">Personal tokens for scripts and integrations, sent as <code>Authorization: Bearer &lt;token&gt;</code>.</div>
+          </div>
+          <div id="apiTokenList"></div>
+          <div style="display:flex;gap:6px;align-items:center">
+            <input type="text" id="apiTokenName" placeholder="Token name, e.g. Home Assistant" style="flex:1;padding:8px 12px;border:1px solid var(--line);border-radius:6px;background:var(--bg);color:var(--text);font-size:12px">
+            <select id="apiTokenScope" style="padding:8px;border:1px solid var(--line);border-radius:6px;background:var(--bg);color:var(--text);font-size:12px">
+              <option value="read">Read only</option>
+              {{ if .User }}{{ if .User.Can "manage_series" }}<option value="manage">Manage series</option>{{ end }}{{ end }}
+              {{ if .User }}{{ if .User.IsAdmin }}<option value="admin">Admin</option>{{ end }}{{ end }}
+            </select>
+            <button id="apiTokenCreateBtn" style="padding:8px 12px;background:#10b981;color:white;border:none;border-radius:6px;cursor:pointer;font-size:12px;font-weight:500;white-space:nowrap">Create</button>
+          </div>
+          <div id="apiTokenSecret" style="display:none;flex-direction:column;gap:6px">
+            <div style="display:flex;gap:6px;align-items:center">
+              <input type="text" id="apiTokenSecretField" readonly style="flex:1;padding:8px 12px;border:1px solid var(--line);border-radius:6px;background:var(--head-bg);color:var(--text);font-size:12px;font-family:monospace;cursor:text" onclick="this.select()">
+              <button id="apiTokenCopyBtn" style="padding:8px 12px;background:var(--amz);color:white;border:none;border-radius:6px;cursor:pointer;font-size:12px;font-weight:500;white-space:nowrap">Copy</button>
+            </div>
+            <div style="color:var(--muted);font-size:.85rem">Copy this token now; it won't be shown again.</div>
+          </div>
+        </div>
+        <div class="modal-row" style="flex-direction:column;align-items:stretch;gap:10px">
+          <div>
+            <div style="font-weight:600">Password</div>
+            <div style="color:var(--muted);font-size:.9rem">Changing your password signs out your other sessions.</div>
+          </div>
+          <div style="display:flex;gap:6px;align-items:center;flex-wrap:wrap">
+            <input type="password" id="accountCurrentPassword" placeholder="Current password" autocomplete="current-password" style="flex:1;min-width:120px;padding:8px 12px;border:1px solid var(--line);border-radius:6px;background:var(--bg);color:var(--text);font-size:12px">
+            <input type="password" id="accountNewPassword" placeholder="New password" autocomplete="new-password" style="flex:1;min-width:120px;padding:8px 12px;border:1px solid var(--line);border-radius:6px;background:var(--bg);color:var(--text);font-size:12px">
+            <button id="accountPasswordBtn" style="padding:8px 12px;background:#10b981;color:white;border:none;border-radius:6px;cursor:pointer;font-size:12px;font-weight:500;white-space:nowrap">Change</button>
+          </div>
+        </div>
+        <div class="modal-row" style="flex-direction:column;align-items:stretch;gap:10px">
+          <div style="display:flex;justify-content:space-between;align-items:center">
+            <div>
+              <div style="font-weight:600">Two-Factor Authentication</div>
+              <div id="twoFactorStatus" style="color:var(--muted);font-size:.9rem">Codes from an authenticator app, asked for after your password.</div>
+            </div>
+            <button id="twoFactorSetupBtn" style="padding:6px 12px;background:#10b981;color:white;border:none;border-radius:6px;cursor:pointer;font-size:13px;font-weight:500;white-space:nowrap">Set up</button>
+          </div>
+          <div id="twoFactorEnroll" style="display:none;flex-direction:column;align-items:center;gap:8px">
+            <img id="twoFactorQR" alt="QR code" width="200" height="200" style="background:#ffffff;padding:6px;border-radius:6px">
+            <code id="twoFactorSecret" style="font-size:12px;word-break:break-all"></code>
+            <div style="display:flex;gap:6px;align-items:center;align-self:stretch">
+              <input type="text" id="twoFactorCode" placeholder="6-digit code" inputmode="numeric" autocomplete="one-time-code" style="flex:1;padding:8px 12px;border:1px solid var(--line);border-radius:6px;background:var(--bg);color:var(--text);font-size:12px">
+              <button id="twoFactorEnableBtn" style="padding:8px 12px;background:#10b981;color:white;border:none;border-radius:6px;cursor:pointer;font-size:12px;font-weight:500;white-space:nowrap">Enable</button>
+            </div>
+          </div>
+          <div id="twoFactorRecovery" style="display:none;flex-direction:column;gap:6px">
+            <code id="twoFactorRecoveryCodes" style="font-size:12px;white-space:pre-wrap;padding:8px 12px;border:1px solid var(--line);border-radius:6px;background:var(--head-bg)"></code>
+            <div style="color:var(--muted);font-size:.85rem">Store these recovery codes somewhere safe; each signs you in once if you lose your device. They won't be shown again.</div>
+          </div>
+          <div id="twoFactorDisable" style="display:none;gap:6px;align-items:center">
+            <input type="password" id="twoFactorPassword" placeholder="Password" autocomplete="current-password" style="flex:1;padding:8px 12px;border:1px solid var(--line);border-radius:6px;background:var(--bg);color:var(--text);font-size:12px">
+            <button id="twoFactorDisableBtn" style="padding:8px 12px;background:#dc2626;color:white;border:none;border-radius:6px;cursor:pointer;font-size:12px;font-weight:500;white-space:nowrap">Disable</button>
+          </div>
+        </div>
+        <div class="modal-row" style="flex-direction:column;align-items:stretch;gap:10px">
+          <div style="display:flex;justify-content:space-between;align-items:center">
+            <div>
+              <div style="font-weight:600">Sessions</div>
+              <div style="color:var(--muted);font-size:.9rem">Browsers and devices signed in to your account.</div>
+            </div>
+            <button id="logoutEverywhereBtn" style="padding:6px 12px;background:#dc2626;color:white;border:none;border-radius:6px;cursor:pointer;font-size:13px;font-weight:500;white-space:nowrap">Log out everywhere</button>
+          </div>
+          <div id="sessionList"></div>
+        </div>
         <div style="padding-top:16px;border-top:1px solid var(--line);display:flex;align-items:center;justify-content:space-between">
           <a href="https://github.com/michaeldvinci/syllabus" target="_blank" rel="noopener" style="color:var(--muted);text-decoration:none;display:flex;align-items:center;gap:4px;font-size:12px" title="View source code on GitHub">
             <svg width="16" height="16" fill="currentColor" viewBox="0 0 16 16">
@@ -664,6 +810,13 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
           </div>
           <button id="createUserBtn" style="padding:6px 12px;background:var(--aud);color:white;border:none;border-radius:6px;cursor:pointer;font-size:13px;font-weight:500">Create User</button>
         </div>
+        <div class="modal-row">
+          <div>
+            <div style="font-weight:600">Require 2FA for Admins</div>
+            <div style="color:var(--muted);font-size:.9rem">Admins without two-factor authentication enroll at their next password login.</div>
+          </div>
+          <input type="checkbox" id="requireAdmin2FA">
+        </div>
         <div style="padding-top:16px;border-top:1px solid var(--line)">
           <div style="font-weight:600;margin-bottom:12px">Existing Users</div>
           <div id="usersList" style="max-height:300px;overflow-y:auto">
@@ -698,7 +851,8 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
         <div style="margin-bottom:16px">
           <label style="display:block;margin-bottom:4px;font-weight:600">Role</label>
           <select id="newUserRole" style="width:100%;padding:8px;border:1px solid var(--line);border-radius:4px;background:var(--bg);color:var(--text)">
-            <option value="user">User</option>
+            <option value="viewer">Viewer</option>
+            <option value="editor">Editor</option>
             <option value="admin">Admin</option>
           </select>
         </div>
@@ -775,6 +929,72 @@ body{margin:0;display:flex;flex-direction:column;height:100vh;overflow:hidden;ba
     </div>
   </div>
 
+  <!-- Edit Series Modal -->
+  <div id="editSeriesModal" class="modal-overlay" role="dialog" aria-modal="true" aria-labelledby="editSeriesTitle">
+    <div class="modal-panel">
+      <div class="modal-head">
+        <div id="editSeriesTitle">Edit Series</div>
+        <button id="editSeriesClose" class="settings-btn" aria-label="Close edit series">
+          <svg width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2">
+            <line x1="18" y1="6" x2="6" y2="18"></line>
+            <line x1="6" y1="6" x2="18" y2="18"></line>
+          </svg>
+        </button>
+      </div>
+      <div class="modal-body">
+        <div style="margin-bottom:16px">
+          <label style="display:block;margin-bottom:4px;font-weight:600">Series Title</label>
+          <input type="text" id="editSeriesTitleInput" style="width:100%;padding:8px;border:1px solid var(--line);border-radius:4px;background:var(--bg);color:var(--text)">
+        </div>
+        <div style="margin-bottom:16px">
+          <label style="display:block;margin-bottom:4px;font-weight:600">Audible URL</label>
+          <input type="url" id="editSeriesAudible" style="width:100%;padding:8px;border:1px solid var(--line);border-radius:4px;background:var(--bg);color:var(--text)" placeholder="https://www.audible.com/series/Bobiverse-Audiobooks/B01M1RDL6W">
+        </div>
+        <div style="margin-bottom:16px">
+          <label style="display:block;margin-bottom:4px;font-weight:600">Amazon URL</label>
+          <input type="url" id="editSeriesAmazon" style="width:100%;padding:8px;border:1px solid var(--line);border-radius:4px;background:var(--bg);color:var(--text)" placeholder="https://www.amazon.com/dp/B0753LBFQ7">
+        </div>
+        <div style="color:var(--muted);font-size:.9rem;margin-bottom:16px">Changes apply to everyone watching this series. A changed URL is scraped again from scratch; the release history is kept.</div>
+        <div style="display:flex;gap:8px;justify-content:flex-end">
+          <button id="cancelEditSeries" style="padding:8px 16px;background:var(--bg);color:var(--text);border:1px solid var(--line);border-radius:6px;cursor:pointer">Cancel</button>
+          <button id="confirmEditSeries" style="padding:8px 16px;background:#10b981;color:white;border:none;border-radius:6px;cursor:pointer">Save</button>
+        </div>
+      </div>
+    </div>
+  </div>
+
+  <!-- Reading Progress Modal -->
+  <div id="progressModal" class="modal-overlay" role="dialog" aria-modal="true" aria-labelledby="progressTitle">
+    <div class="modal-panel">
+      <div class="modal-head">
+        <div id="progressTitle">Reading Progress</div>
+        <button id="progressClose" class="settings-btn" aria-label="Close reading progress">
+          <svg width="16" height="16" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2">
+            <line x1="18" y1="6" x2="6" y2="18"></line>
+            <line x1="6" y1="6" x2="18" y2="18"></line>
+          </svg>
+        </button>
+      </div>
+      <div class="modal-body">
+        <div id="progressSeriesTitle" style="font-weight:700;margin-bottom:12px"></div>
+        <div style="color:var(--muted);font-size:.9rem;margin-bottom:16px">Enter book numbers in the series. Use 0 for none.</div>
+        <div style="display:grid;grid-template-columns:auto 1fr 1fr;gap:8px 12px;align-items:center;margin-bottom:16px">
+          <div></div><div style="font-weight:600">Owned through</div><div style="font-weight:600">Finished through</div>
+          <div style="color:var(--aud);font-weight:600"><span class="icon-headphones"></span> Audio</div>
+          <input type="number" min="0" id="progressAudOwned" style="padding:8px;border:1px solid var(--line);border-radius:4px;background:var(--bg);color:var(--text)">
+          <input type="number" min="0" id="progressAudFinished" style="padding:8px;border:1px solid var(--line);border-radius:4px;background:var(--bg);color:var(--text)">
+          <div style="color:var(--amz);font-weight:600"><span class="icon-book"></span> Ebook</div>
+          <input type="number" min="0" id="progressEbookOwned" style="padding:8px;border:1px solid var(--line);border-radius:4px;background:var(--bg);color:var(--text)">
+          <input type="number" min="0" id="progressEbookFinished" style="padding:8px;border:1px solid var(--line);border-radius:4px;background:var(--bg);color:var(--text)">
+        </div>
+        <div style="display:flex;gap:8px;justify-content:flex-end">
+          <button id="cancelProgress" style="padding:8px 16px;background:var(--bg);color:var(--text);border:1px solid var(--line);border-radius:6px;cursor:pointer">Cancel</button>
+          <button id="confirmProgress" style="padding:8px 16px;background:#10b981;color:white;border:none;border-radius:6px;cursor:pointer">Save</button>
+        </div>
+      </div>
+    </div>
+  </div>
+
 <script>
 /* ── robust date helpers ───────────────────────── */
 const parseAnyDate = (val) => {
@@ -937,6 +1157,8 @@ function applyFilters(){
   const fAmz  = document.querySelector('#fAmzNext')?.checked;
   const fAny  = document.querySelector('#fAnyUpcoming')?.checked;
   const fNone = document.querySelector('#fNoNext')?.checked;
+  const fUnfinished = document.querySelector('#fUnfinished')?.checked;
+  const fNotOwned   = document.querySelector('#fNotOwned')?.checked;
 
   [
     Array.from(document.querySelectorAll('#seriesTbody tr')),
@@ -948,11 +1170,15 @@ function applyFilters(){
       const hasAud = !!parseAnyDate(el.dataset.audNext);
       const hasAmz = !!parseAnyDate(el.dataset.amzNext);
       const any = hasAud || hasAmz;
+      const unfinished = (+el.dataset.audUnfinished || 0) + (+el.dataset.ebookUnfinished || 0);
+      const notOwned = (+el.dataset.audNotOwned || 0) + (+el.dataset.ebookNotOwned || 0);
       let show = true;
       if(fAud && !hasAud) show = false;
       if(fAmz && !hasAmz) show = false;
       if(fAny && !any) show = false;
       if(fNone && any) show = false;
+      if(fUnfinished && !unfinished) show = false;
+      if(fNotOwned && !notOwned) show = false;
       el.style.display = show ? '' : 'none';
     });
   });
@@ -961,13 +1187,13 @@ window.applyFilters = applyFilters;
 
 function wireFilters(){
 
-  ['#fAudNext','#fAmzNext','#fAnyUpcoming','#fNoNext'].forEach(id=>{
+  ['#fAudNext','#fAmzNext','#fAnyUpcoming','#fNoNext','#fUnfinished','#fNotOwned'].forEach(id=>{
     const cb = document.querySelector(id); if(cb) cb.addEventListener('change', applyFilters);
   });
   const clearBtn = document.querySelector('#clearFilters');
   if(clearBtn){
     clearBtn.addEventListener('click', ()=>{
-      ['#fAudNext','#fAmzNext','#fAnyUpcoming','#fNoNext'].forEach(id=>{ const cb=document.querySelector(id); if(cb) cb.checked=false; });
+      ['#fAudNext','#fAmzNext','#fAnyUpcoming','#fNoNext','#fUnfinished','#fNotOwned'].forEach(id=>{ const cb=document.querySelector(id); if(cb) cb.checked=false; });
       applyFilters();
     });
   }
@@ -1224,6 +1450,8 @@ function openUsersModal(){
 }
 window.openUsersModal = openUsersModal;
 
+const currentUsername = '{{ if .User }}{{ .User.Username }}{{ end }}';
+
 function loadUsers(){
   const usersList = document.getElementById('usersList');
   if(!usersList) return;
@@ -1233,17 +1461,30 @@ function loadUsers(){
   fetch('/api/users')
     .then(response => response.json())
     .then(data => {
+      const require2FA = document.getElementById('requireAdmin2FA');
+      if(require2FA) require2FA.checked = !!data.require_admin_2fa;
       if(data.users && data.users.length > 0){
         usersList.innerHTML = data.users.map(user => 
           '<div class="user-list-item">' +
             '<div class="user-info">' +
               '<div class="user-name">' + user.username + '</div>' +
-              '<div class="user-role">' + user.role + '</div>' +
+              '<div class="user-role">' +
+                '<select class="user-role-select" onchange="setUserRole(\'' + user.username + '\', this.value)"' +
+                (user.username === currentUsername ? ' disabled title="Cannot change your own role"' : '') + '>' +
+                  ['viewer', 'editor', 'admin'].map(role =>
+                    '<option value="' + role + '"' + (user.role === role ? ' selected' : '') + '>' + role + '</option>'
+                  ).join('') +
+                '</select>' +
+              '</div>' +
             '</div>' +
             '<div class="user-actions">' +
               '<button class="reset-password-btn" onclick="openResetPasswordModal(\'' + user.username + '\')">' +
                 'Reset Password' +
               '</button>' +
+              (user.totp_enabled ?
+                '<button class="reset-password-btn" onclick="resetUserTwoFactor(\'' + user.username + '\')">' +
+                  'Reset 2FA' +
+                '</button>' : '') +
               '<button class="delete-user-btn" onclick="deleteUser(\'' + user.username + '\')" ' + 
               (user.username === 'admin' ? 'disabled title="Cannot delete admin user"' : '') + '>' +
                 'Delete' +
@@ -1261,6 +1502,50 @@ function loadUsers(){
     });
 }
 
+function setUserRole(username, role){
+  fetch('/api/users/role', {
+    method: 'POST',
+    headers: {'Content-Type': 'application/json'},
+    body: JSON.stringify({username, role})
+  })
+  .then(response => response.json())
+  .then(data => {
+    if(!data.success) alert('Failed to change role: ' + (data.message || 'Unknown error'));
+    loadUsers();
+  })
+  .catch(() => alert('Failed to change role'));
+}
+
+function resetUserTwoFactor(username){
+  if(!confirm('Turn off two-factor authentication for "' + username + '"?')) return;
+
+  fetch('/api/users/reset-2fa', {
+    method: 'POST',
+    headers: {'Content-Type': 'application/json'},
+    body: JSON.stringify({username})
+  })
+  .then(response => response.json())
+  .then(data => {
+    if(!data.success) alert('Failed to reset 2FA: ' + (data.message || 'Unknown error'));
+    loadUsers();
+  })
+  .catch(() => alert('Failed to reset 2FA'));
+}
+
+function setRequireAdmin2FA(required){
+  fetch('/api/users/require-2fa', {
+    method: 'POST',
+    headers: {'Content-Type': 'application/json'},
+    body: JSON.stringify({required})
+  })
+  .then(response => response.json())
+  .then(data => {
+    if(!data.success) alert('Failed to save setting: ' + (data.message || 'Unknown error'));
+    loadUsers();
+  })
+  .catch(() => alert('Failed to save setting'));
+}
+
 function deleteUser(username){
   if(!confirm('Are you sure you want to delete user "' + username + '"?')) return;
   
@@ -1291,7 +1576,7 @@ function openCreateUserModal(){
   // Clear form
   document.getElementById('newUsername').value = '';
   document.getElementById('newPassword').value = '';
-  document.getElementById('newUserRole').value = 'user';
+  document.getElementById('newUserRole').value = 'viewer';
   
   overlay.style.display = 'flex';
   
@@ -1585,6 +1870,147 @@ function openAddSeriesModal(){
 }
 window.openAddSeriesModal = openAddSeriesModal;
 
+/* ── edit series ─────────────────────────────────── */
+function openEditSeriesModal(btn){
+  const overlay = document.getElementById('editSeriesModal');
+  if(!overlay || !btn) return;
+  overlay.style.display = 'flex';
+
+  const seriesId = +btn.dataset.seriesId;
+  const original = { title: btn.dataset.title || '', audible: btn.dataset.audible || '', amazon: btn.dataset.amazon || '' };
+  document.getElementById('editSeriesTitleInput').value = original.title;
+  document.getElementById('editSeriesAudible').value = original.audible;
+  document.getElementById('editSeriesAmazon').value = original.amazon;
+
+  function closeEditSeriesModal(){
+    overlay.style.display = 'none';
+    document.removeEventListener('keydown', onKey);
+  }
+  const onKey = (e) => { if(e.key === 'Escape') closeEditSeriesModal(); };
+  document.addEventListener('keydown', onKey);
+
+  // Remove existing listeners
+  ['editSeriesClose','cancelEditSeries','confirmEditSeries'].forEach(id=>{
+    const el = document.getElementById(id);
+    el?.replaceWith(el.cloneNode(true));
+  });
+  document.getElementById('editSeriesClose')?.addEventListener('click', closeEditSeriesModal);
+  document.getElementById('cancelEditSeries')?.addEventListener('click', closeEditSeriesModal);
+
+  document.getElementById('confirmEditSeries')?.addEventListener('click', ()=>{
+    const title = document.getElementById('editSeriesTitleInput').value.trim();
+    const audible = document.getElementById('editSeriesAudible').value.trim();
+    const amazon = document.getElementById('editSeriesAmazon').value.trim();
+
+    if(!title){
+      alert('Series title is required.');
+      return;
+    }
+    if(!audible && !amazon){
+      alert('At least one URL (Audible or Amazon) is required.');
+      return;
+    }
+
+    // Only send what changed, so an untouched URL isn't parsed again
+    const body = {};
+    if(title !== original.title) body.title = title;
+    if(audible !== original.audible) body.audible_url = audible;
+    if(amazon !== original.amazon) body.amazon_url = amazon;
+    if(Object.keys(body).length === 0){
+      closeEditSeriesModal();
+      return;
+    }
+
+    const btn = document.getElementById('confirmEditSeries');
+    btn.disabled = true;
+    btn.textContent = 'Saving...';
+
+    fetch('/api/v1/series/' + seriesId, {
+      method: 'PATCH',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(body)
+    })
+    .then(response => response.json().then(data => {
+      if(!response.ok) throw new Error(data.error || response.statusText);
+    }))
+    .then(() => {
+      closeEditSeriesModal();
+      window.location.reload();
+    })
+    .catch(err => {
+      console.error('Edit series failed:', err);
+      alert('Failed to save series: ' + err.message);
+    })
+    .finally(() => {
+      btn.disabled = false;
+      btn.textContent = 'Save';
+    });
+  });
+}
+window.openEditSeriesModal = openEditSeriesModal;
+
+/* ── reading progress ──────────────────────────── */
+function openProgressModal(btn){
+  const overlay = document.getElementById('progressModal');
+  if(!overlay || !btn) return;
+  overlay.style.display = 'flex';
+
+  const seriesId = +btn.dataset.seriesId;
+  document.getElementById('progressSeriesTitle').textContent = btn.dataset.title || '';
+  document.getElementById('progressAudOwned').value = btn.dataset.audOwned || 0;
+  document.getElementById('progressAudFinished').value = btn.dataset.audFinished || 0;
+  document.getElementById('progressEbookOwned').value = btn.dataset.ebookOwned || 0;
+  document.getElementById('progressEbookFinished').value = btn.dataset.ebookFinished || 0;
+
+  function closeProgressModal(){
+    overlay.style.display = 'none';
+    document.removeEventListener('keydown', onKey);
+  }
+  const onKey = (e) => { if(e.key === 'Escape') closeProgressModal(); };
+  document.addEventListener('keydown', onKey);
+
+  // Remove existing listeners
+  ['progressClose','cancelProgress','confirmProgress'].forEach(id=>{
+    const el = document.getElementById(id);
+    el?.replaceWith(el.cloneNode(true));
+  });
+  document.getElementById('progressClose')?.addEventListener('click', closeProgressModal);
+  document.getElementById('cancelProgress')?.addEventListener('click', closeProgressModal);
+
+  document.getElementById('confirmProgress')?.addEventListener('click', ()=>{
+    const num = (id) => Math.max(0, parseInt(document.getElementById(id).value, 10) || 0);
+    const updates = [
+      { series_id: seriesId, format: 'audio', owned_through: num('progressAudOwned'), finished_through: num('progressAudFinished') },
+      { series_id: seriesId, format: 'ebook', owned_through: num('progressEbookOwned'), finished_through: num('progressEbookFinished') }
+    ];
+
+    const btn = document.getElementById('confirmProgress');
+    btn.disabled = true;
+    btn.textContent = 'Saving...';
+
+    Promise.all(updates.map(body => fetch('/api/progress', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(body)
+    }).then(response => {
+      if(!response.ok) return response.text().then(text => { throw new Error(text); });
+    })))
+    .then(() => {
+      closeProgressModal();
+      window.location.reload();
+    })
+    .catch(err => {
+      console.error('Save progress failed:', err);
+      alert('Failed to save reading progress: ' + err.message);
+    })
+    .finally(() => {
+      btn.disabled = false;
+      btn.textContent = 'Save';
+    });
+  }, { once: true });
+}
+window.openProgressModal = openProgressModal;
+
 /* ── ical export ───────────────────────────────── */
 function wireIcalExport(){
   const downloadBtn = document.getElementById('icalExportBtn');
@@ -1636,6 +2062,303 @@ function wireIcalExport(){
   }
 }
 
+/* ── api tokens ────────────────────────────────── */
+function loadApiTokens(){
+  const list = document.getElementById('apiTokenList');
+  if(!list) return;
+
+  fetch('/api/tokens')
+    .then(r => r.json())
+    .then(data => {
+      list.innerHTML = '';
+      const tokens = data.tokens || [];
+      if(tokens.length === 0){
+        const empty = document.createElement('div');
+        empty.style.cssText = 'color:var(--muted);font-size:.85rem';
+        empty.textContent = 'No API tokens yet.';
+        list.appendChild(empty);
+        return;
+      }
+      tokens.forEach(token => {
+        const item = document.createElement('div');
+        item.className = 'user-list-item';
+
+        const info = document.createElement('div');
+        info.className = 'user-info';
+        const name = document.createElement('div');
+        name.className = 'user-name';
+        name.textContent = token.name + ' (…' + token.hint + ')';
+        const meta = document.createElement('div');
+        meta.className = 'user-role';
+        const used = token.last_used_at ? 'last used ' + new Date(token.last_used_at).toLocaleString() : 'never used';
+        meta.textContent = token.scope + ' · created ' + new Date(token.created_at).toLocaleDateString() + ' · ' + used;
+        info.appendChild(name);
+        info.appendChild(meta);
+
+        const revoke = document.createElement('button');
+        revoke.className = 'delete-user-btn';
+        revoke.textContent = 'Revoke';
+        revoke.addEventListener('click', () => revokeApiToken(token.id, token.name));
+
+        item.appendChild(info);
+        item.appendChild(revoke);
+        list.appendChild(item);
+      });
+    })
+    .catch(err => console.error('Failed to load API tokens:', err));
+}
+
+function revokeApiToken(id, name){
+  if(!confirm('Revoke token "' + name + '"? Anything using it will stop working.')) return;
+  fetch('/api/tokens/revoke', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ id })
+  })
+  .then(r => r.json())
+  .then(data => {
+    if(!data.success) alert('Failed to revoke token: ' + (data.message || 'Unknown error'));
+    loadApiTokens();
+  })
+  .catch(() => alert('Failed to revoke token'));
+}
+
+function wireApiTokens(){
+  const createBtn = document.getElementById('apiTokenCreateBtn');
+  const nameField = document.getElementById('apiTokenName');
+  const scopeField = document.getElementById('apiTokenScope');
+  const secretBox = document.getElementById('apiTokenSecret');
+  const secretField = document.getElementById('apiTokenSecretField');
+  const copyBtn = document.getElementById('apiTokenCopyBtn');
+  if(!createBtn || !nameField || !scopeField) return;
+
+  createBtn.addEventListener('click', ()=>{
+    const name = nameField.value.trim();
+    if(!name){
+      alert('Token name is required.');
+      return;
+    }
+    fetch('/api/tokens/create', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ name, scope: scopeField.value })
+    })
+    .then(r => r.json())
+    .then(data => {
+      if(!data.success){
+        alert('Failed to create token: ' + (data.message || 'Unknown error'));
+        return;
+      }
+      nameField.value = '';
+      secretField.value = data.secret;
+      secretBox.style.display = 'flex';
+      secretField.select();
+      loadApiTokens();
+    })
+    .catch(() => alert('Failed to create token'));
+  });
+
+  if(copyBtn && secretField){
+    copyBtn.addEventListener('click', ()=>{
+      secretField.select();
+      if(navigator.clipboard){
+        navigator.clipboard.writeText(secretField.value).catch(()=> document.execCommand('copy'));
+      } else {
+        document.execCommand('copy');
+      }
+      copyBtn.textContent = 'Copied!';
+      setTimeout(()=>{ copyBtn.textContent = 'Copy'; }, 2000);
+    });
+  }
+
+  loadApiTokens();
+}
+
+/* ── account ───────────────────────────────────── */
+function loadSessions(){
+  const list = document.getElementById('sessionList');
+  if(!list) return;
+
+  fetch('/api/me/sessions')
+    .then(r => r.json())
+    .then(data => {
+      list.innerHTML = '';
+      (data.sessions || []).forEach(session => {
+        const item = document.createElement('div');
+        item.className = 'user-list-item';
+
+        const info = document.createElement('div');
+        info.className = 'user-info';
+        const name = document.createElement('div');
+        name.className = 'user-name';
+        name.textContent = (session.user_agent || 'Unknown device') + (session.current ? ' (this session)' : '');
+        const meta = document.createElement('div');
+        meta.className = 'user-role';
+        meta.textContent = (session.ip || 'unknown address') + ' · last seen ' + new Date(session.last_seen_at).toLocaleString();
+        info.appendChild(name);
+        info.appendChild(meta);
+        item.appendChild(info);
+
+        if(!session.current){
+          const revoke = document.createElement('button');
+          revoke.className = 'delete-user-btn';
+          revoke.textContent = 'Revoke';
+          revoke.addEventListener('click', () => revokeSession(session.id));
+          item.appendChild(revoke);
+        }
+        list.appendChild(item);
+      });
+    })
+    .catch(err => console.error('Failed to load sessions:', err));
+}
+
+function revokeSession(id){
+  fetch('/api/me/sessions/revoke', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ id })
+  })
+  .then(r => r.json())
+  .then(data => {
+    if(!data.success) alert('Failed to revoke session: ' + (data.message || 'Unknown error'));
+    loadSessions();
+  })
+  .catch(() => alert('Failed to revoke session'));
+}
+
+function loadTwoFactor(){
+  const status = document.getElementById('twoFactorStatus');
+  if(!status) return;
+
+  fetch('/api/me/2fa')
+    .then(r => r.json())
+    .then(data => {
+      document.getElementById('twoFactorSetupBtn').style.display = data.enabled ? 'none' : '';
+      document.getElementById('twoFactorDisable').style.display = data.enabled && !data.required ? 'flex' : 'none';
+      if(data.enabled){
+        status.textContent = 'On · ' + data.recovery_codes_remaining + ' recovery codes left' + (data.required ? ' · required for admins' : '');
+      } else {
+        status.textContent = data.required ? 'Required for admins; set it up now.' : 'Codes from an authenticator app, asked for after your password.';
+      }
+    })
+    .catch(err => console.error('Failed to load two-factor status:', err));
+}
+
+function wireTwoFactor(){
+  const setupBtn = document.getElementById('twoFactorSetupBtn');
+  const enroll = document.getElementById('twoFactorEnroll');
+  if(setupBtn && enroll){
+    setupBtn.addEventListener('click', ()=>{
+      fetch('/api/me/2fa/setup', { method: 'POST' })
+        .then(r => r.json())
+        .then(data => {
+          if(!data.success){
+            alert('Failed to set up 2FA: ' + (data.message || 'Unknown error'));
+            return;
+          }
+          document.getElementById('twoFactorQR').src = data.qr_code;
+          document.getElementById('twoFactorSecret').textContent = data.secret;
+          enroll.style.display = 'flex';
+        })
+        .catch(() => alert('Failed to set up 2FA'));
+    });
+  }
+
+  const enableBtn = document.getElementById('twoFactorEnableBtn');
+  const codeField = document.getElementById('twoFactorCode');
+  if(enableBtn && codeField){
+    enableBtn.addEventListener('click', ()=>{
+      fetch('/api/me/2fa/enable', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ code: codeField.value })
+      })
+      .then(r => r.json())
+      .then(data => {
+        if(!data.success){
+          alert('Failed to enable 2FA: ' + (data.message || 'Unknown error'));
+          return;
+        }
+        codeField.value = '';
+        enroll.style.display = 'none';
+        document.getElementById('twoFactorRecoveryCodes').textContent = data.recovery_codes.join('\n');
+        document.getElementById('twoFactorRecovery').style.display = 'flex';
+        loadTwoFactor();
+      })
+      .catch(() => alert('Failed to enable 2FA'));
+    });
+  }
+
+  const disableBtn = document.getElementById('twoFactorDisableBtn');
+  const passwordField = document.getElementById('twoFactorPassword');
+  if(disableBtn && passwordField){
+    disableBtn.addEventListener('click', ()=>{
+      fetch('/api/me/2fa/disable', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ password: passwordField.value })
+      })
+      .then(r => r.json())
+      .then(data => {
+        if(!data.success){
+          alert('Failed to disable 2FA: ' + (data.message || 'Unknown error'));
+          return;
+        }
+        passwordField.value = '';
+        document.getElementById('twoFactorRecovery').style.display = 'none';
+        loadTwoFactor();
+      })
+      .catch(() => alert('Failed to disable 2FA'));
+    });
+  }
+
+  const require2FA = document.getElementById('requireAdmin2FA');
+  if(require2FA){
+    require2FA.addEventListener('change', () => setRequireAdmin2FA(require2FA.checked));
+  }
+
+  loadTwoFactor();
+}
+
+function wireAccount(){
+  const passwordBtn = document.getElementById('accountPasswordBtn');
+  const currentField = document.getElementById('accountCurrentPassword');
+  const newField = document.getElementById('accountNewPassword');
+  if(passwordBtn && currentField && newField){
+    passwordBtn.addEventListener('click', ()=>{
+      fetch('/api/me/password', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ current_password: currentField.value, new_password: newField.value })
+      })
+      .then(r => r.json())
+      .then(data => {
+        if(!data.success){
+          alert('Failed to change password: ' + (data.message || 'Unknown error'));
+          return;
+        }
+        currentField.value = '';
+        newField.value = '';
+        alert('Password changed. Your other sessions have been signed out.');
+        loadSessions();
+      })
+      .catch(() => alert('Failed to change password'));
+    });
+  }
+
+  const logoutAllBtn = document.getElementById('logoutEverywhereBtn');
+  if(logoutAllBtn){
+    logoutAllBtn.addEventListener('click', ()=>{
+      if(!confirm('Sign out of every session, including this one?')) return;
+      fetch('/api/me/sessions/revoke-all', { method: 'POST' })
+        .then(() => { window.location.href = '/login'; })
+        .catch(() => alert('Failed to log out everywhere'));
+    });
+  }
+
+  loadSessions();
+}
+
 /* ── background task monitoring ─────────────────── */
 function startPolling(){
   if(POLLING_ACTIVE) return; // Already polling
@@ -1708,6 +2431,9 @@ function initDashboard(){
   wireSearch();
   wireSettings();
   wireIcalExport();
+  wireApiTokens();
+  wireAccount();
+  wireTwoFactor();
   wireUserManagement();
   initTabbedView();
   computeTilesAndDecorate();
@@ -1755,7 +2481,7 @@ function deleteSelected() {
   const seriesTitles = Array.from(checkedBoxes).map(cb => cb.value);
   const count = seriesTitles.length;
   
-  if (!confirm('Are you sure you want to delete ' + count + ' series? This action cannot be undone.')) {
+  if (!confirm('Remove ' + count + ' series from your watchlist?')) {
     return;
   }
   
@@ -1973,3 +2699,95 @@ function initTabbedView() {
 </body>
 </html>
 `
+
+// SeriesHTML is the series detail page: the book catalog from each provider
+// and the timeline of release changes seen while scraping
+const SeriesHTML = `{{ define "bookItems" }}{{ range . }}<li class="{{ if .Preorder }}book-preorder{{ end }}"><span class="book-pos">{{ if .Position }}#{{ .Position }}{{ else }}–{{ end }}</span><span class="book-title">{{ .Title }}</span><span>{{ if .Date }}{{ .Date }}{{ end }}{{ if .Preorder }} (preorder){{ end }}</span></li>{{ end }}{{ end }}
+<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Series.Title }} · syllabus</title>
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<link rel="icon" href="/static/favicon.ico" type="image/x-icon">
+<script>try{ if(localStorage.getItem('syll_theme') === 'dark'){ document.documentElement.setAttribute('data-theme', 'dark'); } }catch(e){}</script>
+<style>
+:root{
+  --bg:#ffffff;--text:#111827;--muted:#6b7280;--line:#e5e7eb;--head-bg:#f9fafb;--row-hover:#f3f4f6;
+  --aud:#0ea5e9;--amz:#f59e0b
+}
+[data-theme="dark"]{
+  --bg:#1a1a1a;--text:#e5e7eb;--muted:#9ca3af;--line:#374151;--head-bg:#2a2a2a;--row-hover:#374151;
+  --aud:#4a9eff;--amz:#ff9500
+}
+body{margin:0;background:var(--bg);color:var(--text);font-family:system-ui,-apple-system,sans-serif}
+main{max-width:860px;margin:0 auto;padding:24px}
+a{color:inherit}
+.back{color:var(--muted);text-decoration:none;font-size:14px}
+.back:hover{color:var(--text)}
+h1{font-size:26px;margin:12px 0 4px}
+h2{font-size:16px;margin:0 0 12px}
+.links{display:flex;gap:12px;font-size:14px;color:var(--muted);margin-bottom:24px}
+.panels{display:grid;grid-template-columns:repeat(auto-fit,minmax(280px,1fr));gap:16px;margin-bottom:32px}
+.panel{border:1px solid var(--line);border-radius:10px;padding:16px;background:var(--head-bg)}
+.panel.aud h2{color:var(--aud)}
+.panel.amz h2{color:var(--amz)}
+.summary{font-size:14px;color:var(--muted);margin-bottom:8px}
+.books{list-style:none;margin:0;padding:0;font-size:14px}
+.books li{display:grid;grid-template-columns:2.5em 1fr auto;gap:8px;padding:4px 0;border-bottom:1px solid var(--line)}
+.books li:last-child{border-bottom:none}
+.book-pos{color:var(--muted)}
+.book-preorder .book-title{font-style:italic}
+.empty{color:var(--muted);font-size:14px}
+.timeline{list-style:none;margin:0;padding:0 0 0 16px;border-left:2px solid var(--line)}
+.timeline li{position:relative;padding:0 0 16px 12px;font-size:14px}
+.timeline li::before{content:"";position:absolute;left:-23px;top:4px;width:10px;height:10px;border-radius:50%;background:var(--muted)}
+.timeline li.ev-announced::before{background:#10b981}
+.timeline li.ev-date_changed::before{background:#ef4444}
+.timeline li.ev-released::before{background:#3b82f6}
+.timeline .when{color:var(--muted);font-size:12px}
+.timeline .prov-audible{color:var(--aud);font-weight:600}
+.timeline .prov-amazon{color:var(--amz);font-weight:600}
+</style>
+</head>
+<body>
+<main>
+  <a class="back" href="/">← All series</a>
+  <h1>{{ .Series.Title }}</h1>
+  <div class="links">
+    {{ if .Series.AudibleURL }}<a href="{{ .Series.AudibleURL }}" target="_blank" rel="noopener">Audible</a>{{ end }}
+    {{ if .Series.AmazonURL }}<a href="{{ .Series.AmazonURL }}" target="_blank" rel="noopener">Amazon</a>{{ end }}
+  </div>
+
+  <div class="panels">
+    <section class="panel aud">
+      <h2>Audible</h2>
+      <div class="summary">{{ .Series.AudibleCount }} books{{ if .Series.AudibleNext }} · next {{ .Series.AudibleNext }}{{ end }}{{ if .Series.AudibleStale }} · stale since {{ .Series.AudibleStale }}{{ end }}</div>
+      {{ with .Series.Audio }}{{ if or .Owned .Finished }}<div class="summary">You own through #{{ .Owned }} and have {{ .Verb }} through #{{ .Finished }}{{ if .Unfinished }} · {{ .Unfinished }} un{{ .Verb }}{{ end }}{{ if .NotOwned }} · {{ .NotOwned }} not owned{{ end }}</div>{{ end }}{{ end }}
+      {{ if .Series.AudibleBooks }}<ol class="books">{{ template "bookItems" .Series.AudibleBooks }}</ol>{{ else }}<div class="empty">No books scraped yet</div>{{ end }}
+    </section>
+    <section class="panel amz">
+      <h2>Amazon</h2>
+      <div class="summary">{{ .Series.AmazonCount }} books{{ if .Series.AmazonNext }} · next {{ .Series.AmazonNext }}{{ end }}{{ if .Series.AmazonStale }} · stale since {{ .Series.AmazonStale }}{{ end }}</div>
+      {{ with .Series.Ebook }}{{ if or .Owned .Finished }}<div class="summary">You own through #{{ .Owned }} and have {{ .Verb }} through #{{ .Finished }}{{ if .Unfinished }} · {{ .Unfinished }} un{{ .Verb }}{{ end }}{{ if .NotOwned }} · {{ .NotOwned }} not owned{{ end }}</div>{{ end }}{{ end }}
+      {{ if .Series.AmazonBooks }}<ol class="books">{{ template "bookItems" .Series.AmazonBooks }}</ol>{{ else }}<div class="empty">No books scraped yet</div>{{ end }}
+    </section>
+  </div>
+
+  <h2>Release history</h2>
+  {{ if .Events }}
+  <ul class="timeline">
+    {{ range .Events }}
+    <li class="ev-{{ .Type }}">
+      <div class="when">{{ .When }} · <span class="prov-{{ .Provider }}">{{ .Provider }}</span></div>
+      <div><strong>{{ if .Position }}#{{ .Position }} {{ end }}{{ .Book }}</strong> {{ .Detail }}</div>
+    </li>
+    {{ end }}
+  </ul>
+  {{ else }}
+  <div class="empty">No release changes seen yet. Announcements, date changes and releases appear here as later scrapes find them.</div>
+  {{ end }}
+</main>
+</body>
+</html>
+`
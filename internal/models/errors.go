@@ -0,0 +1,70 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrorCategory classifies why a provider scrape failed
+type ErrorCategory string
+
+// Scrape error categories stored in scrape_jobs.error_category
+const (
+	ErrorBlocked    ErrorCategory = "blocked"     // CAPTCHA or bot-detection page
+	ErrorNotFound   ErrorCategory = "not_found"   // Series or product page doesn't exist
+	ErrorHTTPStatus ErrorCategory = "http_status" // Any other non-200 response
+	ErrorTimeout    ErrorCategory = "timeout"     // Request exceeded the client timeout
+	ErrorNetwork    ErrorCategory = "network"     // Connection or read failure
+	ErrorParse      ErrorCategory = "parse"       // Page loaded but no series data could be extracted
+)
+
+// ScrapeError is returned by providers when a page couldn't be scraped
+type ScrapeError struct {
+	Provider   string
+	Category   ErrorCategory
+	URL        string
+	StatusCode int // HTTP status, 0 when no response was received
+	Err        error
+}
+
+func (e *ScrapeError) Error() string {
+	return e.Provider + ": " + e.Err.Error()
+}
+
+func (e *ScrapeError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the failure is likely transient: timeouts,
+// network errors, throttling/bot detection and 5xx responses
+func (e *ScrapeError) Retryable() bool {
+	switch e.Category {
+	case ErrorTimeout, ErrorNetwork, ErrorBlocked:
+		return true
+	case ErrorHTTPStatus:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// IsRetryable reports whether err is a ScrapeError worth retrying
+func IsRetryable(err error) bool {
+	var se *ScrapeError
+	return errors.As(err, &se) && se.Retryable()
+}
+
+// CategoryOf returns the category of a ScrapeError in err's chain, or "" if there is none
+func CategoryOf(err error) ErrorCategory {
+	var se *ScrapeError
+	if errors.As(err, &se) {
+		return se.Category
+	}
+	return ""
+}
+
+// ScrapeFailure describes the most recent failed scrape of a series on one provider
+type ScrapeFailure struct {
+	Category ErrorCategory
+	Message  string
+	FailedAt *time.Time
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"context"
+	"strconv"
+	"time"
+)
 
 // Config represents the YAML configuration structure
 type Config struct {
@@ -16,6 +20,43 @@ type Settings struct {
 	CacheTimeout        int    `yaml:"cache_timeout,omitempty"`         // Cache timeout in hours (default: 6)
 	LogLevel           string  `yaml:"log_level,omitempty"`             // Log level: debug, info, warn, error (default: info)
 	MainView           string  `yaml:"main_view,omitempty"`             // Default view mode: unified, tabbed (default: unified)
+	MaxScrapeAttempts   int    `yaml:"max_scrape_attempts,omitempty"`   // Attempts per scrape job before giving up on transient errors (default: 3)
+	AudibleRateLimit    int    `yaml:"audible_rate_limit_ms,omitempty"`  // Minimum milliseconds between Audible requests across all workers (default: 500, can't be disabled)
+	AmazonRateLimit     int    `yaml:"amazon_rate_limit_ms,omitempty"`   // Minimum milliseconds between Amazon requests across all workers (default: 1500, can't be disabled)
+	CaptchaCooldown     int    `yaml:"captcha_cooldown,omitempty"`       // Minutes to pause a provider after a CAPTCHA (default: 30)
+	DisablePasswordLogin bool  `yaml:"disable_password_login,omitempty"` // Only allow single sign-on logins (default: false)
+	OIDC         *OIDCSettings `yaml:"oidc,omitempty"`                   // OpenID Connect single sign-on (default: disabled)
+	ProxyAuth *ProxyAuthSettings `yaml:"proxy_auth,omitempty"`            // Trusted reverse proxy authentication (default: disabled)
+	TrustedOrigins []string    `yaml:"trusted_origins,omitempty"`        // Extra origins allowed to post, e.g. https://books.example.com when a proxy rewrites Host
+	TrustedProxies []string    `yaml:"trusted_proxies,omitempty"`        // Reverse proxies whose X-Forwarded-For names the client, for login throttling (default: none; proxy_auth's are added)
+	SyncDryRun     bool        `yaml:"sync_dry_run,omitempty"`           // Log what loading the audiobooks list would add, update and remove without doing it (default: false)
+	WriteBack      bool        `yaml:"write_back,omitempty"`             // Write series added or edited in the UI back to this file (default: false)
+}
+
+// ProxyAuthSettings configures authentication by a trusted reverse proxy
+type ProxyAuthSettings struct {
+	UserHeader     string   `yaml:"user_header,omitempty"`   // Header with the username (default: Remote-User)
+	GroupsHeader   string   `yaml:"groups_header,omitempty"` // Header with comma-separated groups, e.g. Remote-Groups
+	TrustedProxies []string `yaml:"trusted_proxies"`         // CIDRs or addresses allowed to set the headers
+	AdminGroups    []string `yaml:"admin_groups,omitempty"`  // Groups that grant admin; if set, roles follow the groups header
+	EditorGroups   []string `yaml:"editor_groups,omitempty"` // Groups that grant editor
+	DefaultRole    string   `yaml:"default_role,omitempty"`  // Role for everyone else, and new users when roles are managed locally (default: viewer)
+}
+
+// OIDCSettings configures OpenID Connect single sign-on
+type OIDCSettings struct {
+	Issuer            string   `yaml:"issuer"`                        // Issuer URL, e.g. https://auth.example.com/application/o/syllabus/
+	ClientID          string   `yaml:"client_id"`
+	ClientSecret      string   `yaml:"client_secret"`
+	RedirectURL       string   `yaml:"redirect_url"`                  // Public URL of /auth/oidc/callback
+	DisplayName       string   `yaml:"display_name,omitempty"`        // Login button label (default: SSO)
+	Scopes            []string `yaml:"scopes,omitempty"`              // Requested scopes (default: openid, profile, email)
+	UsernameClaim     string   `yaml:"username_claim,omitempty"`      // Claim used as the username (default: preferred_username)
+	RoleClaim         string   `yaml:"role_claim,omitempty"`          // Claim holding groups or roles (default: groups)
+	AdminValues       []string `yaml:"admin_values,omitempty"`        // Role claim values that grant admin; if set, roles follow the claim on every login
+	EditorValues      []string `yaml:"editor_values,omitempty"`       // Role claim values that grant editor
+	DefaultRole       string   `yaml:"default_role,omitempty"`        // Role for everyone else, and new users when roles are managed locally (default: viewer)
+	LinkExistingUsers bool     `yaml:"link_existing_users,omitempty"` // Sign in to an existing local user with the same username
 }
 
 // GetSettings returns the settings with defaults applied and environment variable overrides
@@ -45,6 +86,18 @@ func (c *Config) GetSettings() Settings {
 	if settings.MainView == "" {
 		settings.MainView = "unified"
 	}
+	if settings.MaxScrapeAttempts == 0 {
+		settings.MaxScrapeAttempts = 3
+	}
+	if settings.AudibleRateLimit == 0 {
+		settings.AudibleRateLimit = 500
+	}
+	if settings.AmazonRateLimit == 0 {
+		settings.AmazonRateLimit = 1500
+	}
+	if settings.CaptchaCooldown == 0 {
+		settings.CaptchaCooldown = 30
+	}
 	
 	return settings
 }
@@ -68,30 +121,85 @@ type SeriesIDs struct {
 	AudibleID  string
 	AudibleURL string
 	AmazonASIN string
+	AmazonURL  string
 	Original   Entry
+
+	AudibleOverride Override // Values pinned by aud_num, aud_last and aud_next
+	AmazonOverride  Override // Values pinned by amzn_num, amzn_last and amzn_next
+}
+
+// Override holds one provider's values pinned by hand, for series a storefront
+// lists wrongly. Pinned values take precedence over scraped ones; nil fields
+// aren't pinned.
+type Override struct {
+	Count      *int
+	LatestDate *time.Time
+	NextDate   *time.Time
+}
+
+// IsZero reports whether nothing is pinned
+func (o Override) IsZero() bool {
+	return o.Count == nil && o.LatestDate == nil && o.NextDate == nil
+}
+
+// String formats the pinned values as count,latest,next, leaving unpinned
+// ones empty
+func (o Override) String() string {
+	var count, latest, next string
+	if o.Count != nil {
+		count = strconv.Itoa(*o.Count)
+	}
+	if o.LatestDate != nil {
+		latest = o.LatestDate.Format("2006-01-02")
+	}
+	if o.NextDate != nil {
+		next = o.NextDate.Format("2006-01-02")
+	}
+	return count + "," + latest + "," + next
+}
+
+// Book describes a single title in a series as listed by a provider
+type Book struct {
+	Title       string
+	Position    int // Position in the series, 0 when the provider doesn't list one
+	ASIN        string
+	ReleaseDate *time.Time
+	IsPreorder  bool
 }
 
 // SeriesInfo contains aggregated information about a series
 type SeriesInfo struct {
+	ID                 int // Database series ID, 0 for results straight from a provider
 	Title              string
 	AudibleCount       int
 	AudibleLatestTitle string
 	AudibleLatestDate  *time.Time
 	AudibleNextTitle   string
 	AudibleNextDate    *time.Time
+	AudibleBooks       []Book
+	AudibleError       *ScrapeFailure // Last failed scrape, nil when the last scrape succeeded
+	AudibleStaleSince  *time.Time     // Set while the data shown is from before a failed refresh
 
 	AmazonCount       int
 	AmazonLatestTitle string
 	AmazonLatestDate  *time.Time
 	AmazonNextTitle   string
 	AmazonNextDate    *time.Time
+	AmazonBooks       []Book
+	AmazonError       *ScrapeFailure
+	AmazonStaleSince  *time.Time
+
+	// Values pinned by hand, already applied to the fields above
+	AudibleOverride Override
+	AmazonOverride  Override
 
 	AudibleID  string
 	AmazonASIN string
 	Err        error
 }
 
-// Provider defines the interface for data providers
+// Provider defines the interface for data providers. Fetch must stop and
+// return promptly once ctx is cancelled or its deadline passes.
 type Provider interface {
-	Fetch(entry SeriesIDs) (SeriesInfo, error)
+	Fetch(ctx context.Context, entry SeriesIDs) (SeriesInfo, error)
 }
\ No newline at end of file
@@ -2,21 +2,56 @@ package scraper
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand/v2"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/michaeldvinci/syllabus/internal/database"
 	"github.com/michaeldvinci/syllabus/internal/models"
 )
 
+// Job lease timings. Workers renew their lease while a job runs; a job whose
+// lease lapses (e.g. the process died) is claimed again by the next worker.
+const (
+	jobLease          = 2 * time.Minute
+	jobLeaseHeartbeat = 30 * time.Second
+	jobPollInterval   = 10 * time.Second
+	
+	// jobTimeout bounds a single scrape, including every page it fetches
+	jobTimeout = 10 * time.Minute
+)
+
+// Retry backoff for transient scrape failures: doubles from retryBaseDelay up
+// to retryMaxDelay, with jitter so retries from a bulk refresh spread out
+const (
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 30 * time.Minute
+)
+
 // BackgroundScraper handles background scraping operations
 type BackgroundScraper struct {
-	providers map[string]models.Provider  // Map of provider name to provider instance
-	db        *database.Service
-	jobChan   chan database.ScrapeJob
-	done      chan struct{}
-	wg        sync.WaitGroup
+	providers   map[string]models.Provider  // Map of provider name to provider instance
+	db          *database.Service
+	wake        chan struct{}               // Signals idle workers that new jobs are in the database
+	instanceID  string                      // Identifies this process in job leases
+	maxAttempts int                         // Attempts per job before a transient failure is final
+	cooldown    time.Duration               // How long a provider is paused after a CAPTCHA
+	done        chan struct{}
+	cancel      context.CancelFunc          // Aborts in-flight scrapes on Stop
+	wg          sync.WaitGroup
+	
+	// Cancel functions for the jobs this process is running, by job ID
+	runningMu sync.Mutex
+	running   map[int]context.CancelFunc
+	
+	// Circuit breaker: providers paused after bot detection, until the given time
+	pauseMu     sync.Mutex
+	pausedUntil map[string]time.Time
+	now         func() time.Time            // Clock for the breaker, replaced in tests
 	
 	// For notifying UI of updates
 	updateChan chan SeriesUpdate
@@ -29,16 +64,30 @@ type SeriesUpdate struct {
 	Provider string `json:"provider"`
 	Status   string `json:"status"`
 	Error    string `json:"error,omitempty"`
+	Category string `json:"category,omitempty"` // models.ErrorCategory of a failed scrape
+	
+	PausedUntil *time.Time `json:"paused_until,omitempty"` // Set when Status is "paused"
 }
 
-// NewBackgroundScraper creates a new background scraper with provider map
-func NewBackgroundScraper(providers map[string]models.Provider, db *database.Service) *BackgroundScraper {
+// NewBackgroundScraper creates a new background scraper with provider map.
+// A provider that returns a CAPTCHA is paused for captchaCooldown.
+func NewBackgroundScraper(providers map[string]models.Provider, db *database.Service, maxAttempts int, captchaCooldown time.Duration) *BackgroundScraper {
+	host, _ := os.Hostname()
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
 	return &BackgroundScraper{
-		providers:  providers,
-		db:         db,
-		jobChan:    make(chan database.ScrapeJob, 100), // Buffer for jobs
-		done:       make(chan struct{}),
-		updateChan: make(chan SeriesUpdate, 100),
+		providers:   providers,
+		db:          db,
+		wake:        make(chan struct{}, 100),
+		instanceID:  fmt.Sprintf("%s-%d", host, os.Getpid()),
+		maxAttempts: maxAttempts,
+		cooldown:    captchaCooldown,
+		done:        make(chan struct{}),
+		pausedUntil: make(map[string]time.Time),
+		now:         time.Now,
+		running:     make(map[int]context.CancelFunc),
+		updateChan:  make(chan SeriesUpdate, 100),
 	}
 }
 
@@ -46,6 +95,7 @@ func NewBackgroundScraper(providers map[string]models.Provider, db *database.Ser
 func (bs *BackgroundScraper) Start(ctx context.Context, workers int) {
 	log.Printf("starting %d background scraper workers", workers)
 	
+	ctx, bs.cancel = context.WithCancel(ctx)
 	for i := 0; i < workers; i++ {
 		bs.wg.Add(1)
 		go bs.worker(ctx, i)
@@ -58,6 +108,9 @@ func (bs *BackgroundScraper) Start(ctx context.Context, workers int) {
 func (bs *BackgroundScraper) Stop() {
 	log.Printf("stopping background scraper")
 	close(bs.done)
+	if bs.cancel != nil {
+		bs.cancel()
+	}
 	bs.wg.Wait()
 	close(bs.updateChan)
 }
@@ -85,26 +138,57 @@ func (bs *BackgroundScraper) QueueSeriesUpdate(seriesID int, provider string) er
 		return err
 	}
 	
-	// Send job to channel (non-blocking)
-	select {
-	case bs.jobChan <- *job:
-		log.Printf("queued scrape job %d for series %d (%s)", job.ID, seriesID, provider)
-	default:
-		log.Printf("job queue full, dropping scrape job for series %d", seriesID)
-	}
+	log.Printf("queued scrape job %d for series %d (%s)", job.ID, seriesID, provider)
+	bs.signal()
 	
 	return nil
 }
 
-// Removed dispatcher - jobs are queued directly via QueueSeriesUpdate
+// signal wakes an idle worker. The job itself lives in the database, so a
+// full wake channel only means every worker already has a pending wake-up.
+func (bs *BackgroundScraper) signal() {
+	select {
+	case bs.wake <- struct{}{}:
+	default:
+	}
+}
 
-// worker processes scraping jobs
+// worker claims and processes jobs from the database until stopped
 func (bs *BackgroundScraper) worker(ctx context.Context, workerID int) {
 	defer bs.wg.Done()
 	
+	owner := fmt.Sprintf("%s/worker-%d", bs.instanceID, workerID)
 	log.Printf("background scraper worker %d started", workerID)
 	
+	poll := time.NewTicker(jobPollInterval)
+	defer poll.Stop()
+	
 	for {
+		// Drain the queue before going idle
+		for {
+			select {
+			case <-ctx.Done():
+				log.Printf("worker %d stopping due to context cancellation", workerID)
+				return
+			case <-bs.done:
+				log.Printf("worker %d stopping", workerID)
+				return
+			default:
+			}
+			
+			job, err := bs.db.ClaimScrapeJob(owner, jobLease, bs.pausedProviders()...)
+			if err != nil {
+				log.Printf("worker %d failed to claim job: %v", workerID, err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			// More work may be waiting; let another idle worker look too
+			bs.signal()
+			bs.processJob(ctx, workerID, owner, *job)
+		}
+		
 		select {
 		case <-ctx.Done():
 			log.Printf("worker %d stopping due to context cancellation", workerID)
@@ -112,28 +196,58 @@ func (bs *BackgroundScraper) worker(ctx context.Context, workerID int) {
 		case <-bs.done:
 			log.Printf("worker %d stopping", workerID)
 			return
-		case job := <-bs.jobChan:
-			bs.processJob(workerID, job)
+		case <-bs.wake:
+		case <-poll.C:
 		}
 	}
 }
 
-// processJob processes a single scraping job
-func (bs *BackgroundScraper) processJob(workerID int, job database.ScrapeJob) {
-	log.Printf("worker %d processing job %d for series %d (%s)", workerID, job.ID, job.SeriesID, job.Provider)
+// heartbeat renews a job's lease until stop is closed. If the lease is lost
+// (the job was cancelled or reclaimed) the scrape is aborted via cancel.
+func (bs *BackgroundScraper) heartbeat(jobID int, owner string, stop <-chan struct{}, cancel context.CancelFunc) {
+	ticker := time.NewTicker(jobLeaseHeartbeat)
+	defer ticker.Stop()
 	
-	// Mark job as running
-	if err := bs.db.UpdateScrapeJob(job.ID, database.JobStatusRunning, nil, 0); err != nil {
-		log.Printf("error updating job status to running: %v", err)
-		return
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := bs.db.RenewScrapeJobLease(jobID, owner, jobLease); err != nil {
+				log.Printf("heartbeat: %v", err)
+				cancel()
+				return
+			}
+		}
 	}
+}
+
+// processJob processes a single claimed scraping job. Cancelling ctx
+// (shutdown) aborts the scrape and puts the job back on the queue.
+func (bs *BackgroundScraper) processJob(ctx context.Context, workerID int, owner string, job database.ScrapeJob) {
+	log.Printf("worker %d processing job %d for series %d (%s)", workerID, job.ID, job.SeriesID, job.Provider)
+	
+	jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+	bs.trackJob(job.ID, cancel)
+	defer bs.untrackJob(job.ID)
+	
+	// Keep the lease alive while the scrape runs
+	stopHeartbeat := make(chan struct{})
+	go bs.heartbeat(job.ID, owner, stopHeartbeat, cancel)
+	defer close(stopHeartbeat)
 	
 	// Get series details to construct SeriesIDs
 	series, err := bs.getSeriesDetails(job.SeriesID)
 	if err != nil {
-		errMsg := err.Error()
-		bs.db.UpdateScrapeJob(job.ID, database.JobStatusFailed, &errMsg, 0)
-		bs.notifyUpdate(job.SeriesID, series.Title, job.Provider, "failed", errMsg)
+		bs.db.FailScrapeJob(job.ID, owner, err)
+		bs.notifyFailure(job.SeriesID, "", job.Provider, "failed", err)
+		return
+	}
+	if series == nil {
+		err := fmt.Errorf("series %d not found", job.SeriesID)
+		bs.db.FailScrapeJob(job.ID, owner, err)
+		bs.notifyFailure(job.SeriesID, "", job.Provider, "failed", err)
 		return
 	}
 	
@@ -148,40 +262,84 @@ func (bs *BackgroundScraper) processJob(workerID int, job database.ScrapeJob) {
 	// Get the specific provider for this job
 	provider, exists := bs.providers[job.Provider]
 	if !exists {
-		errMsg := fmt.Sprintf("unknown provider: %s", job.Provider)
-		bs.db.UpdateScrapeJob(job.ID, database.JobStatusFailed, &errMsg, 0)
-		bs.notifyUpdate(job.SeriesID, series.Title, job.Provider, "failed", errMsg)
+		err := fmt.Errorf("unknown provider: %s", job.Provider)
+		bs.db.FailScrapeJob(job.ID, owner, err)
+		bs.notifyFailure(job.SeriesID, series.Title, job.Provider, "failed", err)
 		return
 	}
 	
 	// Perform the scraping with the specific provider
-	info, err := provider.Fetch(seriesIDs)
+	info, err := provider.Fetch(jobCtx, seriesIDs)
+	
+	// Interrupted scrapes aren't provider failures, so leave the data alone
+	if ctx.Err() != nil {
+		log.Printf("worker %d interrupted job %d, returning it to the queue", workerID, job.ID)
+		if err := bs.db.ReleaseScrapeJob(job.ID); err != nil {
+			log.Printf("error releasing job %d: %v", job.ID, err)
+		}
+		return
+	}
+	if errors.Is(jobCtx.Err(), context.Canceled) {
+		log.Printf("worker %d stopped job %d, which was cancelled or lost its lease", workerID, job.ID)
+		return
+	}
+	
 	if err != nil {
 		log.Printf("worker %d failed to scrape series %d: %v", workerID, job.SeriesID, err)
-		// Create empty info to clear stale data from database
-		emptyInfo := models.SeriesInfo{
-			Title: series.Title,
-			// All other fields will be zero values (0, nil) which will clear the database
+		
+		// Bot detection trips the breaker so no worker hits the provider for a while
+		var resumeAt time.Time
+		if models.CategoryOf(err) == models.ErrorBlocked {
+			resumeAt = bs.pauseProvider(job.Provider)
 		}
-		// Update database with empty data to clear stale entries
-		if err := bs.db.UpdateSeriesBooks(job.SeriesID, job.Provider, emptyInfo); err != nil {
-			log.Printf("worker %d failed to clear stale data for series %d: %v", workerID, job.SeriesID, err)
-		} else {
-			log.Printf("worker %d cleared stale data for series %d (%s)", workerID, job.SeriesID, job.Provider)
+		
+		// Transient failures go back on the queue until the attempts run out.
+		// A job cancelled or reclaimed since the scrape started is left alone.
+		status := "failed"
+		if models.IsRetryable(err) && job.Attempts < bs.maxAttempts {
+			delay := retryDelay(job.Attempts)
+			if wait := time.Until(resumeAt); wait > delay {
+				delay = wait
+			}
+			if rerr := bs.db.RescheduleScrapeJob(job.ID, owner, err, time.Now().Add(delay)); errors.Is(rerr, database.ErrLeaseLost) {
+				log.Printf("worker %d dropped failure of job %d, which was cancelled or lost its lease", workerID, job.ID)
+				return
+			} else if rerr != nil {
+				log.Printf("error rescheduling job %d: %v", job.ID, rerr)
+			} else {
+				log.Printf("worker %d will retry job %d (attempt %d/%d) in %s", workerID, job.ID, job.Attempts+1, bs.maxAttempts, delay.Round(time.Second))
+				status = "retrying"
+			}
+		}
+		if status == "failed" {
+			if ferr := bs.db.FailScrapeJob(job.ID, owner, err); errors.Is(ferr, database.ErrLeaseLost) {
+				log.Printf("worker %d dropped failure of job %d, which was cancelled or lost its lease", workerID, job.ID)
+				return
+			} else if ferr != nil {
+				log.Printf("error updating job status to failed: %v", ferr)
+			}
 		}
 		
-		errMsg := err.Error()
-		bs.db.UpdateScrapeJob(job.ID, database.JobStatusFailed, &errMsg, 0)
-		bs.notifyUpdate(job.SeriesID, series.Title, job.Provider, "failed", errMsg)
+		// Keep the last good data and flag it as stale until a scrape succeeds
+		if err := bs.db.MarkSeriesStale(job.SeriesID, job.Provider); err != nil {
+			log.Printf("worker %d failed to mark series %d (%s) stale: %v", workerID, job.SeriesID, job.Provider, err)
+		}
+		bs.notifyFailure(job.SeriesID, series.Title, job.Provider, status, err)
 		return
 	}
 	
-	// Update database with scraped data
-	if err := bs.db.UpdateSeriesBooks(job.SeriesID, job.Provider, info); err != nil {
-		errMsg := err.Error()
-		bs.db.UpdateScrapeJob(job.ID, database.JobStatusFailed, &errMsg, 0)
-		bs.notifyUpdate(job.SeriesID, series.Title, job.Provider, "failed", errMsg)
+	// Store the scraped data and complete the job, unless it was cancelled or
+	// reclaimed while the scrape ran
+	if err := bs.db.CompleteScrapeJob(job, owner, info); errors.Is(err, database.ErrLeaseLost) {
+		log.Printf("worker %d dropped result of job %d, which was cancelled or lost its lease", workerID, job.ID)
+		return
+	} else if err != nil {
 		log.Printf("worker %d failed to update series %d books: %v", workerID, job.SeriesID, err)
+		if errors.Is(bs.db.FailScrapeJob(job.ID, owner, err), database.ErrLeaseLost) {
+			return
+		}
+		bs.db.MarkSeriesStale(job.SeriesID, job.Provider)
+		bs.notifyFailure(job.SeriesID, series.Title, job.Provider, "failed", err)
 		return
 	}
 	
@@ -193,16 +351,123 @@ func (bs *BackgroundScraper) processJob(workerID int, job database.ScrapeJob) {
 		bookCount = info.AmazonCount
 	}
 	
-	// Mark job as completed
-	if err := bs.db.UpdateScrapeJob(job.ID, database.JobStatusCompleted, nil, bookCount); err != nil {
-		log.Printf("error updating job status to completed: %v", err)
-	}
-	
 	// Notify UI of successful update
 	bs.notifyUpdate(job.SeriesID, series.Title, job.Provider, "completed", "")
 	log.Printf("worker %d successfully scraped series %d (%s) - %d books", workerID, job.SeriesID, job.Provider, bookCount)
 }
 
+// CancelJob cancels a pending or running scrape job, aborting its requests if
+// this process is running it. Returns false if the job had already finished.
+// A job already cancelled in the database (e.g. by a change to the series'
+// listing) still has its requests aborted.
+func (bs *BackgroundScraper) CancelJob(jobID int) (bool, error) {
+	cancelled, err := bs.db.CancelScrapeJob(jobID)
+	if err != nil {
+		return false, err
+	}
+	
+	bs.runningMu.Lock()
+	if cancel, ok := bs.running[jobID]; ok {
+		cancel()
+	}
+	bs.runningMu.Unlock()
+	
+	if cancelled {
+		log.Printf("cancelled scrape job %d", jobID)
+	}
+	return cancelled, nil
+}
+
+// trackJob records the cancel function of a job this process is running
+func (bs *BackgroundScraper) trackJob(jobID int, cancel context.CancelFunc) {
+	bs.runningMu.Lock()
+	bs.running[jobID] = cancel
+	bs.runningMu.Unlock()
+}
+
+// untrackJob forgets a finished job
+func (bs *BackgroundScraper) untrackJob(jobID int) {
+	bs.runningMu.Lock()
+	delete(bs.running, jobID)
+	bs.runningMu.Unlock()
+}
+
+// retryDelay returns the jittered backoff before retrying after the given attempt
+func retryDelay(attempt int) time.Duration {
+	d := retryBaseDelay
+	for i := 1; i < attempt && d < retryMaxDelay; i++ {
+		d *= 2
+	}
+	if d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	// Spread retries over [d/2, d)
+	return d/2 + rand.N(d/2)
+}
+
+// pauseProvider stops workers claiming jobs for provider until the cooldown
+// ends and tells the UI. Returns when the provider resumes.
+func (bs *BackgroundScraper) pauseProvider(provider string) time.Time {
+	until := bs.now().Add(bs.cooldown)
+	
+	bs.pauseMu.Lock()
+	if until.Before(bs.pausedUntil[provider]) {
+		until = bs.pausedUntil[provider]
+	}
+	bs.pausedUntil[provider] = until
+	bs.pauseMu.Unlock()
+	
+	log.Printf("%s returned a CAPTCHA, pausing until %s", provider, until.Format(time.Kitchen))
+	
+	update := SeriesUpdate{
+		Provider:    provider,
+		Status:      "paused",
+		Error:       fmt.Sprintf("%s paused until %s", provider, until.Format(time.Kitchen)),
+		Category:    string(models.ErrorBlocked),
+		PausedUntil: &until,
+	}
+	select {
+	case bs.updateChan <- update:
+	default:
+		log.Printf("update channel full, dropping pause notice for %s", provider)
+	}
+	
+	return until
+}
+
+// pausedProviders returns the providers whose breaker is currently open,
+// forgetting any whose cooldown has ended
+func (bs *BackgroundScraper) pausedProviders() []string {
+	bs.pauseMu.Lock()
+	defer bs.pauseMu.Unlock()
+	
+	var paused []string
+	now := bs.now()
+	for provider, until := range bs.pausedUntil {
+		if now.Before(until) {
+			paused = append(paused, provider)
+		} else {
+			delete(bs.pausedUntil, provider)
+		}
+	}
+	return paused
+}
+
+// PausedUntil returns when each currently paused provider resumes
+func (bs *BackgroundScraper) PausedUntil() map[string]time.Time {
+	bs.pauseMu.Lock()
+	defer bs.pauseMu.Unlock()
+	
+	paused := make(map[string]time.Time)
+	now := bs.now()
+	for provider, until := range bs.pausedUntil {
+		if now.Before(until) {
+			paused[provider] = until
+		}
+	}
+	return paused
+}
+
 // getSeriesDetails fetches series details from database
 func (bs *BackgroundScraper) getSeriesDetails(seriesID int) (*database.Series, error) {
 	return bs.db.GetSeriesByID(seriesID)
@@ -226,9 +491,28 @@ func (bs *BackgroundScraper) notifyUpdate(seriesID int, title, provider, status,
 	}
 }
 
-// QueueAllSeriesUpdate queues scraping jobs for all series in the database
+// notifyFailure sends a failed or retrying scrape notification carrying the error category
+func (bs *BackgroundScraper) notifyFailure(seriesID int, title, provider, status string, err error) {
+	update := SeriesUpdate{
+		SeriesID: seriesID,
+		Title:    title,
+		Provider: provider,
+		Status:   status,
+		Error:    err.Error(),
+		Category: string(models.CategoryOf(err)),
+	}
+	
+	select {
+	case bs.updateChan <- update:
+	default:
+		log.Printf("update channel full, dropping update for series %d", seriesID)
+	}
+}
+
+// QueueAllSeriesUpdate queues scraping jobs for every series on at least one
+// user's watchlist. Each series is scraped once however many users watch it.
 func (bs *BackgroundScraper) QueueAllSeriesUpdate() error {
-	stats, err := bs.db.GetAllSeriesStats()
+	stats, err := bs.db.GetWatchedSeriesStats()
 	if err != nil {
 		return err
 	}
@@ -259,7 +543,15 @@ func stringValue(s *string) string {
 	return *s
 }
 
-// CleanupStaleJobs marks any running jobs as failed (for startup cleanup)
-func (bs *BackgroundScraper) CleanupStaleJobs() error {
-	return bs.db.CleanupStaleRunningJobs()
+// RecoverJobs requeues jobs interrupted by a previous shutdown. Pending jobs
+// are left as they are and resume once the workers start.
+func (bs *BackgroundScraper) RecoverJobs() error {
+	n, err := bs.db.RequeueInterruptedJobs()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("requeued %d interrupted scrape jobs", n)
+	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,291 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/database"
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+// providerFunc adapts a function to models.Provider
+type providerFunc func(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error)
+
+func (f providerFunc) Fetch(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error) {
+	return f(ctx, e)
+}
+
+// newTestDB returns a service backed by a fully migrated temporary database
+func newTestDB(t *testing.T) *database.Service {
+	t.Helper()
+	db, err := database.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return database.NewService(db)
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		max     time.Duration // Upper bound before jitter
+	}{
+		{1, retryBaseDelay},
+		{2, 2 * retryBaseDelay},
+		{3, 4 * retryBaseDelay},
+		{6, 32 * retryBaseDelay},
+		{7, retryMaxDelay}, // 64 * 30s would pass the cap
+		{20, retryMaxDelay},
+		{1000, retryMaxDelay},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			d := retryDelay(tt.attempt)
+			if d < tt.max/2 || d >= tt.max {
+				t.Fatalf("retryDelay(%d) = %s, want within [%s, %s)", tt.attempt, d, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestProcessJobRetries(t *testing.T) {
+	const maxAttempts = 3
+	scrapeErr := func(category models.ErrorCategory, status int) error {
+		return &models.ScrapeError{Provider: "audible", Category: category, StatusCode: status, Err: errors.New("scrape failed")}
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		attempt   int
+		wantRetry bool
+	}{
+		{"network error retried", scrapeErr(models.ErrorNetwork, 0), 1, true},
+		{"timeout retried", scrapeErr(models.ErrorTimeout, 0), 2, true},
+		{"server error retried", scrapeErr(models.ErrorHTTPStatus, 503), 1, true},
+		{"last attempt fails", scrapeErr(models.ErrorNetwork, 0), maxAttempts, false},
+		{"not found is permanent", scrapeErr(models.ErrorNotFound, 404), 1, false},
+		{"client error is permanent", scrapeErr(models.ErrorHTTPStatus, 403), 1, false},
+		{"parse error is permanent", scrapeErr(models.ErrorParse, 200), 1, false},
+		{"unclassified error is permanent", errors.New("boom"), 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			series, err := db.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "")
+			if err != nil {
+				t.Fatalf("upsert series: %v", err)
+			}
+			providers := map[string]models.Provider{
+				database.ProviderAudible: providerFunc(func(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error) {
+					return models.SeriesInfo{}, tt.err
+				}),
+			}
+			bs := NewBackgroundScraper(providers, db, maxAttempts, time.Minute)
+
+			if _, err := db.CreateScrapeJob(series.ID, database.ProviderAudible); err != nil {
+				t.Fatalf("CreateScrapeJob: %v", err)
+			}
+			job, err := db.ClaimScrapeJob("test-worker", time.Minute)
+			if err != nil || job == nil {
+				t.Fatalf("ClaimScrapeJob = %v, %v", job, err)
+			}
+			job.Attempts = tt.attempt
+
+			before := time.Now()
+			bs.processJob(context.Background(), 0, "test-worker", *job)
+
+			jobs, err := db.GetSeriesScrapeJobs(series.ID, 1)
+			if err != nil || len(jobs) != 1 {
+				t.Fatalf("GetSeriesScrapeJobs = %v, %v", jobs, err)
+			}
+			got := jobs[0]
+			if tt.wantRetry {
+				if got.Status != database.JobStatusPending || got.NextAttemptAt == nil {
+					t.Fatalf("job = %+v, want it pending with a retry time", got)
+				}
+				if wait := got.NextAttemptAt.Sub(before); wait < retryBaseDelay/2 || wait > retryMaxDelay+time.Second {
+					t.Errorf("retry scheduled in %s, want within the backoff range", wait)
+				}
+			} else if got.Status != database.JobStatusFailed {
+				t.Errorf("job = %+v, want it failed without a retry", got)
+			}
+		})
+	}
+}
+
+func TestPauseProvider(t *testing.T) {
+	bs := NewBackgroundScraper(nil, nil, 1, 30*time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	bs.now = func() time.Time { return now }
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		cooldown   time.Duration
+		pause      string // Provider paused at this step, "" for none
+		wantPaused []string
+		wantUntil  time.Time // Audible's resume time, zero when it isn't paused
+	}{
+		{"pause starts the cooldown", 0, 30 * time.Minute, database.ProviderAudible,
+			[]string{database.ProviderAudible}, now.Add(30 * time.Minute)},
+		{"still paused", 29 * time.Minute, 30 * time.Minute, "",
+			[]string{database.ProviderAudible}, now.Add(30 * time.Minute)},
+		{"a shorter pause doesn't cut the cooldown", 0, time.Minute, database.ProviderAudible,
+			[]string{database.ProviderAudible}, now.Add(30 * time.Minute)},
+		{"a later pause extends it", 0, 30 * time.Minute, database.ProviderAudible,
+			[]string{database.ProviderAudible}, now.Add(59 * time.Minute)},
+		{"providers pause separately", 0, 30 * time.Minute, database.ProviderAmazon,
+			[]string{database.ProviderAmazon, database.ProviderAudible}, now.Add(59 * time.Minute)},
+		{"cooldown ends", 31 * time.Minute, 30 * time.Minute, "",
+			nil, time.Time{}},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		bs.cooldown = step.cooldown
+		if step.pause != "" {
+			bs.pauseProvider(step.pause)
+			if update := <-bs.GetUpdateChannel(); update.Status != "paused" || update.Provider != step.pause {
+				t.Errorf("%s: update = %+v, want a pause notice for %s", step.name, update, step.pause)
+			}
+		}
+
+		paused := bs.pausedProviders()
+		slices.Sort(paused)
+		if !slices.Equal(paused, step.wantPaused) {
+			t.Errorf("%s: paused providers = %v, want %v", step.name, paused, step.wantPaused)
+		}
+		if until := bs.PausedUntil()[database.ProviderAudible]; !until.Equal(step.wantUntil) {
+			t.Errorf("%s: audible resumes at %s, want %s", step.name, until, step.wantUntil)
+		}
+	}
+}
+
+func TestBlockedScrapeWaitsForCooldown(t *testing.T) {
+	db := newTestDB(t)
+	series, err := db.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	blocked := &models.ScrapeError{Provider: "audible", Category: models.ErrorBlocked, Err: errors.New("CAPTCHA page returned")}
+	providers := map[string]models.Provider{
+		database.ProviderAudible: providerFunc(func(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error) {
+			return models.SeriesInfo{}, blocked
+		}),
+	}
+	bs := NewBackgroundScraper(providers, db, 3, 2*time.Hour)
+
+	if _, err := db.CreateScrapeJob(series.ID, database.ProviderAudible); err != nil {
+		t.Fatalf("CreateScrapeJob: %v", err)
+	}
+	job, err := db.ClaimScrapeJob("test-worker", time.Minute)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimScrapeJob = %v, %v", job, err)
+	}
+	bs.processJob(context.Background(), 0, "test-worker", *job)
+
+	if paused := bs.pausedProviders(); !slices.Equal(paused, []string{database.ProviderAudible}) {
+		t.Errorf("paused providers = %v, want [audible]", paused)
+	}
+	// The retry waits out the cooldown rather than the shorter backoff
+	jobs, err := db.GetSeriesScrapeJobs(series.ID, 1)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("GetSeriesScrapeJobs = %v, %v", jobs, err)
+	}
+	if next := jobs[0].NextAttemptAt; jobs[0].Status != database.JobStatusPending || next == nil || time.Until(*next) < 2*time.Hour-time.Minute {
+		t.Errorf("job = %+v, want it retried once the 2h cooldown ends", jobs[0])
+	}
+}
+
+func TestProcessJobMissingSeries(t *testing.T) {
+	db := newTestDB(t)
+	series, err := db.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	fetched := false
+	providers := map[string]models.Provider{
+		database.ProviderAudible: providerFunc(func(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error) {
+			fetched = true
+			return models.SeriesInfo{}, nil
+		}),
+	}
+	bs := NewBackgroundScraper(providers, db, 3, time.Minute)
+
+	if _, err := db.CreateScrapeJob(series.ID, database.ProviderAudible); err != nil {
+		t.Fatalf("CreateScrapeJob: %v", err)
+	}
+	job, err := db.ClaimScrapeJob("test-worker", time.Minute)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimScrapeJob = %v, %v", job, err)
+	}
+	if err := db.DeleteSeries(series.ID); err != nil {
+		t.Fatalf("DeleteSeries: %v", err)
+	}
+	bs.processJob(context.Background(), 0, "test-worker", *job)
+
+	if fetched {
+		t.Error("provider fetched a series that no longer exists")
+	}
+	select {
+	case update := <-bs.GetUpdateChannel():
+		if update.SeriesID != series.ID || update.Status != "failed" || !strings.Contains(update.Error, "not found") {
+			t.Errorf("update = %+v, want a failure for series %d", update, series.ID)
+		}
+	default:
+		t.Error("no update sent for the failed job")
+	}
+}
+
+func TestProcessJobLostLease(t *testing.T) {
+	db := newTestDB(t)
+	series, err := db.UpsertSeries("The Example Saga", "B0AUDSERIE", "", "")
+	if err != nil {
+		t.Fatalf("upsert series: %v", err)
+	}
+	// The job is cancelled while the scrape runs, which then fails
+	var job *database.ScrapeJob
+	providers := map[string]models.Provider{
+		database.ProviderAudible: providerFunc(func(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error) {
+			db.CancelScrapeJob(job.ID)
+			return models.SeriesInfo{}, &models.ScrapeError{Provider: "audible", Category: models.ErrorNetwork, Err: errors.New("connection reset")}
+		}),
+	}
+	bs := NewBackgroundScraper(providers, db, 3, time.Minute)
+
+	if _, err := db.CreateScrapeJob(series.ID, database.ProviderAudible); err != nil {
+		t.Fatalf("CreateScrapeJob: %v", err)
+	}
+	if job, err = db.ClaimScrapeJob("test-worker", time.Minute); err != nil || job == nil {
+		t.Fatalf("ClaimScrapeJob = %v, %v", job, err)
+	}
+	bs.processJob(context.Background(), 0, "test-worker", *job)
+
+	jobs, err := db.GetSeriesScrapeJobs(series.ID, 1)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("GetSeriesScrapeJobs = %v, %v", jobs, err)
+	}
+	if got := jobs[0]; got.Status != database.JobStatusFailed || got.ErrorMessage == nil || *got.ErrorMessage != "cancelled" {
+		t.Errorf("job = %+v, want it left cancelled rather than queued for a retry", got)
+	}
+	stats, err := db.GetAllSeriesStats()
+	if err != nil || len(stats) != 1 {
+		t.Fatalf("GetAllSeriesStats = %v, %v", stats, err)
+	}
+	if stats[0].AudibleStaleSince != nil {
+		t.Error("series marked stale by a scrape that no longer held its job")
+	}
+	select {
+	case update := <-bs.GetUpdateChannel():
+		t.Errorf("update = %+v, want none for a cancelled job", update)
+	default:
+	}
+}
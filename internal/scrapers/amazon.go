@@ -2,6 +2,7 @@ package scrapers
 
 import (
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,21 +23,34 @@ type AmazonPAAPIProvider struct {
 }
 
 // Fetch retrieves series information using Amazon PA-API
-func (p *AmazonPAAPIProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, error) {
+func (p *AmazonPAAPIProvider) Fetch(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error) {
 	if !p.Enabled {
 		return models.SeriesInfo{Title: e.Title}, nil
 	}
 	return models.SeriesInfo{Title: e.Title}, nil
 }
 
+// DefaultAmazonBaseURL is the Amazon storefront scraped when BaseURL is unset
+const DefaultAmazonBaseURL = "https://www.amazon.com"
+
 // AmazonScraperProvider implements the Amazon web scraper
 type AmazonScraperProvider struct {
 	Enabled bool
 	Client  *http.Client
+	BaseURL string       // Storefront root, overridable for tests (default: DefaultAmazonBaseURL)
+	Limiter *RateLimiter // Shared request pacing for amazon.com, nil disables it
+}
+
+// baseURL returns the configured storefront root without a trailing slash
+func (p *AmazonScraperProvider) baseURL() string {
+	if p.BaseURL == "" {
+		return DefaultAmazonBaseURL
+	}
+	return strings.TrimRight(p.BaseURL, "/")
 }
 
 // Fetch retrieves series information by scraping Amazon pages
-func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, error) {
+func (p *AmazonScraperProvider) Fetch(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error) {
 	// Explicitly clear all output fields to prevent variable reuse between scraping loops
 	out := models.SeriesInfo{
 		Title:             e.Title,
@@ -53,18 +67,20 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 
 	amzURL := utils.ExtractURLFromMarkdownLink(e.Original.Amazon)
 	if amzURL == "" && e.AmazonASIN != "" {
-		amzURL = fmt.Sprintf("https://www.amazon.com/dp/%s", e.AmazonASIN)
+		amzURL = fmt.Sprintf("%s/dp/%s", p.baseURL(), e.AmazonASIN)
 	}
 	if amzURL == "" {
 		return out, nil
 	}
 	
-	// Add delay to avoid rate limiting (random between 1-3 seconds)
-	time.Sleep(time.Duration(400+time.Now().UnixNano()%600) * time.Millisecond)
+	// Wait for our turn so concurrent workers don't trigger bot detection
+	if err := p.Limiter.Wait(ctx); err != nil {
+		return out, requestError("amazon", amzURL, err)
+	}
 
-	req, err := http.NewRequest("GET", amzURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", amzURL, nil)
 	if err != nil {
-		return out, nil // Return empty data instead of error
+		return out, fmt.Errorf("amazon: invalid series URL %q: %w", amzURL, err)
 	}
 	// Set comprehensive headers to mimic a real browser and avoid bot detection
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36")
@@ -87,11 +103,11 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 
 	resp, err := p.Client.Do(req)
 	if err != nil {
-		return out, nil // Return empty data instead of error
+		return out, requestError("amazon", amzURL, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return out, nil // Return empty data instead of error
+		return out, statusError("amazon", amzURL, resp.StatusCode)
 	}
 
 	// Handle gzip compression
@@ -100,7 +116,7 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 		gzipReader, err := gzip.NewReader(resp.Body)
 		if err != nil {
 			log.Printf("Amazon scraper: failed to create gzip reader for %s: %v", e.Title, err)
-			return out, nil // Return empty data instead of error
+			return out, &models.ScrapeError{Provider: "amazon", Category: models.ErrorParse, URL: amzURL, StatusCode: resp.StatusCode, Err: err}
 		}
 		defer gzipReader.Close()
 		reader = gzipReader
@@ -109,7 +125,7 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 	body, err := io.ReadAll(reader)
 	if err != nil {
 		log.Printf("Amazon scraper: failed to read response body for %s: %v", e.Title, err)
-		return out, nil // Return empty data instead of error
+		return out, requestError("amazon", amzURL, err)
 	}
 	html := string(body)
 
@@ -166,7 +182,7 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 		log.Printf("Count: 0")
 		log.Printf("Next: blocked")
 		log.Printf("Latest: blocked")
-		return out, nil
+		return out, pageError("amazon", models.ErrorBlocked, amzURL, "CAPTCHA page returned")
 	}
 	
 	// Step 1: Search for book count in multiple patterns
@@ -217,7 +233,7 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 	}
 
 	// Step 3: Find book elements - use only the most reliable pattern
-	bookTitlePattern := `(?is)<a\s+id=["']itemBookTitle_(\d+)["'][^>]*href=["']([^"']+)["'][^>]*>`
+	bookTitlePattern := `(?is)<a\s+id=["']itemBookTitle_(\d+)["'][^>]*href=["']([^"']+)["'][^>]*>(.*?)</a>`
 	reBookTitle := regexp.MustCompile(bookTitlePattern)
 	bookMatches := reBookTitle.FindAllStringSubmatch(html, -1)
 	
@@ -246,11 +262,11 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 		asinRe := regexp.MustCompile(asinPattern)
 		if asinMatch := asinRe.FindStringSubmatch(targetBookURL); len(asinMatch) == 2 {
 			asin := asinMatch[1]
-			fullURL := "https://www.amazon.com/gp/product/" + asin
+			fullURL := p.baseURL() + "/gp/product/" + asin
 			log.Printf("Cleaned URL: %s", fullURL)
 			
 			// Extract publication date from the book page
-			if date := p.extractPublicationDate(fullURL); date != nil {
+			if date := p.extractPublicationDate(ctx, fullURL); date != nil {
 				out.AmazonLatestDate = date
 				log.Printf("Latest: %s", date.Format("2006-01-02"))
 			} else {
@@ -270,14 +286,21 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 			targetURL := "/gp/product/" + asinMatch[1]
 			log.Printf("Amazon scraper: extracted ASIN %s, fetching %s", asinMatch[1], targetURL)
 			
-			if date := p.extractPublicationDate(targetURL); date != nil {
+			if date := p.extractPublicationDate(ctx, targetURL); date != nil {
 				out.AmazonLatestDate = date
 				log.Printf("Amazon scraper: found latest publication date for %s: %s", e.Title, date.Format("2006-01-02"))
 			}
 			
+			if err := ctx.Err(); err != nil {
+				return out, requestError("amazon", amzURL, err)
+			}
+			
 			// Don't override count here - let it stay 0 if not found via normal means
 			log.Printf("Amazon scraper: completed %s via URL fallback - Count: %d, Latest: %v, Next: %v", 
 				e.Title, out.AmazonCount, out.AmazonLatestDate, out.AmazonNextDate)
+			if out.AmazonLatestDate == nil && out.AmazonCount == 0 {
+				return out, pageError("amazon", models.ErrorParse, amzURL, "no publication date found on product page")
+			}
 			return out, nil
 		}
 	}
@@ -296,7 +319,7 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 		
 		log.Printf("Amazon scraper: extracting publication date from %s", targetURL)
 		// Navigate to individual book page and extract publication date
-		if date := p.extractPublicationDate(targetURL); date != nil {
+		if date := p.extractPublicationDate(ctx, targetURL); date != nil {
 			out.AmazonLatestDate = date
 			log.Printf("Amazon scraper: latest release date for %s: %s", e.Title, date.Format("2006-01-02"))
 		} else {
@@ -306,6 +329,28 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 		log.Printf("Amazon scraper: no book links found for %s", e.Title)
 	}
 
+	// A cancelled book page fetch leaves the dates incomplete, so don't report partial data
+	if err := ctx.Err(); err != nil {
+		return out, requestError("amazon", amzURL, err)
+	}
+
+	// Build the per-book catalog from the series listing. Only the latest and
+	// preorder book pages are fetched, so the remaining books carry no date.
+	out.AmazonBooks = amazonBooksFromMatches(bookMatches)
+	if n := len(out.AmazonBooks); n > 0 {
+		latestIdx := n - 1
+		if hasPreorder && n >= 2 {
+			next := &out.AmazonBooks[n-1]
+			next.IsPreorder = true
+			next.ReleaseDate = out.AmazonNextDate
+			out.AmazonNextTitle = next.Title
+			latestIdx = n - 2
+		}
+		latestBook := &out.AmazonBooks[latestIdx]
+		latestBook.ReleaseDate = out.AmazonLatestDate
+		out.AmazonLatestTitle = latestBook.Title
+	}
+
 	// Log final assigned values
 	var latest, next string
 	if out.AmazonLatestDate != nil {
@@ -318,23 +363,48 @@ func (p *AmazonScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, er
 	} else {
 		next = "none"
 	}
-	log.Printf("Amazon %s: count=%d, latest=%s, next=%s", e.Title, out.AmazonCount, latest, next)
+	log.Printf("Amazon %s: count=%d, books=%d, latest=%s, next=%s", e.Title, out.AmazonCount, len(out.AmazonBooks), latest, next)
 	
+	if out.AmazonCount == 0 && out.AmazonLatestDate == nil && out.AmazonNextDate == nil {
+		return out, pageError("amazon", models.ErrorParse, amzURL, "no books found on series page")
+	}
 	return out, nil
 }
 
-func (p *AmazonScraperProvider) extractPublicationDate(bookURL string) *time.Time {
+// amazonBooksFromMatches converts itemBookTitle_N anchors into books, using N
+// as the position in the series
+func amazonBooksFromMatches(matches [][]string) []models.Book {
+	var books []models.Book
+	for _, m := range matches {
+		if len(m) != 4 {
+			continue
+		}
+		title := cleanText(m[3])
+		if title == "" {
+			continue
+		}
+		books = append(books, models.Book{
+			Title:    title,
+			Position: parsePosition(m[1]),
+			ASIN:     extractProductASIN(m[2]),
+		})
+	}
+	return books
+}
+
+func (p *AmazonScraperProvider) extractPublicationDate(ctx context.Context, bookURL string) *time.Time {
 	// Handle relative URLs by prepending Amazon domain
 	if strings.HasPrefix(bookURL, "/") {
-		bookURL = "https://www.amazon.com" + bookURL
+		bookURL = p.baseURL() + bookURL
 	}
 	
 	log.Printf("Amazon scraper: fetching publication date from %s", bookURL)
 	
-	// Add delay to avoid rate limiting
-	time.Sleep(time.Duration(400+time.Now().UnixNano()%600) * time.Millisecond)
+	if err := p.Limiter.Wait(ctx); err != nil {
+		return nil
+	}
 	
-	req, err := http.NewRequest("GET", bookURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", bookURL, nil)
 	if err != nil {
 		log.Printf("Amazon scraper: failed to create request for book page: %v", err)
 		return nil
@@ -441,7 +511,7 @@ func parseStructuredData(data map[string]interface{}, title string) (count int,
 	log.Printf("Amazon scraper: parsing structured data for %s", title)
 	
 	// Look for book series information in the JSON structure
-	if bookType, ok := data["@type"].(string); ok && bookType == "Book" {
+	if bookType, ok := data["@type"].(string); ok && (bookType == "Book" || bookType == "Audiobook") {
 		log.Printf("Amazon scraper: found Book type in structured data")
 		
 		// Try to find publication date
@@ -1,6 +1,7 @@
 package scrapers
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -13,14 +14,27 @@ import (
 	"github.com/michaeldvinci/syllabus/internal/models"
 )
 
+// DefaultAudibleBaseURL is the Audible storefront scraped when BaseURL is unset
+const DefaultAudibleBaseURL = "https://www.audible.com"
+
 // AudibleScraperProvider implements the Audible web scraper
 type AudibleScraperProvider struct {
 	Enabled bool
 	Client  *http.Client
+	BaseURL string       // Storefront root, overridable for tests (default: DefaultAudibleBaseURL)
+	Limiter *RateLimiter // Shared request pacing for audible.com, nil disables it
+}
+
+// baseURL returns the configured storefront root without a trailing slash
+func (p *AudibleScraperProvider) baseURL() string {
+	if p.BaseURL == "" {
+		return DefaultAudibleBaseURL
+	}
+	return strings.TrimRight(p.BaseURL, "/")
 }
 
 // Fetch retrieves series information by scraping Audible pages
-func (p *AudibleScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, error) {
+func (p *AudibleScraperProvider) Fetch(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error) {
 	// Explicitly clear all output fields to prevent variable reuse between scraping loops
 	out := models.SeriesInfo{
 		Title:              e.Title,
@@ -37,34 +51,43 @@ func (p *AudibleScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, e
 
 	seriesURL := e.AudibleURL
 	if seriesURL == "" && e.AudibleID != "" {
-		seriesURL = fmt.Sprintf("https://www.audible.com/series/%s", e.AudibleID)
+		seriesURL = fmt.Sprintf("%s/series/%s", p.baseURL(), e.AudibleID)
 	}
 	if seriesURL == "" {
 		return out, nil
 	}
 
-	req, err := http.NewRequest("GET", seriesURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", seriesURL, nil)
 	if err != nil {
-		return out, nil // Return empty data instead of error
+		return out, fmt.Errorf("audible: invalid series URL %q: %w", seriesURL, err)
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (SeriesTracker/1.0; +local)")
 
+	if err := p.Limiter.Wait(ctx); err != nil {
+		return out, requestError("audible", seriesURL, err)
+	}
 	resp, err := p.Client.Do(req)
 	if err != nil {
-		return out, nil // Return empty data instead of error
+		return out, requestError("audible", seriesURL, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return out, nil // Return empty data instead of error
+		return out, statusError("audible", seriesURL, resp.StatusCode)
 	}
 
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return out, nil // Return empty data instead of error
+		return out, requestError("audible", seriesURL, err)
 	}
 	html := string(b)
 	lower := strings.ToLower(html)
 
+	// Audible serves the same bot check as Amazon when it throttles a client
+	if strings.Contains(html, "validateCaptcha") || strings.Contains(lower, "enter the characters you see below") {
+		log.Printf("Audible CAPTCHA detected for %s - bot detection triggered", e.Title)
+		return out, pageError("audible", models.ErrorBlocked, seriesURL, "CAPTCHA page returned")
+	}
+
 	// Count books using the most reliable pattern first
 	out.AudibleCount = strings.Count(lower, "productlistitem")
 	
@@ -182,6 +205,42 @@ func (p *AudibleScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, e
 		}
 	}
 	
+	// Prefer per-book data from the product list when it carries release dates
+	out.AudibleBooks = parseAudibleBooks(html)
+	markPreorders(out.AudibleBooks, time.Now())
+	if latestBook, nextBook := summarizeBooks(out.AudibleBooks); latestBook != nil || nextBook != nil {
+		out.AudibleLatestTitle, out.AudibleLatestDate = "", nil
+		out.AudibleNextTitle, out.AudibleNextDate = "", nil
+		if latestBook != nil {
+			out.AudibleLatestTitle = latestBook.Title
+			out.AudibleLatestDate = latestBook.ReleaseDate
+		}
+		if nextBook != nil {
+			out.AudibleNextTitle = nextBook.Title
+			out.AudibleNextDate = nextBook.ReleaseDate
+		}
+	}
+	if len(out.AudibleBooks) > out.AudibleCount {
+		out.AudibleCount = len(out.AudibleBooks)
+	}
+
+	// Book pages and script-rendered pages carry no product list; fall back to JSON-LD
+	if out.AudibleCount == 0 {
+		if jsonData := extractJSONLD(html); jsonData != nil {
+			count, latest, next := parseStructuredData(jsonData, e.Title)
+			out.AudibleCount = count
+			if out.AudibleLatestDate == nil {
+				out.AudibleLatestDate = latest
+			}
+			if out.AudibleNextDate == nil {
+				out.AudibleNextDate = next
+			}
+		}
+	}
+	if out.AudibleCount == 0 && out.AudibleLatestDate == nil && out.AudibleNextDate == nil {
+		return out, pageError("audible", models.ErrorParse, seriesURL, "no books found on series page")
+	}
+
 	// Log final assigned values
 	var latest, next string
 	if out.AudibleLatestDate != nil {
@@ -194,7 +253,74 @@ func (p *AudibleScraperProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, e
 	} else {
 		next = "none"
 	}
-	log.Printf("Audible %s: count=%d, latest=%s, next=%s", e.Title, out.AudibleCount, latest, next)
+	log.Printf("Audible %s: count=%d, books=%d, latest=%s, next=%s", e.Title, out.AudibleCount, len(out.AudibleBooks), latest, next)
 
 	return out, nil
+}
+
+var (
+	audibleItemRe   = regexp.MustCompile(`(?i)<li[^>]*productListItem[^>]*>`)
+	audibleLabelRe  = regexp.MustCompile(`(?i)aria-label=["']([^"']+)["']`)
+	audibleTitleRe  = regexp.MustCompile(`(?is)<h3[^>]*>\s*<a[^>]*>(.*?)</a>`)
+	audibleItemIDRe = regexp.MustCompile(`(?i)(?:product-list-item-|data-asin=["'])([A-Z0-9]{10})`)
+	audibleDateRe   = regexp.MustCompile(`(?i)release\s*date:\s*([0-9]{1,2}-[0-9]{1,2}-[0-9]{2,4})`)
+)
+
+// parseAudibleBooks splits a series page into its product list items and
+// extracts the title, position, ASIN and release date of each book
+func parseAudibleBooks(html string) []models.Book {
+	starts := audibleItemRe.FindAllStringIndex(html, -1)
+	var books []models.Book
+	seen := make(map[string]bool)
+
+	for i, loc := range starts {
+		end := len(html)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		item := html[loc[0]:end]
+
+		var book models.Book
+		if m := audibleLabelRe.FindStringSubmatch(html[loc[0]:loc[1]]); len(m) == 2 {
+			book.Title = cleanText(m[1])
+		}
+		if book.Title == "" {
+			if m := audibleTitleRe.FindStringSubmatch(item); len(m) == 2 {
+				book.Title = cleanText(m[1])
+			}
+		}
+		if book.Title == "" {
+			continue
+		}
+
+		if m := audibleItemIDRe.FindStringSubmatch(item); len(m) == 2 {
+			book.ASIN = m[1]
+		} else {
+			book.ASIN = extractProductASIN(item)
+		}
+		if book.ASIN != "" {
+			if seen[book.ASIN] {
+				continue
+			}
+			seen[book.ASIN] = true
+		}
+
+		if m := bookPositionRe.FindStringSubmatch(item); len(m) == 2 {
+			book.Position = parsePosition(m[1])
+		}
+
+		if m := audibleDateRe.FindStringSubmatch(item); len(m) == 2 {
+			dateStr := strings.TrimSpace(m[1])
+			for _, layout := range []string{"01-02-06", "1-2-06", "01-02-2006", "1-2-2006"} {
+				if dt, err := time.Parse(layout, dateStr); err == nil {
+					book.ReleaseDate = &dt
+					break
+				}
+			}
+		}
+
+		books = append(books, book)
+	}
+
+	return books
 }
\ No newline at end of file
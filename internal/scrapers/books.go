@@ -0,0 +1,73 @@
+package scrapers
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+var (
+	tagRe          = regexp.MustCompile(`(?s)<[^>]*>`)
+	bookPositionRe = regexp.MustCompile(`(?i)>\s*Book\s+(\d+(?:\.\d+)?)\s*<`)
+	productASINRe  = regexp.MustCompile(`/(?:gp/product|dp|pd/[^"'?]*)/([A-Z0-9]{10})`)
+)
+
+// cleanText strips markup and entities from a scraped HTML fragment
+func cleanText(s string) string {
+	s = tagRe.ReplaceAllString(s, "")
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// parsePosition converts a "Book N" label to an integer position, returning 0
+// for fractional entries such as novellas (e.g. "Book 2.5")
+func parsePosition(label string) int {
+	n, err := strconv.Atoi(label)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// extractProductASIN pulls the ASIN out of an Audible or Amazon product link
+func extractProductASIN(href string) string {
+	if m := productASINRe.FindStringSubmatch(href); len(m) == 2 {
+		return m[1]
+	}
+	return ""
+}
+
+// markPreorders flags every book whose release date is after today
+func markPreorders(books []models.Book, now time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i := range books {
+		if books[i].ReleaseDate != nil && books[i].ReleaseDate.After(today) {
+			books[i].IsPreorder = true
+		}
+	}
+}
+
+// summarizeBooks returns the most recently released book and the earliest
+// upcoming preorder. Books without a release date are ignored.
+func summarizeBooks(books []models.Book) (latest, next *models.Book) {
+	for i := range books {
+		b := &books[i]
+		if b.ReleaseDate == nil {
+			continue
+		}
+		if b.IsPreorder {
+			if next == nil || b.ReleaseDate.Before(*next.ReleaseDate) {
+				next = b
+			}
+			continue
+		}
+		// Later entries win ties so the highest-numbered book is reported as latest
+		if latest == nil || !b.ReleaseDate.Before(*latest.ReleaseDate) {
+			latest = b
+		}
+	}
+	return latest, next
+}
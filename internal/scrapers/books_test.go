@@ -0,0 +1,108 @@
+package scrapers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+func day(s string) *time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return &d
+}
+
+func TestParseAudibleBooks(t *testing.T) {
+	page := `<ul>
+<li class="bc-list-item productListItem" aria-label="Book One: The Beginning">
+  <a href="/pd/Book-One/B0BOOK0001?ref=series">Listen</a>
+  <span>Book 1</span><span>Release date: 01-10-24</span>
+</li>
+<li class="bc-list-item productListItem" id="product-list-item-B0BOOK0015">
+  <h3 class="bc-heading"><a href="/pd/B0BOOK0015">A <b>Novella</b> &amp; More</a></h3>
+  <span>Book 2.5</span><span>Release date: 6-1-2024</span>
+</li>
+<li class="bc-list-item productListItem" aria-label="Book One: The Beginning (Repeat)" data-asin="B0BOOK0001">
+  <span>Book 1</span>
+</li>
+<li class="bc-list-item productListItem" aria-label="Book Three" data-asin="B0BOOK0003">
+  <span>Book 3</span><span>Release date: 03-01-2099</span>
+</li>
+<li class="bc-list-item productListItem"><span>No title here</span></li>
+</ul>`
+
+	want := []models.Book{
+		{Title: "Book One: The Beginning", Position: 1, ASIN: "B0BOOK0001", ReleaseDate: day("2024-01-10")},
+		{Title: "A Novella & More", Position: 0, ASIN: "B0BOOK0015", ReleaseDate: day("2024-06-01")},
+		{Title: "Book Three", Position: 3, ASIN: "B0BOOK0003", ReleaseDate: day("2099-03-01")},
+	}
+	got := parseAudibleBooks(page)
+	if len(got) != len(want) {
+		t.Fatalf("parsed %d books, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Title != w.Title || g.Position != w.Position || g.ASIN != w.ASIN ||
+			g.ReleaseDate == nil || !g.ReleaseDate.Equal(*w.ReleaseDate) {
+			t.Errorf("book %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestAmazonBooksFromMatches(t *testing.T) {
+	matches := [][]string{
+		{"", "1", "/Book-One/dp/B0AMZ00001?ref=series", "Book One"},
+		{"", "2", "/gp/product/B0AMZ00002", " <span>Book   Two</span> "},
+		{"", "x", "/search?q=three", "Book Three"},
+		{"", "4", "/dp/B0AMZ00004", "<span></span>"},
+		{"", "5", "/dp/B0AMZ00005"},
+	}
+	want := []models.Book{
+		{Title: "Book One", Position: 1, ASIN: "B0AMZ00001"},
+		{Title: "Book Two", Position: 2, ASIN: "B0AMZ00002"},
+		{Title: "Book Three", Position: 0, ASIN: ""},
+	}
+	got := amazonBooksFromMatches(matches)
+	if len(got) != len(want) {
+		t.Fatalf("got %d books, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("book %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSummarizeBooks(t *testing.T) {
+	now := time.Date(2024, 6, 1, 15, 0, 0, 0, time.UTC)
+	books := []models.Book{
+		{Title: "Book One", Position: 1, ReleaseDate: day("2024-01-10")},
+		{Title: "Book Two", Position: 2, ReleaseDate: day("2024-06-01")},
+		{Title: "Boxed Set", ReleaseDate: day("2024-06-01")},
+		{Title: "Book Four", Position: 4, ReleaseDate: day("2025-03-01")},
+		{Title: "Book Three", Position: 3, ReleaseDate: day("2024-09-01")},
+		{Title: "Book Five", Position: 5},
+	}
+	markPreorders(books, now)
+
+	for i, preorder := range []bool{false, false, false, true, true, false} {
+		if books[i].IsPreorder != preorder {
+			t.Errorf("%s preorder = %v, want %v", books[i].Title, books[i].IsPreorder, preorder)
+		}
+	}
+
+	// Ties go to the later entry; books without a date are ignored
+	latest, next := summarizeBooks(books)
+	if latest == nil || latest.Title != "Boxed Set" {
+		t.Errorf("latest = %+v, want Boxed Set", latest)
+	}
+	if next == nil || next.Title != "Book Three" {
+		t.Errorf("next = %+v, want Book Three", next)
+	}
+	if latest, next := summarizeBooks(nil); latest != nil || next != nil {
+		t.Errorf("summarizeBooks(nil) = %v, %v", latest, next)
+	}
+}
@@ -1,6 +1,7 @@
 package scrapers
 
 import (
+	"context"
 	"sync"
 
 	"github.com/michaeldvinci/syllabus/internal/models"
@@ -12,7 +13,7 @@ type CompositeProvider struct {
 }
 
 // Fetch runs all providers concurrently and merges their results
-func (c *CompositeProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, error) {
+func (c *CompositeProvider) Fetch(ctx context.Context, e models.SeriesIDs) (models.SeriesInfo, error) {
 	out := models.SeriesInfo{Title: e.Title, AudibleID: e.AudibleID, AmazonASIN: e.AmazonASIN}
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -22,7 +23,7 @@ func (c *CompositeProvider) Fetch(e models.SeriesIDs) (models.SeriesInfo, error)
 		wg.Add(1)
 		go func(p models.Provider) {
 			defer wg.Done()
-			info, err := p.Fetch(e)
+			info, err := p.Fetch(ctx, e)
 			mu.Lock()
 			if err != nil && firstErr == nil {
 				firstErr = err
@@ -43,6 +44,12 @@ func mergeSeriesInfo(dst, src *models.SeriesInfo) {
 	if src.AmazonCount > dst.AmazonCount {
 		dst.AmazonCount = src.AmazonCount
 	}
+	if len(src.AudibleBooks) > 0 {
+		dst.AudibleBooks = src.AudibleBooks
+	}
+	if len(src.AmazonBooks) > 0 {
+		dst.AmazonBooks = src.AmazonBooks
+	}
 	if src.AudibleLatestTitle != "" || src.AudibleLatestDate != nil {
 		dst.AudibleLatestTitle = src.AudibleLatestTitle
 		dst.AudibleLatestDate = src.AudibleLatestDate
@@ -0,0 +1,50 @@
+package scrapers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+// requestError classifies a failed HTTP round trip as a timeout or network error
+func requestError(provider, url string, err error) error {
+	category := models.ErrorNetwork
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		category = models.ErrorTimeout
+	}
+	return &models.ScrapeError{Provider: provider, Category: category, URL: url, Err: err}
+}
+
+// statusError classifies a non-200 response
+func statusError(provider, url string, code int) error {
+	category := models.ErrorHTTPStatus
+	switch code {
+	case http.StatusNotFound, http.StatusGone:
+		category = models.ErrorNotFound
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		category = models.ErrorBlocked
+	}
+	return &models.ScrapeError{
+		Provider:   provider,
+		Category:   category,
+		URL:        url,
+		StatusCode: code,
+		Err:        fmt.Errorf("unexpected status %d from %s", code, url),
+	}
+}
+
+// pageError reports a page that loaded but was unusable
+func pageError(provider string, category models.ErrorCategory, url, msg string) error {
+	return &models.ScrapeError{
+		Provider:   provider,
+		Category:   category,
+		URL:        url,
+		StatusCode: http.StatusOK,
+		Err:        fmt.Errorf("%s (%s)", msg, url),
+	}
+}
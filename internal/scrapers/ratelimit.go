@@ -0,0 +1,73 @@
+package scrapers
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces out requests to a single provider host. One limiter is
+// shared by every worker using a provider, so adding workers doesn't raise
+// the request rate.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time // Earliest time the next request may start
+}
+
+// NewRateLimiter creates a limiter allowing one request per interval, plus up
+// to half an interval of random jitter. A zero interval disables limiting;
+// settings never produce one, as an unset or zero rate limit means the default.
+func NewRateLimiter(interval time.Duration) *RateLimiter {
+	return &RateLimiter{interval: interval}
+}
+
+// Wait blocks until the caller may send its next request, or ctx is done. A
+// nil limiter never waits. A caller whose ctx is already done gets no slot,
+// and one that gives up waiting hands its slot back if nobody queued behind it.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l == nil || l.interval <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	slot, next := l.reserve(now)
+	timer := time.NewTimer(slot.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.release(slot, next)
+		return ctx.Err()
+	}
+}
+
+// reserve books the next free request slot as seen at now and returns it,
+// along with when the slot after it opens
+func (l *RateLimiter) reserve(now time.Time) (slot, next time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slot = l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval + rand.N(l.interval/2+1))
+	return slot, l.next
+}
+
+// release returns an unused slot from reserve. Slots booked after it keep
+// their place, so it's only reused when it was the last one booked.
+func (l *RateLimiter) release(slot, next time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.next.Equal(next) {
+		l.next = slot
+	}
+}
@@ -0,0 +1,99 @@
+package scrapers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	const interval = time.Second
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		advance time.Duration // Fake clock movement before the reservation
+		wantMin time.Duration // Earliest slot, relative to the previous one (or start)
+		wantMax time.Duration // Latest slot, relative to the previous one (or start)
+	}{
+		{"first request goes straight away", 0, 0, 0},
+		{"burst waits a full interval", 0, interval, interval + interval/2},
+		{"burst keeps spacing", 0, interval, interval + interval/2},
+		{"partway through the wait", interval / 2, interval, interval + interval/2},
+		{"idle limiter goes straight away", time.Minute, 0, 0},
+	}
+
+	l := NewRateLimiter(interval)
+	now, prev := start, start
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+		if tt.wantMin == 0 && tt.wantMax == 0 {
+			prev = now
+		}
+		slot, _ := l.reserve(now)
+		if gap := slot.Sub(prev); gap < tt.wantMin || gap > tt.wantMax {
+			t.Errorf("%s: slot %s after the previous one, want within [%s, %s]", tt.name, gap, tt.wantMin, tt.wantMax)
+		}
+		if slot.Before(now) {
+			t.Errorf("%s: slot %s is before now", tt.name, slot.Sub(now))
+		}
+		prev = slot
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiters := map[string]*RateLimiter{
+		"nil":           nil,
+		"zero interval": NewRateLimiter(0),
+	}
+	for name, l := range limiters {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if err := l.Wait(context.Background()); err != nil {
+					t.Fatalf("Wait: %v", err)
+				}
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if err := l.Wait(ctx); err != context.Canceled {
+				t.Errorf("Wait on a cancelled context = %v, want context.Canceled", err)
+			}
+		})
+	}
+}
+
+func TestRateLimiterAbandonedWait(t *testing.T) {
+	l := NewRateLimiter(time.Hour)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+	booked := l.next
+
+	// A caller that's already cancelled doesn't book a slot
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait on a cancelled context = %v, want context.Canceled", err)
+	}
+	if !l.next.Equal(booked) {
+		t.Errorf("cancelled caller moved the next slot by %s", l.next.Sub(booked))
+	}
+
+	// One that gives up waiting hands its slot back
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait past the deadline = %v, want context.DeadlineExceeded", err)
+	}
+	if !l.next.Equal(booked) {
+		t.Errorf("abandoned wait moved the next slot by %s", l.next.Sub(booked))
+	}
+
+	// Unless someone booked the slot after it in the meantime
+	slot, next := l.reserve(time.Now())
+	later, _ := l.reserve(time.Now())
+	l.release(slot, next)
+	if l.next.Before(later) {
+		t.Errorf("releasing an earlier slot moved the next one to before %s", later)
+	}
+}
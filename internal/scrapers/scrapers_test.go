@@ -0,0 +1,199 @@
+package scrapers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+// Regenerate the golden files with: go test ./internal/scrapers -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// fixtureRoutes maps request paths on the fake storefront to saved pages in testdata
+var fixtureRoutes = map[string]string{
+	"/series/B0AUDSERIE":     "audible_series.html",
+	"/pd/B0AUD00001":         "audible_book.html",
+	"/series/B0AUDCAPTC":     "audible_captcha.html",
+	"/series/B0AUDJSONL":     "audible_jsonld.html",
+	"/dp/B0AMZSERIE":         "amazon_series.html",
+	"/dp/B0AMZ00002":         "amazon_book.html",
+	"/gp/product/B0AMZ00002": "amazon_book.html",
+	"/dp/B0AMZCAPTC":         "amazon_captcha.html",
+	"/dp/B0AMZJSONL":         "amazon_jsonld.html",
+}
+
+// newFixtureServer serves the saved provider pages over HTTP
+func newFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := fixtureRoutes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// scraped holds the fields a provider fills from a page; goldens record only these
+// so adding unrelated SeriesInfo fields doesn't invalidate them
+type scraped struct {
+	Count       int
+	LatestTitle string
+	LatestDate  *time.Time
+	NextTitle   string
+	NextDate    *time.Time
+	Books       []models.Book
+}
+
+// audibleScraped picks the Audible fields out of info
+func audibleScraped(info models.SeriesInfo) scraped {
+	return scraped{info.AudibleCount, info.AudibleLatestTitle, info.AudibleLatestDate,
+		info.AudibleNextTitle, info.AudibleNextDate, info.AudibleBooks}
+}
+
+// amazonScraped picks the Amazon fields out of info
+func amazonScraped(info models.SeriesInfo) scraped {
+	return scraped{info.AmazonCount, info.AmazonLatestTitle, info.AmazonLatestDate,
+		info.AmazonNextTitle, info.AmazonNextDate, info.AmazonBooks}
+}
+
+// checkGolden compares the scraped fields against testdata/golden/<name>.json, rewriting it with -update
+func checkGolden(t *testing.T, name string, fields scraped) {
+	t.Helper()
+	got, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal %s: %v", name, err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("write golden %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden %s (run with -update to create it): %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match golden file %s\ngot:\n%s\nwant:\n%s", name, path, got, want)
+	}
+}
+
+func TestAudibleScraperFixtures(t *testing.T) {
+	srv := newFixtureServer(t)
+	p := &AudibleScraperProvider{Enabled: true, Client: srv.Client(), BaseURL: srv.URL}
+
+	tests := []struct {
+		name     string
+		entry    models.SeriesIDs
+		category models.ErrorCategory // Expected error category, "" for success
+	}{
+		{"audible_series", models.SeriesIDs{Title: "The Example Saga", AudibleID: "B0AUDSERIE"}, ""},
+		{"audible_book", models.SeriesIDs{Title: "The First Example", AudibleURL: srv.URL + "/pd/B0AUD00001"}, ""},
+		{"audible_captcha", models.SeriesIDs{Title: "Blocked Series", AudibleID: "B0AUDCAPTC"}, models.ErrorBlocked},
+		{"audible_jsonld", models.SeriesIDs{Title: "Structured Series", AudibleID: "B0AUDJSONL"}, ""},
+		{"audible_not_found", models.SeriesIDs{Title: "Missing Series", AudibleID: "B0MISSING0"}, models.ErrorNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := p.Fetch(context.Background(), tt.entry)
+			if got := models.CategoryOf(err); got != tt.category {
+				t.Fatalf("Fetch error category = %q, want %q (err: %v)", got, tt.category, err)
+			}
+			if tt.category == "" && err != nil {
+				t.Fatalf("Fetch returned error: %v", err)
+			}
+			checkGolden(t, tt.name, audibleScraped(info))
+		})
+	}
+}
+
+func TestAmazonScraperFixtures(t *testing.T) {
+	srv := newFixtureServer(t)
+	p := &AmazonScraperProvider{Enabled: true, Client: srv.Client(), BaseURL: srv.URL}
+
+	tests := []struct {
+		name     string
+		entry    models.SeriesIDs
+		category models.ErrorCategory // Expected error category, "" for success
+	}{
+		{"amazon_series", models.SeriesIDs{Title: "The Example Saga", AmazonASIN: "B0AMZSERIE"}, ""},
+		{"amazon_book", models.SeriesIDs{Title: "Another Example", AmazonASIN: "B0AMZ00002"}, ""},
+		{"amazon_captcha", models.SeriesIDs{Title: "Blocked Series", AmazonASIN: "B0AMZCAPTC"}, models.ErrorBlocked},
+		{"amazon_jsonld", models.SeriesIDs{Title: "Structured Series", AmazonASIN: "B0AMZJSONL"}, ""},
+		{"amazon_not_found", models.SeriesIDs{Title: "Missing Series", AmazonASIN: "B0MISSING0"}, models.ErrorNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := p.Fetch(context.Background(), tt.entry)
+			if got := models.CategoryOf(err); got != tt.category {
+				t.Fatalf("Fetch error category = %q, want %q (err: %v)", got, tt.category, err)
+			}
+			if tt.category == "" && err != nil {
+				t.Fatalf("Fetch returned error: %v", err)
+			}
+			checkGolden(t, tt.name, amazonScraped(info))
+		})
+	}
+}
+
+func TestFetchCancelled(t *testing.T) {
+	// A storefront that never answers; only cancellation can end the request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	providers := map[string]models.Provider{
+		"audible": &AudibleScraperProvider{Enabled: true, Client: srv.Client(), BaseURL: srv.URL},
+		"amazon":  &AmazonScraperProvider{Enabled: true, Client: srv.Client(), BaseURL: srv.URL},
+		// The limiter's next slot is an hour out, so Wait must give up on cancel
+		"amazon_limited": &AmazonScraperProvider{Enabled: true, Client: srv.Client(), BaseURL: srv.URL,
+			Limiter: &RateLimiter{interval: time.Hour, next: time.Now().Add(time.Hour)}},
+	}
+	entry := models.SeriesIDs{Title: "Slow Series", AudibleID: "B0SLOWSERI", AmazonASIN: "B0SLOWSERI"}
+
+	for name, p := range providers {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+			_, err := p.Fetch(ctx, entry)
+			if err == nil {
+				t.Fatal("Fetch succeeded after cancellation")
+			}
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Fetch error = %v, want context.Canceled in the chain", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Fetch took %s to return after cancellation", elapsed)
+			}
+		})
+	}
+}
@@ -45,6 +45,32 @@ func ApplyEnvOverrides(settings *models.Settings) {
 		}
 	}
 	
+	// Max scrape attempts
+	if env := os.Getenv("SYLLABUS_MAX_SCRAPE_ATTEMPTS"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val > 0 {
+			settings.MaxScrapeAttempts = val
+		}
+	}
+	
+	// Provider rate limits
+	if env := os.Getenv("SYLLABUS_AUDIBLE_RATE_LIMIT_MS"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val > 0 {
+			settings.AudibleRateLimit = val
+		}
+	}
+	if env := os.Getenv("SYLLABUS_AMAZON_RATE_LIMIT_MS"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val > 0 {
+			settings.AmazonRateLimit = val
+		}
+	}
+	
+	// CAPTCHA cooldown
+	if env := os.Getenv("SYLLABUS_CAPTCHA_COOLDOWN"); env != "" {
+		if val, err := strconv.Atoi(env); err == nil && val > 0 {
+			settings.CaptchaCooldown = val
+		}
+	}
+	
 	// Log level
 	if env := os.Getenv("SYLLABUS_LOG_LEVEL"); env != "" {
 		normalized := strings.ToLower(strings.TrimSpace(env))
@@ -60,6 +86,52 @@ func ApplyEnvOverrides(settings *models.Settings) {
 			settings.MainView = normalized
 		}
 	}
+	
+	// Single sign-on
+	settings.DisablePasswordLogin = GetEnvBoolWithDefault("SYLLABUS_DISABLE_PASSWORD_LOGIN", settings.DisablePasswordLogin)
+	if env := os.Getenv("SYLLABUS_OIDC_ISSUER"); env != "" {
+		if settings.OIDC == nil {
+			settings.OIDC = &models.OIDCSettings{}
+		}
+		settings.OIDC.Issuer = env
+	}
+	if settings.OIDC != nil {
+		settings.OIDC.ClientID = GetEnvWithDefault("SYLLABUS_OIDC_CLIENT_ID", settings.OIDC.ClientID)
+		settings.OIDC.ClientSecret = GetEnvWithDefault("SYLLABUS_OIDC_CLIENT_SECRET", settings.OIDC.ClientSecret)
+		settings.OIDC.RedirectURL = GetEnvWithDefault("SYLLABUS_OIDC_REDIRECT_URL", settings.OIDC.RedirectURL)
+		settings.OIDC.DefaultRole = GetEnvWithDefault("SYLLABUS_OIDC_DEFAULT_ROLE", settings.OIDC.DefaultRole)
+	}
+	
+	// Reverse proxy authentication
+	if env := os.Getenv("SYLLABUS_PROXY_AUTH_TRUSTED_PROXIES"); env != "" {
+		if settings.ProxyAuth == nil {
+			settings.ProxyAuth = &models.ProxyAuthSettings{}
+		}
+		settings.ProxyAuth.TrustedProxies = strings.Split(env, ",")
+	}
+	if settings.ProxyAuth != nil {
+		settings.ProxyAuth.UserHeader = GetEnvWithDefault("SYLLABUS_PROXY_AUTH_USER_HEADER", settings.ProxyAuth.UserHeader)
+		settings.ProxyAuth.GroupsHeader = GetEnvWithDefault("SYLLABUS_PROXY_AUTH_GROUPS_HEADER", settings.ProxyAuth.GroupsHeader)
+		settings.ProxyAuth.DefaultRole = GetEnvWithDefault("SYLLABUS_PROXY_AUTH_DEFAULT_ROLE", settings.ProxyAuth.DefaultRole)
+		if env := os.Getenv("SYLLABUS_PROXY_AUTH_ADMIN_GROUPS"); env != "" {
+			settings.ProxyAuth.AdminGroups = strings.Split(env, ",")
+		}
+		if env := os.Getenv("SYLLABUS_PROXY_AUTH_EDITOR_GROUPS"); env != "" {
+			settings.ProxyAuth.EditorGroups = strings.Split(env, ",")
+		}
+	}
+
+	// Cross-origin protection
+	if env := os.Getenv("SYLLABUS_TRUSTED_ORIGINS"); env != "" {
+		settings.TrustedOrigins = strings.Split(env, ",")
+	}
+	if env := os.Getenv("SYLLABUS_TRUSTED_PROXIES"); env != "" {
+		settings.TrustedProxies = strings.Split(env, ",")
+	}
+
+	// Config file sync
+	settings.SyncDryRun = GetEnvBoolWithDefault("SYLLABUS_SYNC_DRY_RUN", settings.SyncDryRun)
+	settings.WriteBack = GetEnvBoolWithDefault("SYLLABUS_WRITE_BACK", settings.WriteBack)
 }
 
 // GetEnvWithDefault returns environment variable value or default if not set
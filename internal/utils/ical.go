@@ -26,37 +26,10 @@ func GenerateICal(infos []models.SeriesInfo) string {
 	}
 
 	for _, info := range infos {
-		// Add Audible next release events
-		if info.AudibleNextDate != nil {
-			title := info.AudibleNextTitle
-			if title == "" {
-				title = "Next audiobook release"
-			}
-			event := createEvent(
-				fmt.Sprintf("[AU] %s Releases", info.Title),
-				title,
-				*info.AudibleNextDate,
-				now,
-				fmt.Sprintf("audible-%s-%d", sanitizeForUID(info.Title), info.AudibleNextDate.Unix()),
-			)
-			events = append(events, event)
-		}
-
-		// Add Amazon next release events
-		if info.AmazonNextDate != nil {
-			title := info.AmazonNextTitle
-			if title == "" {
-				title = "Next ebook release"
-			}
-			event := createEvent(
-				fmt.Sprintf("[AM] %s Releases", info.Title),
-				title,
-				*info.AmazonNextDate,
-				now,
-				fmt.Sprintf("amazon-%s-%d", sanitizeForUID(info.Title), info.AmazonNextDate.Unix()),
-			)
-			events = append(events, event)
-		}
+		events = append(events, providerEvents("AU", "audible", "Next audiobook release", info.Title,
+			info.AudibleBooks, info.AudibleNextTitle, info.AudibleNextDate, now)...)
+		events = append(events, providerEvents("AM", "amazon", "Next ebook release", info.Title,
+			info.AmazonBooks, info.AmazonNextTitle, info.AmazonNextDate, now)...)
 	}
 
 	// Combine calendar header with events
@@ -66,6 +39,44 @@ func GenerateICal(infos []models.SeriesInfo) string {
 	return strings.Join(cal, "\r\n")
 }
 
+// providerEvents builds one event per upcoming book from a provider's catalog,
+// falling back to the single next release when no catalog was scraped
+func providerEvents(tag, provider, fallbackTitle, seriesTitle string, books []models.Book, nextTitle string, nextDate *time.Time, now time.Time) []string {
+	summary := fmt.Sprintf("[%s] %s Releases", tag, seriesTitle)
+	// Books are told apart by ASIN, else position, so two released on the
+	// same day don't share a UID; only a book with neither goes by its date
+	uidFor := func(b models.Book, d time.Time) string {
+		id := fmt.Sprintf("%s-%s", sanitizeForUID(b.Title), d.Format("20060102"))
+		switch {
+		case b.ASIN != "":
+			id = b.ASIN
+		case b.Position > 0:
+			id = fmt.Sprintf("book-%d", b.Position)
+		}
+		return fmt.Sprintf("%s-%s-%s", provider, sanitizeForUID(seriesTitle), id)
+	}
+
+	var events []string
+	for _, b := range books {
+		if !b.IsPreorder || b.ReleaseDate == nil {
+			continue
+		}
+		title := b.Title
+		if title == "" {
+			title = fallbackTitle
+		}
+		events = append(events, createEvent(summary, title, *b.ReleaseDate, now, uidFor(b, *b.ReleaseDate)))
+	}
+	if len(events) > 0 || nextDate == nil {
+		return events
+	}
+
+	if nextTitle == "" {
+		nextTitle = fallbackTitle
+	}
+	return []string{createEvent(summary, nextTitle, *nextDate, now, uidFor(models.Book{Title: nextTitle}, *nextDate))}
+}
+
 // createEvent creates a single VEVENT for iCal format
 func createEvent(title, description string, eventDate, createdDate time.Time, uid string) string {
 	// Format dates for iCal
@@ -200,4 +200,33 @@ func TestGenerateICalWithSpecialCharacters(t *testing.T) {
 	if !strings.Contains(ical, "Title\\; with\\, special\\ncharacters\\\\too") {
 		t.Error("Expected description to be properly escaped in iCal output")
 	}
-}
\ No newline at end of file
+}
+
+func TestGenerateICalUIDsPerBook(t *testing.T) {
+	release, _ := time.Parse("2006-01-02", "2099-12-25")
+
+	infos := []models.SeriesInfo{
+		{
+			Title: "Test Series",
+			AudibleBooks: []models.Book{
+				{Title: "Book Four", Position: 4, ASIN: "B0BOOK0004", ReleaseDate: &release, IsPreorder: true},
+				{Title: "Book Five", Position: 5, ASIN: "B0BOOK0005", ReleaseDate: &release, IsPreorder: true},
+				{Title: "Book Six", Position: 6, ReleaseDate: &release, IsPreorder: true},
+				{Title: "Side Story", ReleaseDate: &release, IsPreorder: true},
+			},
+		},
+	}
+
+	uids := make(map[string]bool)
+	for _, line := range strings.Split(GenerateICal(infos), "\r\n") {
+		if strings.HasPrefix(line, "UID:") {
+			if uids[line] {
+				t.Errorf("duplicate %s", line)
+			}
+			uids[line] = true
+		}
+	}
+	if len(uids) != 4 {
+		t.Errorf("got %d distinct UIDs, want one per book", len(uids))
+	}
+}
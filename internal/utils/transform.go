@@ -1,20 +1,99 @@
 package utils
 
-import "github.com/michaeldvinci/syllabus/internal/models"
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 
-// ToSeriesIDs converts a slice of Entry to a slice of SeriesIDs
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+// ToSeriesIDs converts a slice of Entry to a slice of SeriesIDs, logging
+// any overrides it has to ignore
 func ToSeriesIDs(entries []models.Entry) []models.SeriesIDs {
 	out := make([]models.SeriesIDs, 0, len(entries))
 	for _, e := range entries {
-		audURL := ExtractURLFromMarkdownLink(e.Audible)
-		amzURL := ExtractURLFromMarkdownLink(e.Amazon)
-		out = append(out, models.SeriesIDs{
-			Title:      e.Title,
-			AudibleID:  ExtractAudibleSeriesID(audURL),
-			AudibleURL: audURL,
-			AmazonASIN: ExtractAmazonASIN(amzURL),
-			Original:   e,
-		})
+		ids, err := EntryToSeriesIDs(e)
+		if err != nil {
+			log.Printf("ignoring override for %s: %v", e.Title, err)
+		}
+		out = append(out, ids)
 	}
 	return out
-}
\ No newline at end of file
+}
+
+// EntryToSeriesIDs converts one Entry. Invalid overrides are left out and
+// reported in the error.
+func EntryToSeriesIDs(e models.Entry) (models.SeriesIDs, error) {
+	audURL := ExtractURLFromMarkdownLink(e.Audible)
+	amzURL := ExtractURLFromMarkdownLink(e.Amazon)
+	audible, audErr := ParseOverride(e.AudNum, e.AudLast, e.AudNext)
+	amazon, amzErr := ParseOverride(e.AmznNum, e.AmznLast, e.AmznNext)
+	ids := models.SeriesIDs{
+		Title:           e.Title,
+		AudibleID:       ExtractAudibleSeriesID(audURL),
+		AudibleURL:      audURL,
+		AmazonASIN:      ExtractAmazonASIN(amzURL),
+		AmazonURL:       amzURL,
+		Original:        e,
+		AudibleOverride: audible,
+		AmazonOverride:  amazon,
+	}
+	switch {
+	case audErr != nil && amzErr != nil:
+		return ids, fmt.Errorf("audible %v; amazon %v", audErr, amzErr)
+	case audErr != nil:
+		return ids, fmt.Errorf("audible %v", audErr)
+	case amzErr != nil:
+		return ids, fmt.Errorf("amazon %v", amzErr)
+	}
+	return ids, nil
+}
+
+// ParseOverride reads an entry's pinned count and latest and next release
+// dates (YYYY-MM-DD). Empty values aren't pinned; invalid ones are left out
+// of the result and reported in the error.
+func ParseOverride(num any, last, next string) (models.Override, error) {
+	var o models.Override
+	var errs []string
+	switch n := num.(type) {
+	case nil:
+	case int:
+		o.Count = &n
+	case string:
+		if n = strings.TrimSpace(n); n != "" {
+			if count, err := strconv.Atoi(n); err == nil {
+				o.Count = &count
+			} else {
+				errs = append(errs, fmt.Sprintf("count %q isn't a number", n))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("count %v isn't a whole number", n))
+	}
+	if o.Count != nil && *o.Count < 0 {
+		errs = append(errs, fmt.Sprintf("count %d is negative", *o.Count))
+		o.Count = nil
+	}
+
+	parseDate := func(name, value string) *time.Time {
+		if value = strings.TrimSpace(value); value == "" {
+			return nil
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s date %q isn't YYYY-MM-DD", name, value))
+			return nil
+		}
+		return &date
+	}
+	o.LatestDate = parseDate("latest", last)
+	o.NextDate = parseDate("next", next)
+
+	if len(errs) > 0 {
+		return o, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return o, nil
+}
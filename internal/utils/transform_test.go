@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/michaeldvinci/syllabus/internal/models"
+)
+
+func TestParseOverride(t *testing.T) {
+	tests := []struct {
+		num        any
+		last, next string
+		want       string // Override.String() of the result
+		wantErr    bool
+	}{
+		{nil, "", "", ",,", false},
+		{7, "2024-01-10", " 2099-06-15 ", "7,2024-01-10,2099-06-15", false},
+		{"12", "", "", "12,,", false},
+		{"", "", "", ",,", false},
+		{"twelve", "", "2099-06-15", ",,2099-06-15", true},
+		{2.5, "", "", ",,", true},
+		{-1, "", "", ",,", true},
+		{3, "June 2024", "", "3,,", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseOverride(tt.num, tt.last, tt.next)
+		if got.String() != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseOverride(%v, %q, %q) = %s, %v; want %s, error %v", tt.num, tt.last, tt.next, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestToSeriesIDsReadsOverrides(t *testing.T) {
+	ids := ToSeriesIDs([]models.Entry{{
+		Title:    "The Example Saga",
+		Audible:  "https://www.audible.com/series/B0AUDSERIE",
+		AudNum:   5,
+		AmznNext: "2099-06-15",
+		AmznNum:  "not a number",
+	}})
+	if len(ids) != 1 || ids[0].AudibleOverride.String() != "5,," || ids[0].AmazonOverride.String() != ",,2099-06-15" {
+		t.Errorf("ToSeriesIDs = %+v", ids)
+	}
+}